@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// maxAuditBody is how much of a request body is read for the audit log; it
+// matches the limit of decodeJSON.
+const maxAuditBody = 1 << 20
+
+var patternWildcard = regexp.MustCompile(`\{(\w+)\}`)
+
+// auditParams returns the target and parameters of a mutating request for
+// the audit log. The target is the first path value of the route (e.g. the
+// bucket of DELETE /v1/buckets/{name}) or, for routes without one, the name,
+// access or bucket field of the JSON body. The remaining path values, query
+// parameters and body fields form the parameters; the body is left for the
+// handler to read.
+func auditParams(r *http.Request) (string, map[string]any) {
+	params := map[string]any{}
+	if r.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBody))
+		if err == nil {
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+			json.Unmarshal(body, &params)
+		}
+	}
+	for key, values := range r.URL.Query() {
+		if key == "confirm" {
+			continue // confirmation token, not a parameter
+		}
+		params[key] = strings.Join(values, ",")
+	}
+
+	target := ""
+	for i, m := range patternWildcard.FindAllStringSubmatch(r.Pattern, -1) {
+		if i == 0 {
+			target = r.PathValue(m[1])
+			continue
+		}
+		params[m[1]] = r.PathValue(m[1])
+	}
+	if target == "" {
+		for _, key := range []string{"name", "access", "bucket"} {
+			if v, ok := params[key].(string); ok && v != "" {
+				target = v
+				delete(params, key)
+				break
+			}
+		}
+	}
+	return target, params
+}
+
+// auditRecorder captures the status and error message of a response.
+type auditRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *auditRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *auditRecorder) Write(p []byte) (int, error) {
+	if r.status >= 400 && r.body.Len() < 4096 {
+		r.body.Write(p)
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// err returns the error of a failed response, from its {"error": "..."} body.
+func (r *auditRecorder) err() error {
+	if r.status < 400 {
+		return nil
+	}
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(r.body.Bytes(), &body) == nil && body.Error != "" {
+		return errors.New(body.Error)
+	}
+	return errors.New(http.StatusText(r.status))
+}
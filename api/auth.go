@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+var errInvalidToken = errors.New("invalid or missing bearer token")
+
+// authenticator maps bearer tokens to callers. It is built from the
+// configuration on every (re)load.
+type authenticator struct {
+	tokens []config.APIToken
+	jwt    *services.JWTVerifier // nil unless jwt is configured
+}
+
+func newAuthenticator(cfg *config.Config) (*authenticator, error) {
+	a := &authenticator{tokens: cfg.Tokens()}
+	if cfg.JWT != nil {
+		verifier, err := services.NewJWTVerifier(*cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = verifier
+	}
+	if len(a.tokens) == 0 && a.jwt == nil {
+		return nil, errors.New("apiToken, apiTokens or jwt is required")
+	}
+	return a, nil
+}
+
+// authenticate returns the caller a bearer token belongs to. Static tokens
+// are tried first; anything else is verified as a JWT if jwt is configured.
+// JWT callers are named "jwt:" followed by their name claim.
+func (a *authenticator) authenticate(ctx context.Context, bearer string) (*caller, error) {
+	var match *caller
+	// Compare against every token so the timing does not reveal which one matched.
+	for _, token := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(bearer), []byte(token.Token)) == 1 {
+			match = &caller{name: token.Name, Permissions: token.Permissions}
+		}
+	}
+	if match != nil {
+		return match, nil
+	}
+
+	if a.jwt == nil || bearer == "" || strings.Count(bearer, ".") != 2 {
+		return nil, errInvalidToken
+	}
+	identity, err := a.jwt.Verify(ctx, bearer)
+	if err != nil {
+		slog.Warn("JWT rejected", "error", err)
+		return nil, errInvalidToken
+	}
+	return &caller{name: "jwt:" + identity.Name, Permissions: identity.Permissions}, nil
+}
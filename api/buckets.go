@@ -1,31 +1,67 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/monobilisim/vgw-manager/models"
 	"github.com/monobilisim/vgw-manager/services"
 )
 
-// handleListBuckets returns the merged ZFS+API bucket list as JSON.
-func handleListBuckets(w http.ResponseWriter, _ *http.Request) {
-	buckets, err := services.ListMergedBuckets()
+// handleListBuckets returns the merged ZFS+API bucket list as JSON, limited to
+// the buckets the caller may see.
+// Repeated ?tag=key=value parameters restrict the list to buckets carrying all given tags.
+func handleListBuckets(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	filter, err := tagFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	buckets, err := services.ListMergedBuckets(r.Context(), cfg)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, buckets)
+	c := requestCaller(r)
+	visible := []models.Bucket{}
+	for _, b := range services.FilterBucketsByTags(buckets, filter) {
+		if c.AllowsBucket(b.Name) {
+			visible = append(visible, b)
+		}
+	}
+	writeJSON(w, http.StatusOK, visible)
+}
+
+// tagFilter collects the ?tag=key=value query parameters into a tag filter.
+func tagFilter(r *http.Request) (map[string]string, error) {
+	filter := map[string]string{}
+	for _, raw := range r.URL.Query()["tag"] {
+		tags, err := services.ParseTags(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag filter: %w", err)
+		}
+		for key, value := range tags {
+			filter[key] = value
+		}
+	}
+	return filter, nil
 }
 
 // createBucketRequest is the JSON body for POST /v1/buckets.
 type createBucketRequest struct {
-	Name  string `json:"name"`
-	Quota string `json:"quota"`
-	Owner string `json:"owner"`
+	Name  string            `json:"name"`
+	Quota string            `json:"quota"`
+	Owner string            `json:"owner"`
+	Tags  map[string]string `json:"tags"`
 }
 
-// handleCreateBucket creates a ZFS bucket and optionally sets its owner.
+// handleCreateBucket creates a ZFS bucket and optionally sets its owner and tags.
 func handleCreateBucket(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
 	var req createBucketRequest
 	if !decodeJSON(w, r, &req) {
 		return
@@ -34,42 +70,63 @@ func handleCreateBucket(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, errBucketNameQuotaRequired)
 		return
 	}
+	if !checkBucket(w, r, req.Name) || (req.Owner != "" && !checkUser(w, r, req.Owner)) {
+		return
+	}
 
 	bucketReq := models.BucketCreateRequest{
 		Name:  req.Name,
 		Quota: req.Quota,
 		Owner: req.Owner,
 	}
-	bucketService := services.NewBucketService()
-	if err := bucketService.CreateBucket(bucketReq); err != nil {
+	bucketService := services.NewBucketService(cfg)
+	if err := bucketService.CreateBucket(r.Context(), bucketReq); err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	vgwService := services.NewVersityGWService(cfg)
 	if req.Owner != "" {
-		vgwService := services.NewVersityGWService()
-		if err := vgwService.ChangeBucketOwner(req.Name, req.Owner); err != nil {
+		if err := services.ChangeBucketOwner(r.Context(), cfg, req.Name, req.Owner); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if len(req.Tags) > 0 {
+		if err := vgwService.PutBucketTagging(r.Context(), req.Name, req.Tags); err != nil {
 			writeError(w, http.StatusInternalServerError, err)
 			return
 		}
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]string{
+	writeJSON(w, http.StatusCreated, map[string]any{
 		"name":  req.Name,
 		"quota": req.Quota,
 		"owner": req.Owner,
+		"tags":  req.Tags,
 	})
 }
 
 // handleDeleteBucket deletes a bucket, trying ZFS first then API.
+// With ?force=true the bucket is emptied first; see handleForceDeleteBucket.
 func handleDeleteBucket(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("force") == "true" {
+		audited("bucket.delete", handleForceDeleteBucket)(w, r)
+		return
+	}
+	mutating("bucket.delete", deleteBucket)(w, r)
+}
+
+func deleteBucket(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
 	name := r.PathValue("name")
 	if name == "" {
 		writeError(w, http.StatusBadRequest, errBucketNameRequired)
 		return
 	}
 
-	via, err := services.DeleteBucketWithFallback(name)
+	via, err := services.DeleteBucketWithFallback(r.Context(), cfg, name)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
@@ -80,6 +137,78 @@ func handleDeleteBucket(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleForceDeleteBucket empties and deletes a bucket. It is a two-step call:
+// without a valid ?confirm token it answers 428 with a short-lived token bound
+// to this bucket, which must be sent back as ?confirm=<token> to proceed. The
+// token is not invalidated by use; it stays valid until it expires.
+//
+// Emptying only deletes objects through the S3 API and can take long, so
+// instead of holding mu throughout, the bucket is guarded by emptying and mu
+// is only taken to delete the emptied bucket.
+func handleForceDeleteBucket(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if !validConfirmToken(r.URL.Query().Get("confirm"), "force-delete-bucket", name, time.Now()) {
+		token, expires := newConfirmToken("force-delete-bucket", name, time.Now())
+		writeJSON(w, http.StatusPreconditionRequired, map[string]any{
+			"error":        fmt.Sprintf("force delete of bucket %q permanently removes all of its objects; repeat the request with ?force=true&confirm=<confirmToken>", name),
+			"confirmToken": token,
+			"expiresAt":    expires,
+		})
+		return
+	}
+
+	key := cfg.Site + "/" + name
+	if !emptying.start(key) {
+		writeError(w, http.StatusConflict, fmt.Errorf("bucket %q is already being force-deleted", name))
+		return
+	}
+	defer emptying.done(key)
+
+	// Emptying a large bucket outlasts the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	via, result, err := services.ForceDeleteBucket(r.Context(), cfg, name, services.EmptyOptions{DeleteLock: &mu})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{
+			"error":  err.Error(),
+			"result": result,
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bucket": name,
+		"via":    via,
+		"result": result,
+	})
+}
+
+// emptying holds the buckets (by site and name) being force-deleted, so that
+// a second force delete of the same bucket is refused.
+var emptying = &bucketSet{buckets: map[string]bool{}}
+
+type bucketSet struct {
+	mu      sync.Mutex
+	buckets map[string]bool
+}
+
+// start adds key to the set; it returns false if key is already in it.
+func (b *bucketSet) start(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.buckets[key] {
+		return false
+	}
+	b.buckets[key] = true
+	return true
+}
+
+func (b *bucketSet) done(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.buckets, key)
+}
+
 // makePublicRequest is the optional JSON body for POST /v1/buckets/{name}/public.
 type makePublicRequest struct {
 	Owner string `json:"owner"`
@@ -87,6 +216,7 @@ type makePublicRequest struct {
 
 // handleMakePublic makes a bucket public (read-only for everyone).
 func handleMakePublic(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
 	name := r.PathValue("name")
 	if name == "" {
 		writeError(w, http.StatusBadRequest, errBucketNameRequired)
@@ -97,7 +227,7 @@ func handleMakePublic(w http.ResponseWriter, r *http.Request) {
 	// Body is optional; ignore decode errors (empty body is fine).
 	_ = decodeJSON(w, r, &req)
 
-	if err := services.MakeBucketPublic(name, req.Owner); err != nil {
+	if err := services.MakeBucketPublic(r.Context(), cfg, name, req.Owner); err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -109,14 +239,14 @@ func handleMakePublic(w http.ResponseWriter, r *http.Request) {
 
 // handleMakePrivate removes the public policy from a bucket.
 func handleMakePrivate(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
 	name := r.PathValue("name")
 	if name == "" {
 		writeError(w, http.StatusBadRequest, errBucketNameRequired)
 		return
 	}
 
-	vgwService := services.NewVersityGWService()
-	if err := vgwService.DeleteBucketPolicy(name); err != nil {
+	if err := services.MakeBucketPrivate(r.Context(), cfg, name); err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -125,3 +255,73 @@ func handleMakePrivate(w http.ResponseWriter, r *http.Request) {
 		"status": "private",
 	})
 }
+
+// bucketTagsRequest is the JSON body for PUT /v1/buckets/{name}/tags.
+type bucketTagsRequest struct {
+	Tags map[string]string `json:"tags"`
+}
+
+// handleGetBucketTags returns the tags of a bucket.
+func handleGetBucketTags(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	vgwService := services.NewVersityGWService(cfg)
+	tags, err := vgwService.GetBucketTagging(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bucket": name,
+		"tags":   tags,
+	})
+}
+
+// handleSetBucketTags replaces the tags of a bucket. An empty tag set removes all tags.
+func handleSetBucketTags(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	var req bucketTagsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := services.SetBucketTags(r.Context(), cfg, name, req.Tags); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bucket": name,
+		"tags":   req.Tags,
+	})
+}
+
+// handleDeleteBucketTags removes all tags from a bucket.
+func handleDeleteBucketTags(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	vgwService := services.NewVersityGWService(cfg)
+	if err := vgwService.DeleteBucketTagging(r.Context(), name); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"bucket": name,
+		"status": "untagged",
+	})
+}
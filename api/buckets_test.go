@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+func TestForceDeleteReleasesLockWhileEmptying(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no zfs: the bucket is deleted through the API
+
+	listing := make(chan struct{})
+	release := make(chan struct{})
+	deleted := make(chan struct{}, 1)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Has("uploads"):
+			close(listing)
+			<-release
+			w.Write([]byte("<ListMultipartUploadsResult></ListMultipartUploadsResult>"))
+		case r.Method == http.MethodGet:
+			w.Write([]byte("<ListVersionsResult></ListVersionsResult>"))
+		case r.Method == http.MethodDelete:
+			if mu.TryLock() {
+				mu.Unlock()
+				t.Error("mu is not held while the bucket is deleted")
+			}
+			deleted <- struct{}{}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer gateway.Close()
+	cfg := &config.Config{Site: config.DefaultSiteName, EndpointURL: gateway.URL, Region: "local",
+		AdminAccess: "admin", AdminSecret: "secret", MaxAttempts: 1}
+
+	forceDelete := func() *httptest.ResponseRecorder {
+		token, _ := newConfirmToken("force-delete-bucket", "scratch", time.Now())
+		req := httptest.NewRequest(http.MethodDelete, "/v1/buckets/scratch?force=true&confirm="+token, nil)
+		req = req.WithContext(context.WithValue(req.Context(), configKey{}, cfg))
+		return serveAs(&caller{name: "admin"}, "DELETE /v1/buckets/{name}", handleDeleteBucket, req)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- forceDelete() }()
+	<-listing
+
+	if !mu.TryLock() {
+		t.Fatal("mu is held while the bucket is emptied")
+	}
+	mu.Unlock()
+	if rec := forceDelete(); rec.Code != http.StatusConflict {
+		t.Errorf("second force delete: status = %d, want 409 (body %s)", rec.Code, rec.Body)
+	}
+
+	close(release)
+	if rec := <-done; rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+	select {
+	case <-deleted:
+	default:
+		t.Error("bucket was not deleted")
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// confirmTokenTTL is how long a destructive-operation confirmation token stays valid.
+const confirmTokenTTL = 5 * time.Minute
+
+// confirmKey signs confirmation tokens. It is generated per process, so tokens
+// do not survive a restart — callers simply request a new one.
+var confirmKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("generating confirmation key: %v", err))
+	}
+	return key
+}()
+
+// newConfirmToken returns a token confirming action on target, valid until the returned time.
+func newConfirmToken(action, target string, now time.Time) (string, time.Time) {
+	expires := now.Add(confirmTokenTTL).Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + confirmSignature(action, target, exp), expires
+}
+
+// validConfirmToken reports whether token was issued for action on target and has not expired.
+func validConfirmToken(token, action, target string, now time.Time) bool {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.After(time.Unix(unix, 0)) {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(confirmSignature(action, target, exp)))
+}
+
+func confirmSignature(action, target, exp string) string {
+	mac := hmac.New(sha256.New, confirmKey)
+	mac.Write([]byte(action + "\x00" + target + "\x00" + exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// corsRequest is the JSON body for PUT /v1/buckets/{name}/cors.
+type corsRequest struct {
+	Rules []services.CORSRule `json:"rules"`
+}
+
+// corsAllowGetRequest is the JSON body for POST /v1/buckets/{name}/cors/allow-get.
+type corsAllowGetRequest struct {
+	Origins []string `json:"origins"`
+}
+
+// handleGetCors returns the CORS rules of a bucket.
+func handleGetCors(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	vgwService := services.NewVersityGWService(cfg)
+	rules, err := vgwService.GetBucketCors(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bucket": name,
+		"rules":  rules,
+	})
+}
+
+// handleSetCors replaces all CORS rules of a bucket.
+func handleSetCors(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	var req corsRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	for _, rule := range req.Rules {
+		if err := rule.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err := services.SetBucketCors(r.Context(), cfg, name, req.Rules); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bucket": name,
+		"rules":  req.Rules,
+	})
+}
+
+// handleCorsAllowGet adds origins to the "allow GET from origins" template rule of a bucket.
+func handleCorsAllowGet(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	var req corsAllowGetRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if len(req.Origins) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("at least one origin is required"))
+		return
+	}
+
+	rule, err := services.AllowCorsGet(r.Context(), cfg, name, req.Origins)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bucket": name,
+		"rule":   rule,
+	})
+}
+
+// handleDeleteCors removes all CORS rules from a bucket.
+func handleDeleteCors(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	vgwService := services.NewVersityGWService(cfg)
+	if err := vgwService.DeleteBucketCors(r.Context(), name); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"bucket": name,
+		"status": "cors removed",
+	})
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/monobilisim/vgw-manager/models"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// createGroupRequest is the JSON body for POST /v1/groups.
+type createGroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// groupGrantRequest is the JSON body for PUT /v1/groups/{group}/buckets/{bucket}.
+type groupGrantRequest struct {
+	Permission string `json:"permission"`
+}
+
+// groupStatus maps unknown groups, users, members and grants to 404,
+// duplicates to 409 and everything else (gateway failures) to 500.
+func groupStatus(err error) int {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "not found"), strings.Contains(msg, "is not a member"), strings.Contains(msg, "has no grant"):
+		return http.StatusNotFound
+	case strings.Contains(msg, "already"):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// deniedGroupBucket returns the first bucket granted to the group that the
+// caller may not access, or "" if the caller may access all of them.
+func deniedGroupBucket(c *caller, group models.Group) string {
+	for _, grant := range group.Buckets {
+		if !c.AllowsBucket(grant.Bucket) {
+			return grant.Bucket
+		}
+	}
+	return ""
+}
+
+// checkGroup reports whether the caller may act on the group, i.e. on every
+// bucket it grants, writing a 404 for unknown groups and a 403 otherwise.
+func checkGroup(w http.ResponseWriter, r *http.Request, name string) (*models.Group, bool) {
+	group, err := services.GetGroup(requestConfig(r), name)
+	if err != nil {
+		writeError(w, groupStatus(err), err)
+		return nil, false
+	}
+	c := requestCaller(r)
+	if bucket := deniedGroupBucket(c, *group); bucket != "" {
+		writeError(w, http.StatusForbidden, fmt.Errorf("token %q may not access bucket %q granted to group %q", c.name, bucket, name))
+		return nil, false
+	}
+	return group, true
+}
+
+// handleListGroups returns the groups whose buckets the caller may all access.
+func handleListGroups(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	groups, err := services.ListGroups(cfg)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	c := requestCaller(r)
+	visible := []models.Group{}
+	for _, group := range groups {
+		if deniedGroupBucket(c, group) == "" {
+			visible = append(visible, group)
+		}
+	}
+	writeJSON(w, http.StatusOK, visible)
+}
+
+// handleGetGroup returns a single group.
+func handleGetGroup(w http.ResponseWriter, r *http.Request) {
+	group, ok := checkGroup(w, r, r.PathValue("group"))
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, group)
+}
+
+// handleCreateGroup creates an empty group.
+func handleCreateGroup(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	var req createGroupRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, errors.New("name is required"))
+		return
+	}
+
+	group, err := services.CreateGroup(cfg, req.Name, req.Description)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, group)
+}
+
+// handleDeleteGroup deletes a group and removes its bucket policy statements.
+func handleDeleteGroup(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("group")
+	if _, ok := checkGroup(w, r, name); !ok {
+		return
+	}
+	if err := services.DeleteGroup(r.Context(), cfg, name); err != nil {
+		writeError(w, groupStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"group":  name,
+		"status": "deleted",
+	})
+}
+
+// handleAddGroupMember adds a user to a group.
+func handleAddGroupMember(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	if _, ok := checkGroup(w, r, r.PathValue("group")); !ok {
+		return
+	}
+	group, err := services.AddGroupMember(r.Context(), cfg, r.PathValue("group"), r.PathValue("access"))
+	if err != nil {
+		writeError(w, groupStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, group)
+}
+
+// handleRemoveGroupMember removes a user from a group.
+func handleRemoveGroupMember(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	if _, ok := checkGroup(w, r, r.PathValue("group")); !ok {
+		return
+	}
+	group, err := services.RemoveGroupMember(r.Context(), cfg, r.PathValue("group"), r.PathValue("access"))
+	if err != nil {
+		writeError(w, groupStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, group)
+}
+
+// handleGrantGroupBucket gives a group read or read/write access to a bucket.
+func handleGrantGroupBucket(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	var req groupGrantRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Permission != "" && req.Permission != services.PermissionRead && req.Permission != services.PermissionReadWrite {
+		writeError(w, http.StatusBadRequest, errors.New("permission must be read or readwrite"))
+		return
+	}
+
+	group, err := services.GrantGroupBucket(r.Context(), cfg, r.PathValue("group"), r.PathValue("bucket"), req.Permission)
+	if err != nil {
+		writeError(w, groupStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, group)
+}
+
+// handleRevokeGroupBucket removes a group's access to a bucket.
+func handleRevokeGroupBucket(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	group, err := services.RevokeGroupBucket(r.Context(), cfg, r.PathValue("group"), r.PathValue("bucket"))
+	if err != nil {
+		writeError(w, groupStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, group)
+}
+
+// handleSyncGroup re-applies a group's bucket policy statements.
+func handleSyncGroup(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	if _, ok := checkGroup(w, r, r.PathValue("group")); !ok {
+		return
+	}
+	buckets, err := services.SyncGroup(r.Context(), cfg, r.PathValue("group"))
+	if err != nil {
+		writeError(w, groupStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"buckets": buckets})
+}
+
+// handleUserAccess reports every bucket a user can access and why.
+func handleUserAccess(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	report, err := services.UserAccess(r.Context(), cfg, r.PathValue("access"))
+	if err != nil {
+		writeError(w, groupStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// lifecycleRequest is the JSON body for PUT /v1/buckets/{name}/lifecycle.
+type lifecycleRequest struct {
+	Rules []services.LifecycleRule `json:"rules"`
+}
+
+// handleGetLifecycle returns the lifecycle rules of a bucket.
+func handleGetLifecycle(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	vgwService := services.NewVersityGWService(cfg)
+	rules, err := vgwService.GetBucketLifecycle(r.Context(), name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bucket": name,
+		"rules":  rules,
+	})
+}
+
+// handleSetLifecycle replaces all lifecycle rules of a bucket.
+func handleSetLifecycle(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	var req lifecycleRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	for _, rule := range req.Rules {
+		if err := rule.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err := services.SetBucketLifecycle(r.Context(), cfg, name, req.Rules); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bucket": name,
+		"rules":  req.Rules,
+	})
+}
+
+// handleDeleteLifecycle removes all lifecycle rules from a bucket.
+func handleDeleteLifecycle(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	vgwService := services.NewVersityGWService(cfg)
+	if err := vgwService.DeleteBucketLifecycle(r.Context(), name); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"bucket": name,
+		"status": "lifecycle removed",
+	})
+}
+
+// handlePutLifecycleRule adds or replaces a single lifecycle rule. The rule ID
+// is taken from the path.
+func handlePutLifecycleRule(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	var rule services.LifecycleRule
+	if !decodeJSON(w, r, &rule) {
+		return
+	}
+	rule.ID = r.PathValue("id")
+	if err := rule.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := services.PutLifecycleRule(r.Context(), cfg, name, rule); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"bucket": name,
+		"rule":   rule,
+	})
+}
+
+// handleDeleteLifecycleRule removes a single lifecycle rule by ID.
+func handleDeleteLifecycleRule(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	id := r.PathValue("id")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+	if id == "" {
+		writeError(w, http.StatusBadRequest, errors.New("rule id is required"))
+		return
+	}
+
+	if err := services.RemoveLifecycleRule(r.Context(), cfg, name, id); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"bucket": name,
+		"rule":   id,
+		"status": "deleted",
+	})
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// NoListen as the listen address disables the TCP listener, e.g. to serve
+// only on apiSocket.
+const NoListen = "none"
+
+// Listen opens the listeners the API is served on: addr over TCP unless it is
+// NoListen, with TLS if apiTLSCertFile is set, and the Unix socket apiSocket.
+func Listen(cfg *config.Config, addr string) ([]net.Listener, error) {
+	listeners := []net.Listener{}
+	closeAll := func() {
+		for _, ln := range listeners {
+			ln.Close()
+		}
+	}
+
+	if addr != NoListen {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.APITLSCertFile != "" {
+			tlsConfig, err := services.NewServerTLSConfig(cfg.APITLSCertFile, cfg.APITLSKeyFile, cfg.APITLSClientCAFile)
+			if err != nil {
+				ln.Close()
+				return nil, err
+			}
+			ln = tls.NewListener(ln, tlsConfig)
+		} else if !isLoopback(addr) {
+			slog.Warn("serving the API over plain HTTP on a non-loopback address; set apiTLSCertFile and apiTLSKeyFile", "addr", addr)
+		}
+		listeners = append(listeners, ln)
+	}
+
+	if cfg.APISocket != "" {
+		mode, err := cfg.SocketMode()
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		ln, err := listenUnix(cfg.APISocket, mode, cfg.APISocketGroup)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+		listeners = append(listeners, ln)
+	}
+
+	if len(listeners) == 0 {
+		return nil, errors.New("no listener: set apiListen or apiSocket")
+	}
+	return listeners, nil
+}
+
+// listenUnix listens on a Unix socket with the given permissions and group.
+// A socket left behind by a previous run is replaced; one that still accepts
+// connections is not.
+func listenUnix(path string, mode os.FileMode, group string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != os.ModeSocket {
+			return nil, fmt.Errorf("apiSocket %s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("apiSocket %s is in use by another process", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale apiSocket: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := setSocketOwnership(path, mode, group); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+func setSocketOwnership(path string, mode os.FileMode, group string) error {
+	if group != "" {
+		g, err := user.LookupGroup(group)
+		if err != nil {
+			return fmt.Errorf("invalid apiSocketGroup: %w", err)
+		}
+		gid, err := strconv.Atoi(g.Gid)
+		if err != nil {
+			return fmt.Errorf("invalid apiSocketGroup: %w", err)
+		}
+		if err := os.Chown(path, -1, gid); err != nil {
+			return fmt.Errorf("failed to set apiSocketGroup: %w", err)
+		}
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("failed to set apiSocketMode: %w", err)
+	}
+	return nil
+}
+
+// isLoopback reports whether a listen address only accepts local connections.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
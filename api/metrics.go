@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// metricsTimeout bounds one refresh of a site's inventory.
+const metricsTimeout = 2 * time.Minute
+
+// serverMetrics holds the metrics served on GET /metrics: HTTP request
+// metrics recorded by metricsMiddleware and the bucket, pool and user
+// inventory of every site, refreshed by CollectMetrics.
+type serverMetrics struct {
+	requests *services.CounterVec
+	duration *services.HistogramVec
+
+	mu        sync.Mutex
+	inventory map[string]*siteInventory
+}
+
+// siteInventory is the last collected state of a site. On a failed refresh
+// the previous values are kept and err records why.
+type siteInventory struct {
+	dataset       string
+	buckets       []models.Bucket
+	poolUsed      int64
+	poolAvailable int64
+	users         int
+	hasPool       bool
+	hasUsers      bool
+	refreshed     time.Time
+	err           error
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{
+		requests: services.NewCounterVec("vgw_http_requests_total",
+			"API requests by method, route and status code.",
+			"method", "route", "code"),
+		duration: services.NewHistogramVec("vgw_http_request_duration_seconds",
+			"Duration of API requests by method and route.",
+			services.DefaultLatencyBuckets, "method", "route"),
+		inventory: map[string]*siteInventory{},
+	}
+}
+
+type routeKey struct{}
+
+// metricsMiddleware records the count and duration of every request by its
+// route pattern (e.g. /v1/buckets/{name}), so that bucket and user names do
+// not become label values. Requests that never reach the mux (e.g. rejected
+// for a missing token or an unknown site) are labelled "unrouted".
+func metricsMiddleware(m *serverMetrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			route := "unrouted"
+			rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), routeKey{}, &route)))
+			method := methodLabel(r.Method)
+			m.requests.Inc(method, route, strconv.Itoa(rw.status))
+			m.duration.Observe(time.Since(start).Seconds(), method, route)
+		})
+	}
+}
+
+// methodLabel returns the method label of a request. Any client can send
+// arbitrary methods, even without a token, so methods other than the
+// standard ones are labelled "other" to keep the number of series bounded.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
+
+// recordRoute wraps the mux and reports the pattern of the matched route to
+// metricsMiddleware.
+func recordRoute(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		route, ok := r.Context().Value(routeKey{}).(*string)
+		if !ok {
+			return
+		}
+		// The pattern is "METHOD /path"; the catch-all "/" matches unknown paths.
+		_, pattern, _ := strings.Cut(r.Pattern, " ")
+		if pattern == "" {
+			pattern = r.Pattern
+		}
+		if pattern != "" && pattern != "/" {
+			*route = pattern
+		}
+	})
+}
+
+// CollectMetrics refreshes the bucket, pool and user metrics of every
+// configured site now and then every interval, until ctx is done. Sites are
+// read from the current configuration at each refresh.
+func (s *Server) CollectMetrics(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.refreshInventory(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) refreshInventory(ctx context.Context) {
+	sites := s.state.Load().sites
+	s.metrics.mu.Lock()
+	previous := s.metrics.inventory
+	s.metrics.mu.Unlock()
+
+	inventory := make(map[string]*siteInventory, len(sites.sites))
+	for name, cfg := range sites.sites {
+		inv := &siteInventory{dataset: cfg.ZFSPoolBase}
+		if prev, ok := previous[name]; ok {
+			*inv = *prev
+			inv.dataset = cfg.ZFSPoolBase
+		}
+		siteCtx, cancel := context.WithTimeout(ctx, metricsTimeout)
+		inv.err = collectSite(siteCtx, cfg, inv)
+		cancel()
+		if inv.err != nil {
+			slog.Warn("failed to refresh metrics", "site", name, "error", inv.err)
+		} else {
+			inv.refreshed = time.Now()
+		}
+		inventory[name] = inv
+	}
+
+	s.metrics.mu.Lock()
+	s.metrics.inventory = inventory
+	s.metrics.mu.Unlock()
+}
+
+// collectSite reads the state of a site into inv, updating only the parts
+// that could be read.
+func collectSite(ctx context.Context, cfg *config.Config, inv *siteInventory) error {
+	var errs []error
+	if buckets, err := services.ListMergedBuckets(ctx, cfg); err != nil {
+		errs = append(errs, err)
+	} else {
+		inv.buckets = buckets
+	}
+	if used, available, err := services.NewBucketService(cfg).PoolUsage(ctx); err != nil {
+		errs = append(errs, err)
+	} else {
+		inv.poolUsed, inv.poolAvailable, inv.hasPool = used, available, true
+	}
+	if users, err := services.NewUserService(cfg).ListUsers(); err != nil {
+		errs = append(errs, err)
+	} else {
+		inv.users, inv.hasUsers = len(users), true
+	}
+	return errors.Join(errs...)
+}
+
+// handleMetrics serves all metrics in the Prometheus text format. Bucket
+// series are limited to the buckets the caller may see.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	c := requestCaller(r)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m := services.NewMetricWriter(w)
+
+	s.metrics.mu.Lock()
+	inventory := s.metrics.inventory
+	s.metrics.mu.Unlock()
+	sites := make([]string, 0, len(inventory))
+	for name := range inventory {
+		sites = append(sites, name)
+	}
+	sort.Strings(sites)
+
+	bucketGauges := []struct {
+		name, help string
+		value      func(models.Bucket) (int64, bool)
+	}{
+		{"vgw_bucket_quota_bytes", "Quota of the bucket's dataset; absent without a quota.", func(b models.Bucket) (int64, bool) { return services.ParseZFSSize(b.Quota) }},
+		{"vgw_bucket_used_bytes", "Bytes used by the bucket's dataset.", func(b models.Bucket) (int64, bool) { return services.ParseZFSSize(b.Used) }},
+		{"vgw_bucket_available_bytes", "Bytes available to the bucket's dataset.", func(b models.Bucket) (int64, bool) { return services.ParseZFSSize(b.Available) }},
+		{"vgw_bucket_public", "1 if the bucket allows anonymous reads.", func(b models.Bucket) (int64, bool) {
+			if b.Public {
+				return 1, true
+			}
+			return 0, true
+		}},
+	}
+	for _, g := range bucketGauges {
+		m.Family(g.name, "gauge", g.help)
+		for _, site := range sites {
+			for _, b := range inventory[site].buckets {
+				if !c.AllowsBucket(b.Name) {
+					continue
+				}
+				if v, ok := g.value(b); ok {
+					m.Sample(g.name, float64(v), "site", site, "bucket", b.Name)
+				}
+			}
+		}
+	}
+
+	siteGauges := []struct {
+		name, help string
+		value      func(*siteInventory) float64
+	}{
+		{"vgw_pool_used_bytes", "Bytes used by the ZFS dataset buckets are created under.", func(inv *siteInventory) float64 { return float64(inv.poolUsed) }},
+		{"vgw_pool_available_bytes", "Bytes available to the ZFS dataset buckets are created under.", func(inv *siteInventory) float64 { return float64(inv.poolAvailable) }},
+	}
+	for _, g := range siteGauges {
+		m.Family(g.name, "gauge", g.help)
+		for _, site := range sites {
+			if inv := inventory[site]; inv.hasPool {
+				m.Sample(g.name, g.value(inv), "site", site, "dataset", inv.dataset)
+			}
+		}
+	}
+	m.Family("vgw_users", "gauge", "Users in users.json.")
+	for _, site := range sites {
+		if inv := inventory[site]; inv.hasUsers {
+			m.Sample("vgw_users", float64(inv.users), "site", site)
+		}
+	}
+	m.Family("vgw_inventory_refresh_success", "gauge", "1 if the last refresh of the bucket, pool and user metrics succeeded.")
+	for _, site := range sites {
+		ok := 0.0
+		if inventory[site].err == nil {
+			ok = 1
+		}
+		m.Sample("vgw_inventory_refresh_success", ok, "site", site)
+	}
+	m.Family("vgw_inventory_refresh_timestamp_seconds", "gauge", "Unix time of the last successful refresh of the bucket, pool and user metrics.")
+	for _, site := range sites {
+		if inv := inventory[site]; !inv.refreshed.IsZero() {
+			m.Sample("vgw_inventory_refresh_timestamp_seconds", float64(inv.refreshed.Unix()), "site", site)
+		}
+	}
+
+	s.metrics.requests.Write(m)
+	s.metrics.duration.Write(m)
+	services.WriteGatewayMetrics(m)
+	if err := m.Err(); err != nil {
+		slog.Warn("failed to write metrics", "error", err)
+	}
+}
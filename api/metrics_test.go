@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+func TestMetricsMiddlewareMethodLabel(t *testing.T) {
+	m := newServerMetrics()
+	h := metricsMiddleware(m)(http.HandlerFunc(ok))
+	for _, method := range []string{http.MethodGet, "FOO", "get", "BAR"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/v1/buckets", nil))
+	}
+
+	var out strings.Builder
+	m.requests.Write(services.NewMetricWriter(&out))
+	for _, want := range []string{
+		`vgw_http_requests_total{method="GET",route="unrouted",code="204"} 1`,
+		`vgw_http_requests_total{method="other",route="unrouted",code="204"} 3`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics do not contain %s:\n%s", want, out.String())
+		}
+	}
+}
@@ -1,23 +1,26 @@
 package api
 
 import (
-	"crypto/subtle"
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/services"
 )
 
 // mu serializes all mutating operations (ZFS and users.json are not safe
 // to call concurrently).
 var mu sync.Mutex
 
-// mutating wraps a handler with the package-level mutex.
-func mutating(h http.HandlerFunc) http.HandlerFunc {
+// mutating wraps a handler with the package-level mutex and records the
+// request in the audit log as operation op.
+func mutating(op string, h http.HandlerFunc) http.HandlerFunc {
+	h = audited(op, h)
 	return func(w http.ResponseWriter, r *http.Request) {
 		mu.Lock()
 		defer mu.Unlock()
@@ -25,6 +28,22 @@ func mutating(h http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// audited records the request in the audit log as operation op without
+// taking mu, for handlers that do their own locking.
+func audited(op string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		target, params := auditParams(r)
+		actor := services.AuditActor{Source: "api", RemoteAddr: r.RemoteAddr}
+		if c := requestCaller(r); c != nil {
+			actor.Token = c.name
+		}
+		audit := services.StartAudit(requestConfig(r), actor, op, target, params)
+		rec := &auditRecorder{ResponseWriter: w, status: http.StatusOK}
+		h(rec, r)
+		audit.Finish(rec.err())
+	}
+}
+
 // chain wraps a handler with the given middlewares (outermost first).
 func chain(h http.Handler, mws ...func(http.Handler) http.Handler) http.Handler {
 	for _, mw := range mws {
@@ -33,8 +52,27 @@ func chain(h http.Handler, mws ...func(http.Handler) http.Handler) http.Handler
 	return h
 }
 
-// authMiddleware rejects requests without a valid Bearer token.
-// /healthz is always allowed without auth.
+type stateKey struct{}
+
+// stateMiddleware records the server state current when a request arrives,
+// so that its token check and site configuration come from the same load
+// even if the server is reloaded meanwhile.
+func stateMiddleware(current *atomic.Pointer[serverState]) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), stateKey{}, current.Load())
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requestState returns the server state recorded by stateMiddleware.
+func requestState(r *http.Request) *serverState {
+	return r.Context().Value(stateKey{}).(*serverState)
+}
+
+// authMiddleware rejects requests without a valid Bearer token and records
+// the token's caller in the request. /healthz is always allowed without auth.
 func authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/healthz" {
@@ -42,27 +80,33 @@ func authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
-		if subtle.ConstantTimeCompare([]byte(got), []byte(config.APIToken)) != 1 {
-			writeError(w, http.StatusUnauthorized, errors.New("invalid or missing bearer token"))
+		c, err := requestState(r).auth.authenticate(r.Context(), got)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
 			return
 		}
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withCaller(r, c))
 	})
 }
 
-// loggingMiddleware logs one line per request: method path status duration remote_addr.
-// It never logs the token, secret, or Authorization header.
+// loggingMiddleware logs one line per request: method path status duration remote_addr
+// and the name of the token used. It never logs the token, secret, or Authorization header.
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
 		next.ServeHTTP(rw, r)
+		token := ""
+		if c := requestCaller(r); c != nil {
+			token = c.name
+		}
 		slog.Info("request",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"status", rw.status,
 			"duration", time.Since(start).String(),
 			"remote_addr", r.RemoteAddr,
+			"token", token,
 		)
 	})
 }
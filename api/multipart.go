@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// defaultMultipartAgeHours is the upload age used when the request does not set one.
+const defaultMultipartAgeHours = 24
+
+var (
+	errInvalidOlderThanHours  = errors.New("olderThanHours must be a non-negative integer")
+	errBucketRequiredForToken = errors.New("bucket is required for tokens limited to some buckets")
+)
+
+// abortMultipartRequest is the JSON body for POST /v1/multipart-uploads/abort.
+type abortMultipartRequest struct {
+	Bucket         string `json:"bucket"`
+	OlderThanHours *int   `json:"olderThanHours"`
+	DryRun         bool   `json:"dryRun"`
+}
+
+// handleListMultipartUploads lists incomplete multipart uploads older than
+// ?olderThanHours (default 24) in ?bucket, or in every bucket the caller may
+// see when omitted.
+func handleListMultipartUploads(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	bucket := r.URL.Query().Get("bucket")
+	if bucket != "" && !checkBucket(w, r, bucket) {
+		return
+	}
+	hours := defaultMultipartAgeHours
+	if raw := r.URL.Query().Get("olderThanHours"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, errInvalidOlderThanHours)
+			return
+		}
+		hours = n
+	}
+
+	uploads, err := services.FindStaleMultipartUploads(r.Context(), cfg, bucket, time.Duration(hours)*time.Hour)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	c := requestCaller(r)
+	visible := []services.MultipartUpload{}
+	for _, upload := range uploads {
+		if c.AllowsBucket(upload.Bucket) {
+			visible = append(visible, upload)
+		}
+	}
+	writeJSON(w, http.StatusOK, visible)
+}
+
+// handleAbortMultipartUploads aborts stale multipart uploads; with dryRun it only reports them.
+// Tokens limited to some buckets must name the bucket.
+func handleAbortMultipartUploads(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	var req abortMultipartRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Bucket == "" && len(requestCaller(r).Buckets) > 0 {
+		writeError(w, http.StatusForbidden, errBucketRequiredForToken)
+		return
+	}
+	if req.Bucket != "" && !checkBucket(w, r, req.Bucket) {
+		return
+	}
+
+	hours := defaultMultipartAgeHours
+	if req.OlderThanHours != nil {
+		if *req.OlderThanHours < 0 {
+			writeError(w, http.StatusBadRequest, errInvalidOlderThanHours)
+			return
+		}
+		hours = *req.OlderThanHours
+	}
+
+	result, err := services.CleanupMultipartUploads(r.Context(), cfg, req.Bucket, time.Duration(hours)*time.Hour, req.DryRun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// presignRequest is the JSON body for POST /v1/buckets/{name}/presign.
+type presignRequest struct {
+	Key            string `json:"key"`
+	Method         string `json:"method"`
+	ExpiresSeconds int    `json:"expiresSeconds"`
+	SignAs         string `json:"signAs"`
+}
+
+// handlePresign returns a time-limited presigned GET or PUT URL for an object.
+func handlePresign(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	var req presignRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.ExpiresSeconds < 0 {
+		writeError(w, http.StatusBadRequest, errors.New("expiresSeconds must not be negative"))
+		return
+	}
+
+	presignReq := services.PresignRequest{
+		Bucket: name,
+		Key:    req.Key,
+		Method: req.Method,
+		Expiry: time.Duration(req.ExpiresSeconds) * time.Second,
+		SignAs: req.SignAs,
+	}
+	if err := presignReq.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	// An upload URL writes to the bucket, so it needs more than the route's read scope.
+	if c := requestCaller(r); presignReq.Method == http.MethodPut && !c.Allows(config.ScopeBucketsWrite) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("token %q lacks the %s scope", c.name, config.ScopeBucketsWrite))
+		return
+	}
+	// Signing as the owner hands out the owner's credentials, so the owner
+	// must match the caller's user patterns.
+	if presignReq.SignAs == services.PresignAsOwner {
+		owner, err := services.ResolveBucketOwner(r.Context(), cfg, name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !checkUser(w, r, owner) {
+			return
+		}
+		presignReq.Owner = owner
+	}
+
+	presigned, err := services.PresignObjectURL(r.Context(), cfg, presignReq)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, presigned)
+}
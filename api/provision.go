@@ -8,12 +8,16 @@ import (
 
 // handleProvision creates a user, a bucket, and sets the bucket owner in one call.
 func handleProvision(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
 	var req services.ProvisionRequest
 	if !decodeJSON(w, r, &req) {
 		return
 	}
+	if !checkUser(w, r, req.Access) || !checkRole(w, r, req.Role) || !checkBucket(w, r, req.Bucket) || (req.Owner != "" && !checkUser(w, r, req.Owner)) {
+		return
+	}
 
-	summary, err := services.Provision(req)
+	summary, err := services.Provision(r.Context(), cfg, req)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// quotaWatchPoll is the longest the quota watcher sleeps before it looks at
+// the current configuration again, so that sites added by a reload are
+// picked up without waiting for a long interval to pass.
+const quotaWatchPoll = time.Minute
+
+// WatchQuotas runs the quota watcher of every site with quotaAlerts now and
+// then every quotaAlerts.interval of that site, until ctx is done. The sites
+// and their intervals are read from the current configuration on every
+// round; a changed interval applies from the next check of a site. It runs
+// apart from CollectMetrics, so slow notification targets do not delay
+// metrics and the metrics timeout does not cut notifications short.
+func (s *Server) WatchQuotas(ctx context.Context) {
+	next := map[string]time.Time{}
+	for {
+		wait := quotaWatchPoll
+		for name, cfg := range s.state.Load().sites.sites {
+			if cfg.QuotaAlerts == nil {
+				delete(next, name)
+				continue
+			}
+			if due := next[name]; time.Now().Before(due) {
+				wait = min(wait, time.Until(due))
+				continue
+			}
+
+			interval := cfg.QuotaAlerts.WithDefaults().Interval
+			next[name] = time.Now().Add(interval)
+			wait = min(wait, interval)
+
+			listCtx, cancel := context.WithTimeout(ctx, metricsTimeout)
+			buckets, err := services.ListMergedBuckets(listCtx, cfg)
+			cancel()
+			if err != nil {
+				slog.Warn("failed to list buckets for quota alerts", "site", name, "error", err)
+				continue
+			}
+			checkQuotaAlerts(ctx, cfg, buckets)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// checkQuotaAlerts runs the quota watcher of a site on freshly listed
+// buckets, notifying the configured webhooks and email recipients. Alerts
+// that could not be delivered are logged and retried at the next check.
+func checkQuotaAlerts(ctx context.Context, cfg *config.Config, buckets []models.Bucket) {
+	if cfg.QuotaAlerts == nil {
+		return
+	}
+	err := services.CheckQuotaAlerts(cfg, buckets, time.Now(), func(alert models.QuotaAlert) error {
+		if err := services.NotifyQuotaAlert(ctx, cfg.QuotaAlerts, alert); err != nil {
+			return err
+		}
+		slog.Info("quota alert sent", "site", alert.Site, "bucket", alert.Bucket,
+			"threshold", alert.Threshold, "resolved", alert.Resolved, "usage_percent", alert.UsagePercent)
+		return nil
+	})
+	if err != nil {
+		slog.Warn("quota alerts failed", "site", cfg.Site, "error", err)
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// handleBucketReport returns an object count / prefix / age breakdown of a bucket.
+// Optional query parameters: prefix, depth (prefix depth) and top (number of prefixes).
+func handleBucketReport(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	name := r.PathValue("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, errBucketNameRequired)
+		return
+	}
+
+	opts := services.ReportOptions{Prefix: r.URL.Query().Get("prefix")}
+	var err error
+	if opts.PrefixDepth, err = intQuery(r, "depth"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if opts.TopPrefixes, err = intQuery(r, "top"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Listing a large bucket outlasts the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	report, err := services.GenerateBucketReport(r.Context(), cfg, name, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// intQuery parses an optional non-negative integer query parameter (0 when absent).
+func intQuery(r *http.Request, key string) (int, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", key)
+	}
+	return n, nil
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// caller is the authenticated client of a request: the name of its token and
+// what the token may do.
+type caller struct {
+	name string
+	config.Permissions
+}
+
+type callerKey struct{}
+
+// withCaller stores the caller in the request context.
+func withCaller(r *http.Request, c *caller) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), callerKey{}, c))
+}
+
+// requestCaller returns the caller of an authenticated request, or nil.
+func requestCaller(r *http.Request) *caller {
+	c, _ := r.Context().Value(callerKey{}).(*caller)
+	return c
+}
+
+// allow wraps a handler so that it only runs for callers granted scope. The
+// route's bucket ({name}, {bucket}) and user ({access}, {child}) path values
+// must also match the caller's name patterns.
+func allow(scope string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := requestCaller(r)
+		if !c.Allows(scope) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("token %q lacks the %s scope", c.name, scope))
+			return
+		}
+		for _, key := range []string{"name", "bucket"} {
+			if name := r.PathValue(key); name != "" && !checkBucket(w, r, name) {
+				return
+			}
+		}
+		for _, key := range []string{"access", "child"} {
+			if access := r.PathValue(key); access != "" && !checkUser(w, r, access) {
+				return
+			}
+		}
+		h(w, r)
+	}
+}
+
+// checkBucket reports whether the caller may act on the bucket, writing a 403
+// otherwise.
+func checkBucket(w http.ResponseWriter, r *http.Request, name string) bool {
+	c := requestCaller(r)
+	if !c.AllowsBucket(name) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("token %q may not access bucket %q", c.name, name))
+		return false
+	}
+	return true
+}
+
+// checkUser reports whether the caller may act on the user, writing a 403
+// otherwise.
+func checkUser(w http.ResponseWriter, r *http.Request, access string) bool {
+	c := requestCaller(r)
+	if !c.AllowsUser(access) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("token %q may not access user %q", c.name, access))
+		return false
+	}
+	return true
+}
+
+// checkRole reports whether the caller may create users with the role,
+// writing a 403 otherwise. admin and userplus users are not confined to their
+// own buckets, so tokens limited by bucket or user patterns may only create
+// users with role user.
+func checkRole(w http.ResponseWriter, r *http.Request, role string) bool {
+	c := requestCaller(r)
+	if (role == "admin" || role == "userplus") && (len(c.Buckets) > 0 || len(c.Users) > 0) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("token %q is limited to name patterns and may not create %s users", c.name, role))
+		return false
+	}
+	return true
+}
+
+// checkShowSecrets reports whether ?showSecrets=true was requested, writing a
+// 403 and returning ok=false if the caller may not reveal secrets.
+func checkShowSecrets(w http.ResponseWriter, r *http.Request) (show, ok bool) {
+	if r.URL.Query().Get("showSecrets") != "true" {
+		return false, true
+	}
+	c := requestCaller(r)
+	if !c.Allows(config.ScopeSecretsRead) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("token %q lacks the %s scope", c.name, config.ScopeSecretsRead))
+		return false, false
+	}
+	return true, true
+}
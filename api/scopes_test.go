@@ -0,0 +1,266 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+// serveAs registers h under pattern and serves one request as caller c.
+func serveAs(c *caller, pattern string, h http.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc(pattern, h)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, withCaller(req, c))
+	return rec
+}
+
+func ok(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestAllow(t *testing.T) {
+	reader := &caller{name: "reader", Permissions: config.Permissions{Scopes: []string{config.ScopeBucketsRead}}}
+	writer := &caller{name: "writer", Permissions: config.Permissions{Scopes: []string{config.ScopeBucketsWrite}}}
+	team := &caller{name: "team", Permissions: config.Permissions{
+		Scopes:  []string{config.ScopeBucketsWrite, config.ScopeUsersManage},
+		Buckets: []string{"team-*"},
+		Users:   []string{"team-*"},
+	}}
+
+	tests := []struct {
+		name    string
+		caller  *caller
+		scope   string
+		pattern string
+		path    string
+		want    int
+	}{
+		{"granted scope", reader, config.ScopeBucketsRead, "GET /b/{name}", "/b/x", http.StatusNoContent},
+		{"missing scope", reader, config.ScopeBucketsWrite, "GET /b/{name}", "/b/x", http.StatusForbidden},
+		{"write implies read", writer, config.ScopeBucketsRead, "GET /b/{name}", "/b/x", http.StatusNoContent},
+		{"write does not imply users", writer, config.ScopeUsersRead, "GET /u", "/u", http.StatusForbidden},
+		{"bucket name matches", team, config.ScopeBucketsRead, "GET /b/{name}", "/b/team-a", http.StatusNoContent},
+		{"bucket name outside patterns", team, config.ScopeBucketsRead, "GET /b/{name}", "/b/other", http.StatusForbidden},
+		{"bucket path value outside patterns", team, config.ScopeUsersManage, "PUT /g/{group}/b/{bucket}", "/g/ops/b/other", http.StatusForbidden},
+		{"access outside patterns", team, config.ScopeUsersRead, "GET /u/{access}", "/u/bob", http.StatusForbidden},
+		{"child outside patterns", team, config.ScopeUsersManage, "DELETE /u/{access}/sa/{child}", "/u/team-a/sa/ci", http.StatusForbidden},
+		{"user and child match", team, config.ScopeUsersManage, "DELETE /u/{access}/sa/{child}", "/u/team-a/sa/team-ci", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, _, _ := strings.Cut(tt.pattern, " ")
+			rec := serveAs(tt.caller, tt.pattern, allow(tt.scope, ok), httptest.NewRequest(method, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+func TestCheckBucketAndUser(t *testing.T) {
+	c := &caller{name: "team", Permissions: config.Permissions{Buckets: []string{"team-*"}, Users: []string{"alice", "team-?"}}}
+	tests := []struct {
+		name  string
+		check func(http.ResponseWriter, *http.Request, string) bool
+		value string
+		want  bool
+	}{
+		{"bucket match", checkBucket, "team-backups", true},
+		{"bucket mismatch", checkBucket, "billing", false},
+		{"user exact", checkUser, "alice", true},
+		{"user pattern", checkUser, "team-1", true},
+		{"user mismatch", checkUser, "team-12", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			got := tt.check(rec, withCaller(httptest.NewRequest(http.MethodGet, "/", nil), c), tt.value)
+			if got != tt.want {
+				t.Errorf("check(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+			if !got && rec.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403", rec.Code)
+			}
+		})
+	}
+
+	unrestricted := &caller{name: "all"}
+	if !checkUser(httptest.NewRecorder(), withCaller(httptest.NewRequest(http.MethodGet, "/", nil), unrestricted), "anyone") {
+		t.Error("caller without user patterns should match every user")
+	}
+}
+
+func TestCheckShowSecrets(t *testing.T) {
+	reader := &caller{name: "reader", Permissions: config.Permissions{Scopes: []string{config.ScopeUsersRead}}}
+	secrets := &caller{name: "secrets", Permissions: config.Permissions{Scopes: []string{config.ScopeUsersRead, config.ScopeSecretsRead}}}
+	tests := []struct {
+		name     string
+		caller   *caller
+		query    string
+		wantShow bool
+		wantOK   bool
+	}{
+		{"not requested", reader, "", false, true},
+		{"not true", reader, "?showSecrets=1", false, true},
+		{"requested without scope", reader, "?showSecrets=true", false, false},
+		{"requested with scope", secrets, "?showSecrets=true", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			show, ok := checkShowSecrets(rec, withCaller(httptest.NewRequest(http.MethodGet, "/v1/users"+tt.query, nil), tt.caller))
+			if show != tt.wantShow || ok != tt.wantOK {
+				t.Errorf("checkShowSecrets() = %v, %v, want %v, %v", show, ok, tt.wantShow, tt.wantOK)
+			}
+			if !ok && rec.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403", rec.Code)
+			}
+		})
+	}
+}
+
+func TestPresignAsOwnerChecksOwner(t *testing.T) {
+	owners := map[string]string{"team-data": "team-a", "shared": "bob"}
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucket := strings.Trim(r.URL.Path, "/")
+		w.Write([]byte("<AccessControlPolicy><Owner><ID>" + owners[bucket] + "</ID></Owner></AccessControlPolicy>"))
+	}))
+	defer gateway.Close()
+
+	usersJSON := filepath.Join(t.TempDir(), "users.json")
+	users, _ := json.Marshal(map[string]any{"accessAccounts": map[string]any{
+		"team-a": map[string]string{"access": "team-a", "secret": "team-a-secret"},
+		"bob":    map[string]string{"access": "bob", "secret": "bob-secret"},
+	}})
+	if err := os.WriteFile(usersJSON, users, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{EndpointURL: gateway.URL, Region: "local", AdminAccess: "admin", AdminSecret: "secret",
+		UsersJSONPath: usersJSON, MaxAttempts: 1}
+
+	team := &caller{name: "team", Permissions: config.Permissions{Scopes: []string{config.ScopeBucketsRead}, Users: []string{"team-*"}}}
+	tests := []struct {
+		name   string
+		bucket string
+		signAs string
+		want   int
+	}{
+		{"owner matches user patterns", "team-data", "owner", http.StatusOK},
+		{"owner outside user patterns", "shared", "owner", http.StatusForbidden},
+		{"admin does not name a user", "shared", "admin", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"key": "report.pdf", "signAs": "` + tt.signAs + `"}`
+			req := httptest.NewRequest(http.MethodPost, "/v1/buckets/"+tt.bucket+"/presign", strings.NewReader(body))
+			req = req.WithContext(context.WithValue(req.Context(), configKey{}, cfg))
+			rec := serveAs(team, "POST /v1/buckets/{name}/presign", handlePresign, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusOK && tt.signAs == "owner" && !strings.Contains(rec.Body.String(), `"signedBy":"team-a"`) {
+				t.Errorf("body = %s, want it signed by team-a", rec.Body)
+			}
+		})
+	}
+}
+
+func TestGroupsLimitedToBucketPatterns(t *testing.T) {
+	dataDir := t.TempDir()
+	groups, _ := json.Marshal(map[string]any{"groups": map[string]models.Group{
+		"team":  {Name: "team", Members: []string{"team-a"}, Buckets: []models.GroupGrant{{Bucket: "team-data", Permission: "read"}}},
+		"mixed": {Name: "mixed", Members: []string{"team-a"}, Buckets: []models.GroupGrant{{Bucket: "team-data", Permission: "read"}, {Bucket: "billing", Permission: "read"}}},
+	}})
+	if err := os.WriteFile(filepath.Join(dataDir, "groups.json"), groups, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{DataDir: dataDir}
+	team := &caller{name: "team", Permissions: config.Permissions{
+		Scopes:  []string{config.ScopeUsersManage},
+		Buckets: []string{"team-*"},
+		Users:   []string{"team-*"},
+	}}
+	request := func(method, path string) *http.Request {
+		req := httptest.NewRequest(method, path, nil)
+		return req.WithContext(context.WithValue(req.Context(), configKey{}, cfg))
+	}
+
+	rec := serveAs(team, "GET /groups", handleListGroups, request(http.MethodGet, "/groups"))
+	var listed []models.Group
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("list: %v (body %s)", err, rec.Body)
+	}
+	if len(listed) != 1 || listed[0].Name != "team" {
+		t.Errorf("listed groups = %+v, want only team", listed)
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		h       http.HandlerFunc
+		method  string
+		path    string
+	}{
+		{"get", "GET /groups/{group}", handleGetGroup, http.MethodGet, "/groups/mixed"},
+		{"delete", "DELETE /groups/{group}", handleDeleteGroup, http.MethodDelete, "/groups/mixed"},
+		{"sync", "POST /groups/{group}/sync", handleSyncGroup, http.MethodPost, "/groups/mixed/sync"},
+		{"add member", "PUT /groups/{group}/members/{access}", handleAddGroupMember, http.MethodPut, "/groups/mixed/members/team-b"},
+		{"remove member", "DELETE /groups/{group}/members/{access}", handleRemoveGroupMember, http.MethodDelete, "/groups/mixed/members/team-a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveAs(team, tt.pattern, tt.h, request(tt.method, tt.path))
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403 (body %s)", rec.Code, rec.Body)
+			}
+		})
+	}
+
+	rec = serveAs(team, "GET /groups/{group}", handleGetGroup, request(http.MethodGet, "/groups/team"))
+	if rec.Code != http.StatusOK {
+		t.Errorf("get team: status = %d, want 200 (body %s)", rec.Code, rec.Body)
+	}
+}
+
+func TestCheckRole(t *testing.T) {
+	team := &caller{name: "team", Permissions: config.Permissions{Scopes: []string{config.ScopeUsersManage}, Users: []string{"team-*"}}}
+	all := &caller{name: "all", Permissions: config.Permissions{Scopes: []string{config.ScopeUsersManage}}}
+	tests := []struct {
+		caller *caller
+		role   string
+		want   bool
+	}{
+		{team, "user", true},
+		{team, "userplus", false},
+		{team, "admin", false},
+		{all, "admin", true},
+		{all, "userplus", true},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		got := checkRole(rec, withCaller(httptest.NewRequest(http.MethodPost, "/v1/users", nil), tt.caller), tt.role)
+		if got != tt.want {
+			t.Errorf("checkRole(%s, %q) = %v, want %v", tt.caller.name, tt.role, got, tt.want)
+		}
+		if !got && rec.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want 403", rec.Code)
+		}
+	}
+
+	body := `{"access": "team-admin", "secret": "secret", "role": "admin"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), configKey{}, &config.Config{}))
+	rec := serveAs(team, "POST /v1/users", handleCreateUser, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("create admin user: status = %d, want 403 (body %s)", rec.Code, rec.Body)
+	}
+}
@@ -3,51 +3,143 @@ package api
 
 import (
 	"net/http"
+	"sync/atomic"
 	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/services"
 )
 
 const apiPrefix = "/v1"
 
-// NewServer creates an *http.Server with all routes registered and
-// sensible timeouts. The token is required for all routes except /healthz.
-func NewServer(version string) *http.Server {
+// Server is the API server. Its configuration can be replaced with Reload
+// while it runs; requests in flight keep the configuration they started with.
+type Server struct {
+	*http.Server
+	state   atomic.Pointer[serverState]
+	metrics *serverMetrics
+}
+
+// serverState is what Reload replaces: the effective configuration of every
+// site and the authenticator for the API tokens and JWTs of the same load.
+type serverState struct {
+	sites *siteConfigs
+	auth  *authenticator
+}
+
+// NewServer creates a Server with all routes registered and sensible
+// timeouts. A token is required for all routes except /healthz; each route
+// checks the scope it needs with allow.
+// Routes without a /sites/{site} prefix act on site.
+func NewServer(version string, cfg *config.Config, site string) (*Server, error) {
+	s := &Server{metrics: newServerMetrics()}
+	if err := s.Reload(cfg, site); err != nil {
+		return nil, err
+	}
 	mux := http.NewServeMux()
 
 	// Health check — no auth required.
 	mux.HandleFunc("GET /healthz", handleHealth)
 
+	// Prometheus metrics; see CollectMetrics.
+	mux.HandleFunc("GET /metrics", allow(config.ScopeMetrics, s.handleMetrics))
+
 	// Bucket routes.
-	mux.HandleFunc("GET "+apiPrefix+"/buckets", handleListBuckets)
-	mux.HandleFunc("POST "+apiPrefix+"/buckets", mutating(handleCreateBucket))
-	mux.HandleFunc("DELETE "+apiPrefix+"/buckets/{name}", mutating(handleDeleteBucket))
-	mux.HandleFunc("POST "+apiPrefix+"/buckets/{name}/public", mutating(handleMakePublic))
-	mux.HandleFunc("POST "+apiPrefix+"/buckets/{name}/private", mutating(handleMakePrivate))
+	mux.HandleFunc("GET "+apiPrefix+"/buckets", allow(config.ScopeBucketsRead, handleListBuckets))
+	mux.HandleFunc("POST "+apiPrefix+"/buckets", allow(config.ScopeBucketsWrite, mutating("bucket.create", handleCreateBucket)))
+	mux.HandleFunc("DELETE "+apiPrefix+"/buckets/{name}", allow(config.ScopeBucketsWrite, handleDeleteBucket))
+	mux.HandleFunc("POST "+apiPrefix+"/buckets/{name}/public", allow(config.ScopeBucketsWrite, mutating("bucket.make_public", handleMakePublic)))
+	mux.HandleFunc("POST "+apiPrefix+"/buckets/{name}/private", allow(config.ScopeBucketsWrite, mutating("bucket.make_private", handleMakePrivate)))
+	mux.HandleFunc("GET "+apiPrefix+"/buckets/{name}/tags", allow(config.ScopeBucketsRead, handleGetBucketTags))
+	mux.HandleFunc("PUT "+apiPrefix+"/buckets/{name}/tags", allow(config.ScopeBucketsWrite, mutating("bucket.set_tags", handleSetBucketTags)))
+	mux.HandleFunc("DELETE "+apiPrefix+"/buckets/{name}/tags", allow(config.ScopeBucketsWrite, mutating("bucket.delete_tags", handleDeleteBucketTags)))
+	mux.HandleFunc("GET "+apiPrefix+"/buckets/{name}/lifecycle", allow(config.ScopeBucketsRead, handleGetLifecycle))
+	mux.HandleFunc("PUT "+apiPrefix+"/buckets/{name}/lifecycle", allow(config.ScopeBucketsWrite, mutating("lifecycle.set", handleSetLifecycle)))
+	mux.HandleFunc("DELETE "+apiPrefix+"/buckets/{name}/lifecycle", allow(config.ScopeBucketsWrite, mutating("lifecycle.clear", handleDeleteLifecycle)))
+	mux.HandleFunc("PUT "+apiPrefix+"/buckets/{name}/lifecycle/rules/{id}", allow(config.ScopeBucketsWrite, mutating("lifecycle.put_rule", handlePutLifecycleRule)))
+	mux.HandleFunc("DELETE "+apiPrefix+"/buckets/{name}/lifecycle/rules/{id}", allow(config.ScopeBucketsWrite, mutating("lifecycle.delete_rule", handleDeleteLifecycleRule)))
+	mux.HandleFunc("GET "+apiPrefix+"/buckets/{name}/cors", allow(config.ScopeBucketsRead, handleGetCors))
+	mux.HandleFunc("PUT "+apiPrefix+"/buckets/{name}/cors", allow(config.ScopeBucketsWrite, mutating("cors.set", handleSetCors)))
+	mux.HandleFunc("DELETE "+apiPrefix+"/buckets/{name}/cors", allow(config.ScopeBucketsWrite, mutating("cors.clear", handleDeleteCors)))
+	mux.HandleFunc("POST "+apiPrefix+"/buckets/{name}/cors/allow-get", allow(config.ScopeBucketsWrite, mutating("cors.allow_get", handleCorsAllowGet)))
+	mux.HandleFunc("GET "+apiPrefix+"/buckets/{name}/report", allow(config.ScopeBucketsRead, handleBucketReport))
+	mux.HandleFunc("POST "+apiPrefix+"/buckets/{name}/presign", allow(config.ScopeBucketsRead, handlePresign))
+
+	// Multipart upload routes.
+	mux.HandleFunc("GET "+apiPrefix+"/multipart-uploads", allow(config.ScopeBucketsRead, handleListMultipartUploads))
+	mux.HandleFunc("POST "+apiPrefix+"/multipart-uploads/abort", allow(config.ScopeBucketsWrite, mutating("multipart.abort", handleAbortMultipartUploads)))
 
 	// User routes.
-	mux.HandleFunc("GET "+apiPrefix+"/users", handleListUsers)
-	mux.HandleFunc("GET "+apiPrefix+"/users/{access}", handleGetUser)
-	mux.HandleFunc("POST "+apiPrefix+"/users", mutating(handleCreateUser))
-	mux.HandleFunc("DELETE "+apiPrefix+"/users/{access}", mutating(handleDeleteUser))
+	mux.HandleFunc("GET "+apiPrefix+"/users", allow(config.ScopeUsersRead, handleListUsers))
+	mux.HandleFunc("GET "+apiPrefix+"/users/{access}", allow(config.ScopeUsersRead, handleGetUser))
+	mux.HandleFunc("GET "+apiPrefix+"/users/{access}/client-config", allow(config.ScopeUsersRead, allow(config.ScopeSecretsRead, handleClientConfig)))
+	mux.HandleFunc("POST "+apiPrefix+"/users", allow(config.ScopeUsersManage, mutating("user.create", handleCreateUser)))
+	mux.HandleFunc("DELETE "+apiPrefix+"/users/{access}", allow(config.ScopeUsersManage, mutating("user.delete", handleDeleteUser)))
+	mux.HandleFunc("GET "+apiPrefix+"/users/{access}/access", allow(config.ScopeUsersRead, handleUserAccess))
+	mux.HandleFunc("GET "+apiPrefix+"/users/{access}/service-accounts", allow(config.ScopeUsersRead, handleListServiceAccounts))
+	mux.HandleFunc("POST "+apiPrefix+"/users/{access}/service-accounts", allow(config.ScopeUsersManage, mutating("service_account.create", handleCreateServiceAccount)))
+	mux.HandleFunc("POST "+apiPrefix+"/users/{access}/service-accounts/sync", allow(config.ScopeUsersManage, mutating("service_account.sync", handleSyncServiceAccounts)))
+	mux.HandleFunc("DELETE "+apiPrefix+"/users/{access}/service-accounts/{child}", allow(config.ScopeUsersManage, mutating("service_account.revoke", handleRevokeServiceAccount)))
+	mux.HandleFunc("POST "+apiPrefix+"/users/{access}/service-accounts/{child}/rotate", allow(config.ScopeUsersManage, mutating("service_account.rotate", handleRotateServiceAccount)))
+
+	// Group routes.
+	mux.HandleFunc("GET "+apiPrefix+"/groups", allow(config.ScopeUsersRead, handleListGroups))
+	mux.HandleFunc("POST "+apiPrefix+"/groups", allow(config.ScopeUsersManage, mutating("group.create", handleCreateGroup)))
+	mux.HandleFunc("GET "+apiPrefix+"/groups/{group}", allow(config.ScopeUsersRead, handleGetGroup))
+	mux.HandleFunc("DELETE "+apiPrefix+"/groups/{group}", allow(config.ScopeUsersManage, mutating("group.delete", handleDeleteGroup)))
+	mux.HandleFunc("POST "+apiPrefix+"/groups/{group}/sync", allow(config.ScopeUsersManage, mutating("group.sync", handleSyncGroup)))
+	mux.HandleFunc("PUT "+apiPrefix+"/groups/{group}/members/{access}", allow(config.ScopeUsersManage, mutating("group.add_member", handleAddGroupMember)))
+	mux.HandleFunc("DELETE "+apiPrefix+"/groups/{group}/members/{access}", allow(config.ScopeUsersManage, mutating("group.remove_member", handleRemoveGroupMember)))
+	mux.HandleFunc("PUT "+apiPrefix+"/groups/{group}/buckets/{bucket}", allow(config.ScopeUsersManage, allow(config.ScopeBucketsWrite, mutating("group.grant_bucket", handleGrantGroupBucket))))
+	mux.HandleFunc("DELETE "+apiPrefix+"/groups/{group}/buckets/{bucket}", allow(config.ScopeUsersManage, allow(config.ScopeBucketsWrite, mutating("group.revoke_bucket", handleRevokeGroupBucket))))
+
+	// Site routes. Every /v1 route is also served as /v1/sites/{site}/...
+	mux.HandleFunc("GET "+apiPrefix+"/sites", allow(config.ScopeBucketsRead, handleListSites))
 
 	// Provision route.
-	mux.HandleFunc("POST "+apiPrefix+"/provision", mutating(handleProvision))
+	mux.HandleFunc("POST "+apiPrefix+"/provision", allow(config.ScopeProvision, mutating("provision", handleProvision)))
 
 	// Catch-all for unknown routes → 404.
 	mux.HandleFunc("/", handleNotFound)
 
 	handler := chain(
-		mux,
+		recordRoute(mux),
+		siteMiddleware,
 		recoveryMiddleware,
 		loggingMiddleware,
 		authMiddleware,
+		metricsMiddleware(s.metrics),
+		stateMiddleware(&s.state),
 	)
 
-	return &http.Server{
+	s.Server = &http.Server{
 		Handler:           handler,
 		ReadHeaderTimeout: 10 * time.Second,
 		WriteTimeout:      60 * time.Second,
 		IdleTimeout:       120 * time.Second,
 	}
+	return s, nil
+}
+
+// Reload atomically replaces the configuration used by new requests,
+// including the API tokens and JWT keys, and drops the cached gateway
+// transports so that TLS CA and client certificate files are read again. On
+// error the current configuration stays active.
+//
+// The listeners with their TLS settings and the metrics interval are set up
+// at startup; a reload does not change them.
+func (s *Server) Reload(cfg *config.Config, site string) error {
+	auth, err := newAuthenticator(cfg)
+	if err != nil {
+		return err
+	}
+	sites, err := newSiteConfigs(cfg, site)
+	if err != nil {
+		return err
+	}
+	s.state.Store(&serverState{sites: sites, auth: auth})
+	services.ResetGatewayTransports()
+	return nil
 }
 
 // handleHealth returns a simple health check response. No auth required.
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+func TestServerReload(t *testing.T) {
+	siteConfig := func(token string, sites ...string) *config.Config {
+		cfg := &config.Config{EndpointURL: "http://default:7070", APIToken: token, Sites: map[string]config.Site{}}
+		for _, name := range sites {
+			cfg.Sites[name] = config.Site{EndpointURL: "http://" + name + ":7070"}
+		}
+		return cfg
+	}
+	srv, err := NewServer("test", siteConfig("old", "eu"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// listSites returns the status of GET /v1/sites with token and the
+	// names of the sites it lists.
+	listSites := func(token string) (int, []string) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/sites", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rec, req)
+		var sites []siteInfo
+		json.Unmarshal(rec.Body.Bytes(), &sites)
+		names := []string{}
+		for _, site := range sites {
+			names = append(names, site.Name)
+		}
+		return rec.Code, names
+	}
+	check := func(step, token string, wantStatus int, wantSites ...string) {
+		t.Helper()
+		status, names := listSites(token)
+		if status != wantStatus {
+			t.Fatalf("%s: GET /v1/sites with %q: status = %d, want %d", step, token, status, wantStatus)
+		}
+		if wantStatus == http.StatusOK && !slices.Equal(names, wantSites) {
+			t.Errorf("%s: sites = %v, want %v", step, names, wantSites)
+		}
+	}
+	check("start", "old", http.StatusOK, "default", "eu")
+
+	if err := srv.Reload(siteConfig("new", "asia"), ""); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	check("reload", "old", http.StatusUnauthorized)
+	check("reload", "new", http.StatusOK, "default", "asia")
+
+	// Neither an invalid authentication setup nor an unknown default site
+	// changes any part of the configuration.
+	if err := srv.Reload(siteConfig("", "eu"), ""); err == nil {
+		t.Error("Reload() without tokens succeeded")
+	}
+	if err := srv.Reload(siteConfig("newer", "eu"), "missing"); err == nil {
+		t.Error("Reload() with an unknown default site succeeded")
+	}
+	check("failed reload", "newer", http.StatusUnauthorized)
+	check("failed reload", "new", http.StatusOK, "default", "asia")
+}
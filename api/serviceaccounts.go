@@ -0,0 +1,112 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// serviceAccountRequest is the JSON body for POST /v1/users/{access}/service-accounts.
+type serviceAccountRequest struct {
+	Access      string `json:"access"`
+	Secret      string `json:"secret"`
+	Description string `json:"description"`
+}
+
+// handleListServiceAccounts returns the service accounts of a user.
+func handleListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	accounts, err := services.ListServiceAccounts(cfg, r.PathValue("access"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, accounts)
+}
+
+// handleCreateServiceAccount creates a service account under a user and grants
+// it the user's buckets. The response contains the secret.
+func handleCreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	var req serviceAccountRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Access != "" && !checkUser(w, r, req.Access) {
+		return
+	}
+
+	created, err := services.CreateServiceAccount(r.Context(), cfg, services.ServiceAccountRequest{
+		Parent:      r.PathValue("access"),
+		Access:      req.Access,
+		Secret:      req.Secret,
+		Description: req.Description,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// serviceAccountOf returns the requested service account if it belongs to the
+// user in the path, writing a 404 otherwise.
+func serviceAccountOf(w http.ResponseWriter, r *http.Request) (string, bool) {
+	cfg := requestConfig(r)
+	parent, child := r.PathValue("access"), r.PathValue("child")
+	account, err := services.GetServiceAccount(cfg, child)
+	if err == nil && account.Parent != parent {
+		err = fmt.Errorf("service account %s does not belong to %s", child, parent)
+	}
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return "", false
+	}
+	return child, true
+}
+
+// handleRevokeServiceAccount deletes a service account.
+func handleRevokeServiceAccount(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	child, ok := serviceAccountOf(w, r)
+	if !ok {
+		return
+	}
+	if err := services.RevokeServiceAccount(r.Context(), cfg, child); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access": child,
+		"status": "revoked",
+	})
+}
+
+// handleRotateServiceAccount replaces a service account's secret. The response
+// contains the new secret.
+func handleRotateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	child, ok := serviceAccountOf(w, r)
+	if !ok {
+		return
+	}
+	rotated, err := services.RotateServiceAccount(r.Context(), cfg, child)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, rotated)
+}
+
+// handleSyncServiceAccounts re-applies the service account grants to every
+// bucket the user owns.
+func handleSyncServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	buckets, err := services.SyncServiceAccountGrants(r.Context(), cfg, r.PathValue("access"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"buckets": buckets})
+}
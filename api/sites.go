@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// siteInfo is one entry of GET /v1/sites. Credentials are never returned.
+// Default marks the site used by routes without a /sites/{site} prefix.
+type siteInfo struct {
+	Name        string `json:"name"`
+	EndpointURL string `json:"endpointURL"`
+	Default     bool   `json:"default"`
+}
+
+// siteConfigs is the effective configuration of every site, resolved once
+// per (re)load of the configuration.
+type siteConfigs struct {
+	root        *config.Config
+	defaultSite string
+	sites       map[string]*config.Config
+}
+
+func newSiteConfigs(root *config.Config, defaultSite string) (*siteConfigs, error) {
+	sc := &siteConfigs{root: root, sites: map[string]*config.Config{}}
+	for _, name := range root.SiteNames() {
+		cfg, err := root.ForSite(name)
+		if err != nil {
+			return nil, err
+		}
+		sc.sites[name] = cfg
+	}
+	def, err := root.ForSite(defaultSite)
+	if err != nil {
+		return nil, err
+	}
+	sc.defaultSite = def.Site
+	return sc, nil
+}
+
+type configKey struct{}
+
+// requestConfig returns the configuration of the site a request is for.
+func requestConfig(r *http.Request) *config.Config {
+	return r.Context().Value(configKey{}).(*config.Config)
+}
+
+// siteMiddleware serves /v1/sites/{site}/... against the named site by
+// rewriting the path to the regular /v1/... route. All other requests use
+// the default site. Handlers read the site's configuration with
+// requestConfig.
+func siteMiddleware(next http.Handler) http.Handler {
+	prefix := apiPrefix + "/sites/"
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sites := requestState(r).sites
+		site := sites.defaultSite
+		if rest, ok := strings.CutPrefix(r.URL.Path, prefix); ok {
+			name, tail, _ := strings.Cut(rest, "/")
+			if _, ok := sites.sites[name]; !ok {
+				writeError(w, http.StatusNotFound, fmt.Errorf("unknown site %q", name))
+				return
+			}
+			site = name
+			r = r.Clone(r.Context())
+			r.URL.Path = apiPrefix + "/" + tail
+			if r.URL.RawPath != "" {
+				if _, rawTail, ok := strings.Cut(strings.TrimPrefix(r.URL.RawPath, prefix), "/"); ok {
+					r.URL.RawPath = apiPrefix + "/" + rawTail
+				} else {
+					r.URL.RawPath = ""
+				}
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), configKey{}, sites.sites[site])
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// handleListSites returns the configured sites.
+func handleListSites(w http.ResponseWriter, r *http.Request) {
+	sites := requestState(r).sites
+	list := []siteInfo{}
+	for _, name := range sites.root.SiteNames() {
+		list = append(list, siteInfo{
+			Name:        name,
+			EndpointURL: sites.sites[name].EndpointURL,
+			Default:     name == sites.defaultSite,
+		})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+func TestSiteMiddleware(t *testing.T) {
+	root := &config.Config{EndpointURL: "http://default:7070", Sites: map[string]config.Site{
+		"eu": {EndpointURL: "http://eu:7070"},
+	}}
+	sc, err := newSiteConfigs(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var current atomic.Pointer[serverState]
+	current.Store(&serverState{sites: sc})
+
+	var gotSite, gotPath, gotRawPath string
+	h := stateMiddleware(&current)(siteMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSite, gotPath, gotRawPath = requestConfig(r).Site, r.URL.Path, r.URL.RawPath
+	})))
+
+	tests := []struct {
+		target      string
+		wantStatus  int
+		wantSite    string
+		wantPath    string
+		wantRawPath string
+	}{
+		{"/v1/buckets", http.StatusOK, "default", "/v1/buckets", ""},
+		{"/v1/sites", http.StatusOK, "default", "/v1/sites", ""},
+		{"/v1/sites/eu/buckets/logs", http.StatusOK, "eu", "/v1/buckets/logs", ""},
+		{"/v1/sites/default/users", http.StatusOK, "default", "/v1/users", ""},
+		{"/v1/sites/eu/users/a%2Fb", http.StatusOK, "eu", "/v1/users/a/b", "/v1/users/a%2Fb"},
+		{"/v1/sites/asia/buckets", http.StatusNotFound, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			gotSite, gotPath, gotRawPath = "", "", ""
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotSite != tt.wantSite || gotPath != tt.wantPath || gotRawPath != tt.wantRawPath {
+				t.Errorf("site, path, raw path = %q, %q, %q, want %q, %q, %q",
+					gotSite, gotPath, gotRawPath, tt.wantSite, tt.wantPath, tt.wantRawPath)
+			}
+		})
+	}
+}
@@ -24,42 +24,80 @@ func maskSecret(user models.User, showSecrets bool) models.User {
 	return user
 }
 
-// handleListUsers returns all users. Secrets are masked unless ?showSecrets=true.
+// handleListUsers returns the users the caller may see. Secrets are masked
+// unless ?showSecrets=true.
 func handleListUsers(w http.ResponseWriter, r *http.Request) {
-	userService := services.NewUserService()
+	cfg := requestConfig(r)
+	showSecrets, ok := checkShowSecrets(w, r)
+	if !ok {
+		return
+	}
+	userService := services.NewUserService(cfg)
 	users, err := userService.ListUsers()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	showSecrets := r.URL.Query().Get("showSecrets") == "true"
-	masked := make([]models.User, len(users))
-	for i, u := range users {
-		masked[i] = maskSecret(u, showSecrets)
+	c := requestCaller(r)
+	masked := []models.User{}
+	for _, u := range users {
+		if c.AllowsUser(u.Access) {
+			masked = append(masked, maskSecret(u, showSecrets))
+		}
 	}
 	writeJSON(w, http.StatusOK, masked)
 }
 
 // handleGetUser returns a single user by access key. Secret is masked unless ?showSecrets=true.
 func handleGetUser(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
 	access := r.PathValue("access")
 	if access == "" {
 		writeError(w, http.StatusBadRequest, errors.New("access key is required"))
 		return
 	}
+	showSecrets, ok := checkShowSecrets(w, r)
+	if !ok {
+		return
+	}
 
-	userService := services.NewUserService()
+	userService := services.NewUserService(cfg)
 	user, err := userService.GetUser(access)
 	if err != nil {
 		writeError(w, http.StatusNotFound, err)
 		return
 	}
 
-	showSecrets := r.URL.Query().Get("showSecrets") == "true"
 	writeJSON(w, http.StatusOK, maskSecret(*user, showSecrets))
 }
 
+// handleClientConfig renders a user's credentials as a client configuration
+// (?format=aws|rclone|s3cmd|mc|env). The response contains the secret.
+func handleClientConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
+	access := r.PathValue("access")
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		writeError(w, http.StatusBadRequest, errors.New("format is required"))
+		return
+	}
+
+	userService := services.NewUserService(cfg)
+	user, err := userService.GetUser(access)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	clientConfig, err := services.RenderClientConfig(cfg, *user, format)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, clientConfig)
+}
+
 // createUserRequest is the JSON body for POST /v1/users.
 type createUserRequest struct {
 	Access    string `json:"access"`
@@ -72,6 +110,7 @@ type createUserRequest struct {
 
 // handleCreateUser creates a new user via the VersityGW API.
 func handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
 	var req createUserRequest
 	if !decodeJSON(w, r, &req) {
 		return
@@ -84,6 +123,9 @@ func handleCreateUser(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, errInvalidRole)
 		return
 	}
+	if !checkUser(w, r, req.Access) || !checkRole(w, r, req.Role) {
+		return
+	}
 
 	userReq := models.UserCreateRequest{
 		Access:    req.Access,
@@ -94,8 +136,8 @@ func handleCreateUser(w http.ResponseWriter, r *http.Request) {
 		ProjectID: req.ProjectID,
 	}
 
-	vgwService := services.NewVersityGWService()
-	if err := vgwService.CreateUser(userReq); err != nil {
+	vgwService := services.NewVersityGWService(cfg)
+	if err := vgwService.CreateUser(r.Context(), userReq); err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
@@ -106,16 +148,17 @@ func handleCreateUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleDeleteUser deletes a user by access key.
+// handleDeleteUser deletes a user by access key, revoking its service accounts
+// and removing it from its groups.
 func handleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	cfg := requestConfig(r)
 	access := r.PathValue("access")
 	if access == "" {
 		writeError(w, http.StatusBadRequest, errors.New("access key is required"))
 		return
 	}
 
-	vgwService := services.NewVersityGWService()
-	if err := vgwService.DeleteUser(access); err != nil {
+	if err := services.DeleteUser(r.Context(), cfg, access); err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
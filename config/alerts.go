@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// Formats of a quota alert webhook.
+const (
+	WebhookJSON  = "json"
+	WebhookSlack = "slack"
+)
+
+// QuotaAlertsConfig configures the quota watcher of the API server, which
+// notifies when a bucket's usage crosses a percentage of its quota.
+type QuotaAlertsConfig struct {
+	// Thresholds are percentages of the quota (default 80, 90, 100). Each
+	// fires once when usage rises to it.
+	Thresholds []int `json:"thresholds" yaml:"thresholds"`
+	// Hysteresis is how many percentage points usage must fall below a
+	// threshold before the threshold can fire again (default 5).
+	Hysteresis *int `json:"hysteresis" yaml:"hysteresis"`
+	// Interval is how often bucket usage is checked (default 5m).
+	Interval time.Duration `json:"interval" yaml:"interval"`
+	// Webhooks receive every alert as an HTTP POST.
+	Webhooks []AlertWebhook `json:"webhooks" yaml:"webhooks"`
+	// Email sends every alert over SMTP; nil disables it.
+	Email *AlertEmail `json:"email" yaml:"email"`
+}
+
+// AlertWebhook is an HTTP endpoint receiving quota alerts, either as a JSON
+// object (WebhookJSON, the default) or as a Slack-compatible {"text": ...}
+// message (WebhookSlack).
+type AlertWebhook struct {
+	URL    string `json:"url" yaml:"url"`
+	Format string `json:"format" yaml:"format"`
+}
+
+// AlertEmail configures quota alert emails. With a username, the SMTP server
+// must offer STARTTLS (or be on localhost) for PLAIN authentication.
+type AlertEmail struct {
+	SMTPHost string   `json:"smtpHost" yaml:"smtpHost"`
+	SMTPPort int      `json:"smtpPort" yaml:"smtpPort"`
+	From     string   `json:"from" yaml:"from"`
+	To       []string `json:"to" yaml:"to"`
+	Username string   `json:"username" yaml:"username"`
+	Password string   `json:"password" yaml:"password"`
+	// PasswordFile or PasswordCommand provide the password like
+	// adminSecretFile and adminSecretCommand.
+	PasswordFile    string `json:"passwordFile" yaml:"passwordFile"`
+	PasswordCommand string `json:"passwordCommand" yaml:"passwordCommand"`
+}
+
+// WithDefaults returns the configuration with empty thresholds, hysteresis,
+// interval, webhook formats and SMTP port set to their defaults, and the
+// thresholds sorted.
+func (q QuotaAlertsConfig) WithDefaults() QuotaAlertsConfig {
+	if len(q.Thresholds) == 0 {
+		q.Thresholds = []int{80, 90, 100}
+	} else {
+		q.Thresholds = append([]int(nil), q.Thresholds...)
+		sort.Ints(q.Thresholds)
+	}
+	if q.Hysteresis == nil {
+		hysteresis := 5
+		q.Hysteresis = &hysteresis
+	}
+	if q.Interval == 0 {
+		q.Interval = 5 * time.Minute
+	}
+	webhooks := make([]AlertWebhook, len(q.Webhooks))
+	for i, w := range q.Webhooks {
+		if w.Format == "" {
+			w.Format = WebhookJSON
+		}
+		webhooks[i] = w
+	}
+	q.Webhooks = webhooks
+	if q.Email != nil {
+		email := *q.Email
+		if email.SMTPPort == 0 {
+			email.SMTPPort = 587
+		}
+		q.Email = &email
+	}
+	return q
+}
+
+// validate checks the thresholds, hysteresis, interval and notification targets.
+func (q QuotaAlertsConfig) validate() error {
+	seen := map[int]bool{}
+	for _, t := range q.Thresholds {
+		if t < 1 || t > 1000 {
+			return fmt.Errorf("quotaAlerts: threshold %d must be a percentage between 1 and 1000", t)
+		}
+		if seen[t] {
+			return fmt.Errorf("quotaAlerts: threshold %d is listed twice", t)
+		}
+		seen[t] = true
+	}
+	if q.Hysteresis != nil && *q.Hysteresis < 0 {
+		return fmt.Errorf("quotaAlerts: hysteresis must not be negative")
+	}
+	if q.Interval < 0 {
+		return fmt.Errorf("quotaAlerts: interval must not be negative")
+	}
+	if len(q.Webhooks) == 0 && q.Email == nil {
+		return fmt.Errorf("quotaAlerts: at least one webhook or email is required")
+	}
+	for _, w := range q.Webhooks {
+		u, err := url.Parse(w.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("quotaAlerts: invalid webhook url %q", w.URL)
+		}
+		if w.Format != "" && w.Format != WebhookJSON && w.Format != WebhookSlack {
+			return fmt.Errorf("quotaAlerts: webhook format %q must be %q or %q", w.Format, WebhookJSON, WebhookSlack)
+		}
+	}
+	if e := q.Email; e != nil {
+		if e.SMTPHost == "" {
+			return fmt.Errorf("quotaAlerts: email.smtpHost is required")
+		}
+		if e.SMTPPort < 0 || e.SMTPPort > 65535 {
+			return fmt.Errorf("quotaAlerts: invalid email.smtpPort %d", e.SMTPPort)
+		}
+		if _, err := mail.ParseAddress(e.From); err != nil {
+			return fmt.Errorf("quotaAlerts: invalid email.from %q: %w", e.From, err)
+		}
+		if len(e.To) == 0 {
+			return fmt.Errorf("quotaAlerts: email.to needs at least one address")
+		}
+		for _, to := range e.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("quotaAlerts: invalid email.to address %q: %w", to, err)
+			}
+		}
+		if err := validateSecretSource("password", e.PasswordFile, e.PasswordCommand); err != nil {
+			return fmt.Errorf("quotaAlerts: email: %w", err)
+		}
+	}
+	return nil
+}
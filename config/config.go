@@ -4,57 +4,119 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Config holds runtime configuration values.
 type Config struct {
-	AdminAccess   string `json:"adminAccess" yaml:"adminAccess"`
-	AdminSecret   string `json:"adminSecret" yaml:"adminSecret"`
-	EndpointURL   string `json:"endpointURL" yaml:"endpointURL"`
-	Region        string `json:"region" yaml:"region"`
-	UsersJSONPath string `json:"usersJSONPath" yaml:"usersJSONPath"`
-	ZFSPoolBase   string `json:"zfsPoolBase" yaml:"zfsPoolBase"`
-	MountBase     string `json:"mountBase" yaml:"mountBase"`
-	APIListen     string `json:"apiListen" yaml:"apiListen"`
-	APIToken      string `json:"apiToken" yaml:"apiToken"`
+	AdminAccess string `json:"adminAccess" yaml:"adminAccess"`
+	AdminSecret string `json:"adminSecret" yaml:"adminSecret"`
+	EndpointURL string `json:"endpointURL" yaml:"endpointURL"`
+	// PublicEndpointURL is the endpoint handed out to S3 clients in generated
+	// client configs and presigned URLs. Defaults to EndpointURL.
+	PublicEndpointURL string `json:"publicEndpointURL" yaml:"publicEndpointURL"`
+	Region            string `json:"region" yaml:"region"`
+	UsersJSONPath     string `json:"usersJSONPath" yaml:"usersJSONPath"`
+	ZFSPoolBase       string `json:"zfsPoolBase" yaml:"zfsPoolBase"`
+	MountBase         string `json:"mountBase" yaml:"mountBase"`
+	APIListen         string `json:"apiListen" yaml:"apiListen"`
+	APIToken          string `json:"apiToken" yaml:"apiToken"`
+	// AdminSecretFile and APITokenFile read a secret from a file (e.g. a
+	// systemd credential or docker secret) instead of the plaintext value.
+	AdminSecretFile string `json:"adminSecretFile" yaml:"adminSecretFile"`
+	APITokenFile    string `json:"apiTokenFile" yaml:"apiTokenFile"`
+	// AdminSecretCommand and APITokenCommand run a shell command and use its
+	// standard output as the secret, like git credential helpers.
+	AdminSecretCommand string `json:"adminSecretCommand" yaml:"adminSecretCommand"`
+	APITokenCommand    string `json:"apiTokenCommand" yaml:"apiTokenCommand"`
+	// APITokens are further API tokens, each limited to some scopes and
+	// optionally to buckets and users matching name patterns.
+	APITokens []APIToken `json:"apiTokens" yaml:"apiTokens"`
+	// JWT additionally accepts JWT bearer tokens; nil disables them.
+	JWT *JWTConfig `json:"jwt" yaml:"jwt"`
+	// APITLSCertFile and APITLSKeyFile make the API server speak HTTPS on
+	// apiListen. They are loaded again when they change (e.g. on renewal).
+	APITLSCertFile string `json:"apiTLSCertFile" yaml:"apiTLSCertFile"`
+	APITLSKeyFile  string `json:"apiTLSKeyFile" yaml:"apiTLSKeyFile"`
+	// APITLSClientCAFile requires API clients to present a certificate
+	// signed by one of these CAs, in addition to their bearer token.
+	APITLSClientCAFile string `json:"apiTLSClientCAFile" yaml:"apiTLSClientCAFile"`
+	// APISocket is a Unix socket the API is also served on for local
+	// callers, with APISocketMode permissions (octal, default 0660) and owned
+	// by APISocketGroup if set.
+	APISocket      string `json:"apiSocket" yaml:"apiSocket"`
+	APISocketMode  string `json:"apiSocketMode" yaml:"apiSocketMode"`
+	APISocketGroup string `json:"apiSocketGroup" yaml:"apiSocketGroup"`
+	// MetricsInterval is how often the API server refreshes the bucket, pool
+	// and user metrics served on /metrics.
+	MetricsInterval time.Duration `json:"metricsInterval" yaml:"metricsInterval"`
+	// QuotaAlerts notifies when bucket usage crosses a percentage of the
+	// quota, checked by the API server; nil disables it.
+	QuotaAlerts *QuotaAlertsConfig `json:"quotaAlerts" yaml:"quotaAlerts"`
+	// DataDir holds state recorded by vgw-manager itself (e.g. service accounts).
+	DataDir string `json:"dataDir" yaml:"dataDir"`
+	// AuditLog records every mutating operation of the CLI, API and TUI: an
+	// absolute file path the events are appended to as JSON lines, or
+	// AuditJournald to send them to the systemd journal. Empty disables it.
+	AuditLog string `json:"auditLog" yaml:"auditLog"`
+	// RequestTimeout bounds a single VersityGW request attempt (e.g. "30s").
+	RequestTimeout time.Duration `json:"requestTimeout" yaml:"requestTimeout"`
+	// MaxAttempts is how often idempotent VersityGW requests are tried on
+	// connection errors and 5xx responses; 1 disables retries.
+	MaxAttempts int `json:"maxAttempts" yaml:"maxAttempts"`
+	// BucketCacheTTL is how long bucket listings reuse the owner, visibility
+	// and tags read from the gateway for a bucket.
+	BucketCacheTTL time.Duration `json:"bucketCacheTTL" yaml:"bucketCacheTTL"`
+	// TLSCAFile is a PEM bundle trusted in addition to the system roots when
+	// connecting to an HTTPS endpoint (e.g. a private CA).
+	TLSCAFile string `json:"tlsCAFile" yaml:"tlsCAFile"`
+	// TLSCertFile and TLSKeyFile are the client certificate and key presented
+	// to endpoints that require mutual TLS.
+	TLSCertFile string `json:"tlsCertFile" yaml:"tlsCertFile"`
+	TLSKeyFile  string `json:"tlsKeyFile" yaml:"tlsKeyFile"`
+	// TLSInsecureSkipVerify disables verification of the endpoint certificate.
+	// Only meant for lab setups.
+	TLSInsecureSkipVerify bool `json:"tlsInsecureSkipVerify" yaml:"tlsInsecureSkipVerify"`
+	// Sites are further gateways managed from the same installation; the
+	// top-level values form the site named DefaultSiteName.
+	Sites map[string]Site `json:"sites" yaml:"sites"`
+	// DefaultSite is the site used when no --context is given; VGW_CONTEXT
+	// overrides it.
+	DefaultSite string `json:"defaultSite" yaml:"defaultSite"`
+	// Site is the name of the site a Config returned by ForSite describes.
+	Site string `json:"-" yaml:"-"`
 }
 
+// AuditJournald as auditLog sends audit events to the systemd journal.
+const AuditJournald = "journald"
+
 var (
 	// DefaultConfigPath is the default file path used when no override is provided.
 	DefaultConfigPath = "/etc/vgw-manager.yaml"
 
 	// Defaults used when no file/env is provided.
 	defaultConfig = Config{
-		AdminAccess:   "changeme-access",
-		AdminSecret:   "changeme-secret",
-		EndpointURL:   "http://localhost:7070",
-		Region:        "local",
-		UsersJSONPath: "/tank/s3/accounts/users.json",
-		ZFSPoolBase:   "tank/s3/buckets",
-		MountBase:     "/tank/s3/buckets",
-		APIListen:     "127.0.0.1:8080",
-	}
-
-	// Exported values used across the app (populated in init).
-	AdminAccess   string
-	AdminSecret   string
-	EndpointURL   string
-	Region        string
-	UsersJSONPath string
-	ZFSPoolBase   string
-	MountBase     string
-	APIListen     string
-	APIToken      string
+		AdminAccess:     "changeme-access",
+		AdminSecret:     "changeme-secret",
+		EndpointURL:     "http://localhost:7070",
+		Region:          "local",
+		UsersJSONPath:   "/tank/s3/accounts/users.json",
+		ZFSPoolBase:     "tank/s3/buckets",
+		MountBase:       "/tank/s3/buckets",
+		APIListen:       "127.0.0.1:8080",
+		DataDir:         "/var/lib/vgw-manager",
+		RequestTimeout:  30 * time.Second,
+		MaxAttempts:     3,
+		BucketCacheTTL:  30 * time.Second,
+		MetricsInterval: time.Minute,
+	}
 )
 
-func init() {
-	// Load defaults without failing hard if config is absent.
-	_ = Load("")
-}
-
-// Load applies configuration from the provided path, environment variables, and defaults.
+// Load reads the configuration from the provided path, environment variables, and defaults.
 // The search order for the file is:
 //  1. configPath argument (if non-empty)
 //  2. VGW_CONFIG_PATH environment variable
@@ -62,37 +124,34 @@ func init() {
 //
 // Environment variables still override values loaded from the file.
 // If the file cannot be read and the path came from the flag/env, the error is returned.
-func Load(configPath string) error {
+// The returned Config describes the top-level site; use ForSite to select another one.
+func Load(configPath string) (*Config, error) {
 	cfg := defaultConfig
 
-	resolvedPath := resolvePath(configPath)
+	resolvedPath := ResolvePath(configPath)
 
 	loadedCfg, err := loadFromFile(resolvedPath, cfg)
 	if err == nil {
 		cfg = loadedCfg
 	} else if configPath != "" || os.Getenv("VGW_CONFIG_PATH") != "" {
-		return fmt.Errorf("failed to load config file %s: %w", resolvedPath, err)
+		return nil, fmt.Errorf("failed to load config file %s: %w", resolvedPath, err)
 	}
 
-	cfg = applyEnv(cfg)
+	cfg, err = applyEnv(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 
 	// Validate the final configuration.
 	if err := cfg.Validate(); err != nil {
-		return fmt.Errorf("invalid configuration: %w", err)
+		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	// Expose package-level vars for callers.
-	AdminAccess = cfg.AdminAccess
-	AdminSecret = cfg.AdminSecret
-	EndpointURL = cfg.EndpointURL
-	Region = cfg.Region
-	UsersJSONPath = cfg.UsersJSONPath
-	ZFSPoolBase = cfg.ZFSPoolBase
-	MountBase = cfg.MountBase
-	APIListen = cfg.APIListen
-	APIToken = cfg.APIToken
+	if err := cfg.resolveSecrets(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 
-	return nil
+	return &cfg, nil
 }
 
 // Validate checks if the configuration values are valid.
@@ -103,6 +162,11 @@ func (c *Config) Validate() error {
 	if _, err := url.ParseRequestURI(c.EndpointURL); err != nil {
 		return fmt.Errorf("invalid endpointURL: %w", err)
 	}
+	if c.PublicEndpointURL != "" {
+		if _, err := url.ParseRequestURI(c.PublicEndpointURL); err != nil {
+			return fmt.Errorf("invalid publicEndpointURL: %w", err)
+		}
+	}
 	if c.Region == "" {
 		return fmt.Errorf("region is required")
 	}
@@ -115,10 +179,84 @@ func (c *Config) Validate() error {
 	if c.MountBase == "" {
 		return fmt.Errorf("mountBase is required")
 	}
-	return nil
+	if c.DataDir == "" {
+		return fmt.Errorf("dataDir is required")
+	}
+	if c.AuditLog != "" && c.AuditLog != AuditJournald && !filepath.IsAbs(c.AuditLog) {
+		return fmt.Errorf("auditLog must be an absolute path or %q", AuditJournald)
+	}
+	if c.RequestTimeout <= 0 {
+		return fmt.Errorf("requestTimeout must be positive")
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("maxAttempts must be at least 1")
+	}
+	if c.MetricsInterval <= 0 {
+		return fmt.Errorf("metricsInterval must be positive")
+	}
+	if c.BucketCacheTTL <= 0 {
+		return fmt.Errorf("bucketCacheTTL must be positive")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("tlsCertFile and tlsKeyFile must be set together")
+	}
+	if (c.APITLSCertFile == "") != (c.APITLSKeyFile == "") {
+		return fmt.Errorf("apiTLSCertFile and apiTLSKeyFile must be set together")
+	}
+	if c.APITLSClientCAFile != "" && c.APITLSCertFile == "" {
+		return fmt.Errorf("apiTLSClientCAFile requires apiTLSCertFile and apiTLSKeyFile")
+	}
+	if _, err := c.SocketMode(); err != nil {
+		return err
+	}
+	for name, path := range map[string]string{
+		"tlsCAFile": c.TLSCAFile, "tlsCertFile": c.TLSCertFile, "tlsKeyFile": c.TLSKeyFile,
+		"apiTLSCertFile": c.APITLSCertFile, "apiTLSKeyFile": c.APITLSKeyFile, "apiTLSClientCAFile": c.APITLSClientCAFile,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	if err := validateSecretSource("adminSecret", c.AdminSecretFile, c.AdminSecretCommand); err != nil {
+		return err
+	}
+	if err := validateSecretSource("apiToken", c.APITokenFile, c.APITokenCommand); err != nil {
+		return err
+	}
+	if err := c.validateTokens(); err != nil {
+		return err
+	}
+	if c.JWT != nil {
+		if err := c.JWT.validate(); err != nil {
+			return err
+		}
+	}
+	if c.QuotaAlerts != nil {
+		if err := c.QuotaAlerts.validate(); err != nil {
+			return err
+		}
+	}
+	return c.validateSites()
 }
 
-func resolvePath(flagPath string) string {
+// SocketMode returns the permissions of the API socket: APISocketMode parsed
+// as an octal number, or 0660.
+func (c *Config) SocketMode() (os.FileMode, error) {
+	if c.APISocketMode == "" {
+		return 0o660, nil
+	}
+	mode, err := strconv.ParseUint(c.APISocketMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid apiSocketMode %q: want octal permissions like 0660", c.APISocketMode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// ResolvePath returns the config file Load reads for the given --config value.
+func ResolvePath(flagPath string) string {
 	if flagPath != "" {
 		return flagPath
 	}
@@ -151,6 +289,9 @@ func loadFromFile(path string, base Config) (Config, error) {
 	if fileCfg.EndpointURL != "" {
 		base.EndpointURL = fileCfg.EndpointURL
 	}
+	if fileCfg.PublicEndpointURL != "" {
+		base.PublicEndpointURL = fileCfg.PublicEndpointURL
+	}
 	if fileCfg.Region != "" {
 		base.Region = fileCfg.Region
 	}
@@ -169,11 +310,86 @@ func loadFromFile(path string, base Config) (Config, error) {
 	if fileCfg.APIToken != "" {
 		base.APIToken = fileCfg.APIToken
 	}
+	if fileCfg.APITokens != nil {
+		base.APITokens = fileCfg.APITokens
+	}
+	if fileCfg.JWT != nil {
+		base.JWT = fileCfg.JWT
+	}
+	if fileCfg.QuotaAlerts != nil {
+		base.QuotaAlerts = fileCfg.QuotaAlerts
+	}
+	if fileCfg.APITLSCertFile != "" {
+		base.APITLSCertFile = fileCfg.APITLSCertFile
+	}
+	if fileCfg.APITLSKeyFile != "" {
+		base.APITLSKeyFile = fileCfg.APITLSKeyFile
+	}
+	if fileCfg.APITLSClientCAFile != "" {
+		base.APITLSClientCAFile = fileCfg.APITLSClientCAFile
+	}
+	if fileCfg.APISocket != "" {
+		base.APISocket = fileCfg.APISocket
+	}
+	if fileCfg.APISocketMode != "" {
+		base.APISocketMode = fileCfg.APISocketMode
+	}
+	if fileCfg.APISocketGroup != "" {
+		base.APISocketGroup = fileCfg.APISocketGroup
+	}
+	if fileCfg.AdminSecretFile != "" {
+		base.AdminSecretFile = fileCfg.AdminSecretFile
+	}
+	if fileCfg.AdminSecretCommand != "" {
+		base.AdminSecretCommand = fileCfg.AdminSecretCommand
+	}
+	if fileCfg.APITokenFile != "" {
+		base.APITokenFile = fileCfg.APITokenFile
+	}
+	if fileCfg.APITokenCommand != "" {
+		base.APITokenCommand = fileCfg.APITokenCommand
+	}
+	if fileCfg.DataDir != "" {
+		base.DataDir = fileCfg.DataDir
+	}
+	if fileCfg.AuditLog != "" {
+		base.AuditLog = fileCfg.AuditLog
+	}
+	if fileCfg.RequestTimeout != 0 {
+		base.RequestTimeout = fileCfg.RequestTimeout
+	}
+	if fileCfg.MaxAttempts != 0 {
+		base.MaxAttempts = fileCfg.MaxAttempts
+	}
+	if fileCfg.MetricsInterval != 0 {
+		base.MetricsInterval = fileCfg.MetricsInterval
+	}
+	if fileCfg.BucketCacheTTL != 0 {
+		base.BucketCacheTTL = fileCfg.BucketCacheTTL
+	}
+	if fileCfg.TLSCAFile != "" {
+		base.TLSCAFile = fileCfg.TLSCAFile
+	}
+	if fileCfg.TLSCertFile != "" {
+		base.TLSCertFile = fileCfg.TLSCertFile
+	}
+	if fileCfg.TLSKeyFile != "" {
+		base.TLSKeyFile = fileCfg.TLSKeyFile
+	}
+	if fileCfg.TLSInsecureSkipVerify {
+		base.TLSInsecureSkipVerify = true
+	}
+	if fileCfg.Sites != nil {
+		base.Sites = fileCfg.Sites
+	}
+	if fileCfg.DefaultSite != "" {
+		base.DefaultSite = fileCfg.DefaultSite
+	}
 
 	return base, nil
 }
 
-func applyEnv(base Config) Config {
+func applyEnv(base Config) (Config, error) {
 	if v := os.Getenv("VGW_ADMIN_ACCESS"); v != "" {
 		base.AdminAccess = v
 	}
@@ -183,6 +399,9 @@ func applyEnv(base Config) Config {
 	if v := os.Getenv("VGW_ENDPOINT_URL"); v != "" {
 		base.EndpointURL = v
 	}
+	if v := os.Getenv("VGW_PUBLIC_ENDPOINT_URL"); v != "" {
+		base.PublicEndpointURL = v
+	}
 	if v := os.Getenv("VGW_REGION"); v != "" {
 		base.Region = v
 	}
@@ -198,8 +417,85 @@ func applyEnv(base Config) Config {
 	if v := os.Getenv("VGW_API_LISTEN"); v != "" {
 		base.APIListen = v
 	}
+	if v := os.Getenv("VGW_API_TLS_CERT_FILE"); v != "" {
+		base.APITLSCertFile = v
+	}
+	if v := os.Getenv("VGW_API_TLS_KEY_FILE"); v != "" {
+		base.APITLSKeyFile = v
+	}
+	if v := os.Getenv("VGW_API_TLS_CLIENT_CA_FILE"); v != "" {
+		base.APITLSClientCAFile = v
+	}
+	if v := os.Getenv("VGW_API_SOCKET"); v != "" {
+		base.APISocket = v
+	}
 	if v := os.Getenv("VGW_API_TOKEN"); v != "" {
 		base.APIToken = v
 	}
-	return base
+	if v := os.Getenv("VGW_ADMIN_SECRET_FILE"); v != "" {
+		base.AdminSecretFile = v
+	}
+	if v := os.Getenv("VGW_ADMIN_SECRET_COMMAND"); v != "" {
+		base.AdminSecretCommand = v
+	}
+	if v := os.Getenv("VGW_API_TOKEN_FILE"); v != "" {
+		base.APITokenFile = v
+	}
+	if v := os.Getenv("VGW_API_TOKEN_COMMAND"); v != "" {
+		base.APITokenCommand = v
+	}
+	if v := os.Getenv("VGW_DATA_DIR"); v != "" {
+		base.DataDir = v
+	}
+	if v := os.Getenv("VGW_AUDIT_LOG"); v != "" {
+		base.AuditLog = v
+	}
+	if v := os.Getenv("VGW_CONTEXT"); v != "" {
+		base.DefaultSite = v
+	}
+	if v := os.Getenv("VGW_REQUEST_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return base, fmt.Errorf("invalid VGW_REQUEST_TIMEOUT: %w", err)
+		}
+		base.RequestTimeout = timeout
+	}
+	if v := os.Getenv("VGW_MAX_ATTEMPTS"); v != "" {
+		attempts, err := strconv.Atoi(v)
+		if err != nil {
+			return base, fmt.Errorf("invalid VGW_MAX_ATTEMPTS: %w", err)
+		}
+		base.MaxAttempts = attempts
+	}
+	if v := os.Getenv("VGW_METRICS_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return base, fmt.Errorf("invalid VGW_METRICS_INTERVAL: %w", err)
+		}
+		base.MetricsInterval = interval
+	}
+	if v := os.Getenv("VGW_BUCKET_CACHE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return base, fmt.Errorf("invalid VGW_BUCKET_CACHE_TTL: %w", err)
+		}
+		base.BucketCacheTTL = ttl
+	}
+	if v := os.Getenv("VGW_TLS_CA_FILE"); v != "" {
+		base.TLSCAFile = v
+	}
+	if v := os.Getenv("VGW_TLS_CERT_FILE"); v != "" {
+		base.TLSCertFile = v
+	}
+	if v := os.Getenv("VGW_TLS_KEY_FILE"); v != "" {
+		base.TLSKeyFile = v
+	}
+	if v := os.Getenv("VGW_TLS_INSECURE_SKIP_VERIFY"); v != "" {
+		insecure, err := strconv.ParseBool(v)
+		if err != nil {
+			return base, fmt.Errorf("invalid VGW_TLS_INSECURE_SKIP_VERIFY: %w", err)
+		}
+		base.TLSInsecureSkipVerify = insecure
+	}
+	return base, nil
 }
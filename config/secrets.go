@@ -0,0 +1,106 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// secretCommandTimeout bounds a *Command secret helper.
+const secretCommandTimeout = 30 * time.Second
+
+// validateSecretSource checks the file and command alternatives of a secret:
+// at most one may be set, and a secret file must be readable by its owner
+// only.
+func validateSecretSource(name, file, command string) error {
+	if file != "" && command != "" {
+		return fmt.Errorf("%sFile and %sCommand cannot be used together", name, name)
+	}
+	if file == "" {
+		return nil
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return fmt.Errorf("invalid %sFile: %w", name, err)
+	}
+	if info.Mode().Perm()&0o044 != 0 {
+		return fmt.Errorf("%sFile %s is readable by group or others; restrict it with chmod go-r", name, file)
+	}
+	return nil
+}
+
+// resolveSecrets replaces the admin secrets, API tokens and the SMTP password
+// of quota alerts by the contents of their *File or the output of their
+// *Command, for the top level and every site.
+func (c *Config) resolveSecrets() error {
+	var err error
+	if c.AdminSecret, err = readSecret("adminSecret", c.AdminSecret, c.AdminSecretFile, c.AdminSecretCommand); err != nil {
+		return err
+	}
+	if c.APIToken, err = readSecret("apiToken", c.APIToken, c.APITokenFile, c.APITokenCommand); err != nil {
+		return err
+	}
+	if err := c.resolveTokens(); err != nil {
+		return err
+	}
+	if c.QuotaAlerts != nil && c.QuotaAlerts.Email != nil {
+		alerts := *c.QuotaAlerts
+		email := *alerts.Email
+		if email.Password, err = readSecret("password", email.Password, email.PasswordFile, email.PasswordCommand); err != nil {
+			return fmt.Errorf("quotaAlerts: email: %w", err)
+		}
+		alerts.Email = &email
+		c.QuotaAlerts = &alerts
+	}
+
+	sites := make(map[string]Site, len(c.Sites))
+	for name, site := range c.Sites {
+		if site.AdminSecret, err = readSecret("adminSecret", site.AdminSecret, site.AdminSecretFile, site.AdminSecretCommand); err != nil {
+			return fmt.Errorf("site %s: %w", name, err)
+		}
+		sites[name] = site
+	}
+	if c.Sites != nil {
+		c.Sites = sites
+	}
+	return nil
+}
+
+// readSecret returns the secret from file or command if one is set, or value
+// otherwise. Trailing newlines are removed.
+func readSecret(name, value, file, command string) (string, error) {
+	var secret, source string
+	switch {
+	case file != "":
+		source = name + "File"
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %sFile: %w", name, err)
+		}
+		secret = string(data)
+	case command != "":
+		source = name + "Command"
+		ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+		defer cancel()
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("%sCommand failed: %w (stderr: %s)", name, err, strings.TrimSpace(stderr.String()))
+		}
+		secret = string(out)
+	default:
+		return value, nil
+	}
+
+	secret = strings.TrimRight(secret, "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("%s returned an empty secret", source)
+	}
+	return secret, nil
+}
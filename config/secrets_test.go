@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSecretFile writes content to a file in a temporary directory with the
+// given permissions, regardless of the umask.
+func writeSecretFile(t *testing.T, content string, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(content), perm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateSecretSource(t *testing.T) {
+	private := writeSecretFile(t, "s3cr3t\n", 0o600)
+	groupReadable := writeSecretFile(t, "s3cr3t\n", 0o640)
+	worldReadable := writeSecretFile(t, "s3cr3t\n", 0o604)
+
+	tests := []struct {
+		name    string
+		file    string
+		command string
+		wantErr string
+	}{
+		{"neither", "", "", ""},
+		{"private file", private, "", ""},
+		{"command", "", "echo s3cr3t", ""},
+		{"group-readable file", groupReadable, "", "readable by group or others"},
+		{"world-readable file", worldReadable, "", "readable by group or others"},
+		{"file and command", private, "echo s3cr3t", "cannot be used together"},
+		{"missing file", filepath.Join(t.TempDir(), "missing"), "", "invalid adminSecretFile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSecretSource("adminSecret", tt.file, tt.command)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateSecretSource() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateSecretSource() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		file    string
+		command string
+		want    string
+		wantErr string
+	}{
+		{"plain value", "inline", "", "", "inline", ""},
+		{"file wins over value", "inline", writeSecretFile(t, "from-file\n", 0o600), "", "from-file", ""},
+		{"crlf trimmed", "", writeSecretFile(t, "from-file\r\n\n", 0o600), "", "from-file", ""},
+		{"inner newline kept", "", writeSecretFile(t, "line1\nline2\n", 0o600), "", "line1\nline2", ""},
+		{"command output", "", "", "printf 'from-command\\n'", "from-command", ""},
+		{"empty file", "", writeSecretFile(t, "\n", 0o600), "", "", "adminSecretFile returned an empty secret"},
+		{"failing command", "", "", "echo oops >&2; exit 3", "", "adminSecretCommand failed"},
+		{"missing file", "", filepath.Join(t.TempDir(), "missing"), "", "", "failed to read adminSecretFile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readSecret("adminSecret", tt.value, tt.file, tt.command)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("readSecret() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("readSecret() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultSiteName names the gateway described by the top-level configuration.
+const DefaultSiteName = "default"
+
+// Site holds the settings of one named gateway. Empty fields inherit the
+// top-level value, except that a site with its own endpointURL does not
+// inherit the top-level publicEndpointURL and dataDir defaults to
+// <dataDir>/sites/<name>.
+type Site struct {
+	AdminAccess           string `json:"adminAccess" yaml:"adminAccess"`
+	AdminSecret           string `json:"adminSecret" yaml:"adminSecret"`
+	AdminSecretFile       string `json:"adminSecretFile" yaml:"adminSecretFile"`
+	AdminSecretCommand    string `json:"adminSecretCommand" yaml:"adminSecretCommand"`
+	EndpointURL           string `json:"endpointURL" yaml:"endpointURL"`
+	PublicEndpointURL     string `json:"publicEndpointURL" yaml:"publicEndpointURL"`
+	Region                string `json:"region" yaml:"region"`
+	UsersJSONPath         string `json:"usersJSONPath" yaml:"usersJSONPath"`
+	ZFSPoolBase           string `json:"zfsPoolBase" yaml:"zfsPoolBase"`
+	MountBase             string `json:"mountBase" yaml:"mountBase"`
+	DataDir               string `json:"dataDir" yaml:"dataDir"`
+	TLSCAFile             string `json:"tlsCAFile" yaml:"tlsCAFile"`
+	TLSCertFile           string `json:"tlsCertFile" yaml:"tlsCertFile"`
+	TLSKeyFile            string `json:"tlsKeyFile" yaml:"tlsKeyFile"`
+	TLSInsecureSkipVerify bool   `json:"tlsInsecureSkipVerify" yaml:"tlsInsecureSkipVerify"`
+}
+
+// ForSite returns the effective configuration of the named site. An empty
+// name or DefaultSiteName selects the top-level configuration. The result has
+// no Sites of its own and PublicEndpointURL defaults to EndpointURL.
+func (c Config) ForSite(name string) (*Config, error) {
+	cfg := c
+	cfg.Sites = nil
+	cfg.DefaultSite = ""
+	cfg.Site = DefaultSiteName
+	if name != "" && name != DefaultSiteName {
+		site, ok := c.Sites[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown site %q (available: %s)", name, strings.Join(c.SiteNames(), ", "))
+		}
+		cfg.Site = name
+		cfg.applySite(site, filepath.Join(c.DataDir, "sites", name))
+	}
+	if cfg.PublicEndpointURL == "" {
+		cfg.PublicEndpointURL = cfg.EndpointURL
+	}
+	return &cfg, nil
+}
+
+// applySite overrides the configuration with the non-empty fields of a site.
+func (c *Config) applySite(site Site, dataDir string) {
+	if site.EndpointURL != "" {
+		c.EndpointURL = site.EndpointURL
+		c.PublicEndpointURL = ""
+	}
+	c.DataDir = dataDir
+	if site.AdminSecret != "" || site.AdminSecretFile != "" || site.AdminSecretCommand != "" {
+		// The site's own secret source replaces the inherited one.
+		c.AdminSecretFile, c.AdminSecretCommand = "", ""
+	}
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&c.AdminAccess, site.AdminAccess},
+		{&c.AdminSecret, site.AdminSecret},
+		{&c.AdminSecretFile, site.AdminSecretFile},
+		{&c.AdminSecretCommand, site.AdminSecretCommand},
+		{&c.PublicEndpointURL, site.PublicEndpointURL},
+		{&c.Region, site.Region},
+		{&c.UsersJSONPath, site.UsersJSONPath},
+		{&c.ZFSPoolBase, site.ZFSPoolBase},
+		{&c.MountBase, site.MountBase},
+		{&c.DataDir, site.DataDir},
+		{&c.TLSCAFile, site.TLSCAFile},
+		{&c.TLSCertFile, site.TLSCertFile},
+		{&c.TLSKeyFile, site.TLSKeyFile},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	if site.TLSInsecureSkipVerify {
+		c.TLSInsecureSkipVerify = true
+	}
+}
+
+// SiteNames returns DefaultSiteName followed by the configured sites in
+// alphabetical order.
+func (c Config) SiteNames() []string {
+	names := make([]string, 0, len(c.Sites))
+	for name := range c.Sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{DefaultSiteName}, names...)
+}
+
+// validateSites checks the effective configuration of every named site.
+func (c Config) validateSites() error {
+	if c.DefaultSite != "" && c.DefaultSite != DefaultSiteName {
+		if _, ok := c.Sites[c.DefaultSite]; !ok {
+			return fmt.Errorf("defaultSite %q is not a configured site", c.DefaultSite)
+		}
+	}
+	for _, name := range c.SiteNames()[1:] {
+		if name == DefaultSiteName || name == "" || strings.ContainsAny(name, "/ ") {
+			return fmt.Errorf("invalid site name %q", name)
+		}
+		cfg, err := c.ForSite(name)
+		if err != nil {
+			return err
+		}
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("site %s: %w", name, err)
+		}
+	}
+	return nil
+}
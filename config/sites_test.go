@@ -0,0 +1,74 @@
+package config
+
+import "testing"
+
+func TestForSite(t *testing.T) {
+	root := Config{
+		AdminAccess:        "admin",
+		AdminSecretCommand: "pass show vgw/admin",
+		EndpointURL:        "http://127.0.0.1:7070",
+		PublicEndpointURL:  "https://s3.example.com",
+		Region:             "us-east-1",
+		DataDir:            "/var/lib/vgw-manager",
+		TLSCAFile:          "/etc/vgw/ca.pem",
+		Sites: map[string]Site{
+			"inherit":  {Region: "eu-west-1"},
+			"eu":       {EndpointURL: "https://eu.internal:7070", AdminSecret: "eu-secret"},
+			"eu-pub":   {EndpointURL: "https://eu.internal:7070", PublicEndpointURL: "https://eu.example.com"},
+			"own-data": {DataDir: "/srv/vgw-eu"},
+			"tls":      {TLSCAFile: "/etc/vgw/eu-ca.pem", TLSInsecureSkipVerify: true},
+		},
+	}
+
+	tests := []struct {
+		site          string
+		wantSite      string
+		endpoint      string
+		public        string
+		region        string
+		dataDir       string
+		secretCommand string
+		tlsCA         string
+		insecure      bool
+	}{
+		{"", DefaultSiteName, "http://127.0.0.1:7070", "https://s3.example.com", "us-east-1", "/var/lib/vgw-manager", "pass show vgw/admin", "/etc/vgw/ca.pem", false},
+		{DefaultSiteName, DefaultSiteName, "http://127.0.0.1:7070", "https://s3.example.com", "us-east-1", "/var/lib/vgw-manager", "pass show vgw/admin", "/etc/vgw/ca.pem", false},
+		{"inherit", "inherit", "http://127.0.0.1:7070", "https://s3.example.com", "eu-west-1", "/var/lib/vgw-manager/sites/inherit", "pass show vgw/admin", "/etc/vgw/ca.pem", false},
+		// Own endpoint: the top-level public endpoint is not inherited, and
+		// the own secret replaces the inherited secret command.
+		{"eu", "eu", "https://eu.internal:7070", "https://eu.internal:7070", "us-east-1", "/var/lib/vgw-manager/sites/eu", "", "/etc/vgw/ca.pem", false},
+		{"eu-pub", "eu-pub", "https://eu.internal:7070", "https://eu.example.com", "us-east-1", "/var/lib/vgw-manager/sites/eu-pub", "pass show vgw/admin", "/etc/vgw/ca.pem", false},
+		{"own-data", "own-data", "http://127.0.0.1:7070", "https://s3.example.com", "us-east-1", "/srv/vgw-eu", "pass show vgw/admin", "/etc/vgw/ca.pem", false},
+		{"tls", "tls", "http://127.0.0.1:7070", "https://s3.example.com", "us-east-1", "/var/lib/vgw-manager/sites/tls", "pass show vgw/admin", "/etc/vgw/eu-ca.pem", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantSite+"/"+tt.site, func(t *testing.T) {
+			cfg, err := root.ForSite(tt.site)
+			if err != nil {
+				t.Fatalf("ForSite(%q) error = %v", tt.site, err)
+			}
+			got := []string{cfg.Site, cfg.EndpointURL, cfg.PublicEndpointURL, cfg.Region, cfg.DataDir, cfg.AdminSecretCommand, cfg.TLSCAFile}
+			want := []string{tt.wantSite, tt.endpoint, tt.public, tt.region, tt.dataDir, tt.secretCommand, tt.tlsCA}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Errorf("ForSite(%q) = site, endpoint, public, region, dataDir, secretCommand, tlsCA %q, want %q", tt.site, got, want)
+					break
+				}
+			}
+			if cfg.TLSInsecureSkipVerify != tt.insecure {
+				t.Errorf("TLSInsecureSkipVerify = %v, want %v", cfg.TLSInsecureSkipVerify, tt.insecure)
+			}
+			if cfg.Sites != nil || cfg.AdminAccess != "admin" {
+				t.Errorf("ForSite(%q) kept Sites or lost adminAccess: %+v", tt.site, cfg)
+			}
+		})
+	}
+
+	if _, err := root.ForSite("missing"); err == nil {
+		t.Error("ForSite(missing) succeeded")
+	}
+	noPublic := Config{EndpointURL: "http://127.0.0.1:7070"}
+	if cfg, _ := noPublic.ForSite(""); cfg.PublicEndpointURL != "http://127.0.0.1:7070" {
+		t.Errorf("PublicEndpointURL = %q, want the endpoint", cfg.PublicEndpointURL)
+	}
+}
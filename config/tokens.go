@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+)
+
+// Scopes an API token can be granted.
+const (
+	// ScopeBucketsRead allows listing buckets and reading their settings.
+	ScopeBucketsRead = "buckets:read"
+	// ScopeBucketsWrite allows creating, changing and deleting buckets and
+	// implies ScopeBucketsRead.
+	ScopeBucketsWrite = "buckets:write"
+	// ScopeUsersRead allows listing users, groups and service accounts.
+	ScopeUsersRead = "users:read"
+	// ScopeUsersManage allows creating, changing and deleting users, groups
+	// and service accounts and implies ScopeUsersRead.
+	ScopeUsersManage = "users:manage"
+	// ScopeSecretsRead allows revealing user secrets (?showSecrets=true and
+	// client configs).
+	ScopeSecretsRead = "secrets:read"
+	// ScopeProvision allows POST /v1/provision.
+	ScopeProvision = "provision"
+	// ScopeMetrics allows scraping GET /metrics.
+	ScopeMetrics = "metrics"
+)
+
+// AllScopes lists every scope; the legacy apiToken is granted all of them.
+var AllScopes = []string{ScopeBucketsRead, ScopeBucketsWrite, ScopeUsersRead, ScopeUsersManage, ScopeSecretsRead, ScopeProvision, ScopeMetrics}
+
+// DefaultTokenName is the name the API logs for the legacy apiToken.
+const DefaultTokenName = "default"
+
+// Permissions limit what an API client may do. Buckets and Users are glob
+// patterns (as in path.Match, e.g. "billing-*"); an empty list matches every
+// name.
+type Permissions struct {
+	Scopes  []string `json:"scopes" yaml:"scopes"`
+	Buckets []string `json:"buckets" yaml:"buckets"`
+	Users   []string `json:"users" yaml:"users"`
+}
+
+// APIToken is a named API token with limited permissions. Like apiToken, the
+// token can be read from TokenFile or the output of TokenCommand instead.
+type APIToken struct {
+	Name         string `json:"name" yaml:"name"`
+	Token        string `json:"token" yaml:"token"`
+	TokenFile    string `json:"tokenFile" yaml:"tokenFile"`
+	TokenCommand string `json:"tokenCommand" yaml:"tokenCommand"`
+	Permissions  `yaml:",inline"`
+}
+
+// Allows reports whether scope is granted, directly or through the write
+// scope that implies it.
+func (p Permissions) Allows(scope string) bool {
+	if slices.Contains(p.Scopes, scope) {
+		return true
+	}
+	switch scope {
+	case ScopeBucketsRead:
+		return slices.Contains(p.Scopes, ScopeBucketsWrite)
+	case ScopeUsersRead:
+		return slices.Contains(p.Scopes, ScopeUsersManage)
+	}
+	return false
+}
+
+// AllowsBucket reports whether the bucket name matches the bucket patterns.
+func (p Permissions) AllowsBucket(name string) bool {
+	return matchAny(p.Buckets, name)
+}
+
+// AllowsUser reports whether the access key matches the user patterns.
+func (p Permissions) AllowsUser(access string) bool {
+	return matchAny(p.Users, access)
+}
+
+func matchAny(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the scopes and name patterns.
+func (p Permissions) Validate() error {
+	for _, scope := range p.Scopes {
+		if !slices.Contains(AllScopes, scope) {
+			return fmt.Errorf("unknown scope %q (valid: %v)", scope, AllScopes)
+		}
+	}
+	for _, pattern := range append(slices.Clone(p.Buckets), p.Users...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Tokens returns the API tokens the server accepts: the legacy apiToken (if
+// set) with every scope under DefaultTokenName, followed by apiTokens.
+func (c *Config) Tokens() []APIToken {
+	tokens := []APIToken{}
+	if c.APIToken != "" {
+		tokens = append(tokens, APIToken{
+			Name:        DefaultTokenName,
+			Token:       c.APIToken,
+			Permissions: Permissions{Scopes: AllScopes},
+		})
+	}
+	return append(tokens, c.APITokens...)
+}
+
+// validateTokens checks the names, permissions and secret sources of apiTokens.
+func (c *Config) validateTokens() error {
+	names := map[string]bool{DefaultTokenName: true}
+	for _, token := range c.APITokens {
+		if token.Name == "" {
+			return fmt.Errorf("apiTokens: every token needs a name")
+		}
+		if names[token.Name] {
+			return fmt.Errorf("apiTokens: duplicate or reserved name %q", token.Name)
+		}
+		names[token.Name] = true
+
+		if token.Token == "" && token.TokenFile == "" && token.TokenCommand == "" {
+			return fmt.Errorf("apiTokens %s: token, tokenFile or tokenCommand is required", token.Name)
+		}
+		if err := validateSecretSource("token", token.TokenFile, token.TokenCommand); err != nil {
+			return fmt.Errorf("apiTokens %s: %w", token.Name, err)
+		}
+		if len(token.Scopes) == 0 {
+			return fmt.Errorf("apiTokens %s: at least one scope is required", token.Name)
+		}
+		if err := token.Permissions.Validate(); err != nil {
+			return fmt.Errorf("apiTokens %s: %w", token.Name, err)
+		}
+	}
+	return nil
+}
+
+// resolveTokens reads the apiTokens from their files or commands and rejects
+// tokens that are used twice.
+func (c *Config) resolveTokens() error {
+	tokens := make([]APIToken, len(c.APITokens))
+	for i, token := range c.APITokens {
+		var err error
+		if token.Token, err = readSecret("token", token.Token, token.TokenFile, token.TokenCommand); err != nil {
+			return fmt.Errorf("apiTokens %s: %w", token.Name, err)
+		}
+		tokens[i] = token
+	}
+	if c.APITokens != nil {
+		c.APITokens = tokens
+	}
+
+	seen := map[string]string{}
+	for _, token := range c.Tokens() {
+		if other, ok := seen[token.Token]; ok {
+			return fmt.Errorf("apiTokens %s: same token as %s", token.Name, other)
+		}
+		seen[token.Token] = token.Name
+	}
+	return nil
+}
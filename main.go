@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
@@ -39,20 +41,69 @@ func main() {
 		return
 	}
 
+	// "doctor" is accepted as a subcommand; the flags after it still apply.
+	runDoctor := false
+	if len(os.Args) > 1 && strings.EqualFold(os.Args[1], "doctor") {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		runDoctor = true
+	}
+
 	exe := filepath.Base(os.Args[0])
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n\n", exe)
 		fmt.Fprintln(flag.CommandLine.Output(), "Operations:")
 		fmt.Fprintln(flag.CommandLine.Output(), "  --list-users          List all users and exit")
-		fmt.Fprintln(flag.CommandLine.Output(), "  --list-buckets        List all buckets and exit")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --list-buckets        List all buckets and exit (filter with --tag key=value)")
 		fmt.Fprintln(flag.CommandLine.Output(), "  update               Update the binary to the latest release and exit")
 		fmt.Fprintln(flag.CommandLine.Output(), "  --update              Update the binary to the latest release and exit")
 		fmt.Fprintln(flag.CommandLine.Output(), "  --version             Print version and exit")
+		fmt.Fprintln(flag.CommandLine.Output(), "  doctor               Check config, zfs, users.json, gateway credentials, API token and audit log (optional --json)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --doctor              Same as doctor")
 		fmt.Fprintln(flag.CommandLine.Output(), "  --provision           Create user + bucket + set owner without launching the TUI")
-		fmt.Fprintln(flag.CommandLine.Output(), "                         (use with --access, --role, --bucket, --quota, optional --secret/--owner/--uid/--gid/--project-id)")
+		fmt.Fprintln(flag.CommandLine.Output(), "                         (use with --access, --role, --bucket, --quota, optional --secret/--owner/--uid/--gid/--project-id/--tags)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --set-tags            Replace bucket tags (use with --bucket, --tags; empty --tags clears them)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --lifecycle           Show bucket lifecycle rules (use with --bucket)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --put-lifecycle-rule  Add or replace a lifecycle rule (use with --bucket, --rule-id, optional --prefix,")
+		fmt.Fprintln(flag.CommandLine.Output(), "                         --expire-days, --abort-multipart-days)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --delete-lifecycle-rule Remove a lifecycle rule (use with --bucket, --rule-id)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --clear-lifecycle     Remove all lifecycle rules from a bucket (use with --bucket)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --cors                Show bucket CORS rules (use with --bucket)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --cors-allow-get      Allow browser GET/HEAD from origins (use with --bucket, --origin)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --clear-cors          Remove all CORS rules from a bucket (use with --bucket)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --report              Object count / prefix / age usage report (use with --bucket, optional --prefix,")
+		fmt.Fprintln(flag.CommandLine.Output(), "                         --prefix-depth, --top, --json)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --multipart-uploads   List incomplete multipart uploads (optional --bucket, default all buckets;")
+		fmt.Fprintln(flag.CommandLine.Output(), "                         --older-than-hours)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --abort-multipart     Abort incomplete multipart uploads older than --older-than-hours")
+		fmt.Fprintln(flag.CommandLine.Output(), "                         (optional --bucket, --dry-run)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --delete-bucket --force Empty a bucket (objects, versions, multipart uploads) and delete it")
+		fmt.Fprintln(flag.CommandLine.Output(), "                         (use with --bucket; asks to type the bucket name unless --confirm <bucket>)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --presign             Print a presigned object URL (use with --bucket, --key, optional --method GET|PUT,")
+		fmt.Fprintln(flag.CommandLine.Output(), "                         --expires, --as-owner)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --export-credentials  Print a ready-to-use client config for a user (use with --access,")
+		fmt.Fprintln(flag.CommandLine.Output(), "                         --format aws|rclone|s3cmd|mc|env)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --create-service-account Create an extra access key owning the same buckets as a user")
+		fmt.Fprintln(flag.CommandLine.Output(), "                         (use with --parent, optional --access, --secret, --description)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --list-service-accounts List service accounts (optional --parent)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --revoke-service-account Delete a service account (use with --access)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --rotate-service-account Generate a new secret for a service account (use with --access)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --sync-service-accounts Re-grant a user's buckets to its service accounts (use with --parent)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --list-groups         List groups with their members and bucket grants")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --create-group        Create a group (use with --group, optional --description)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --delete-group        Delete a group and its bucket policy statements (use with --group)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --add-group-member    Add a user to a group (use with --group, --access)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --remove-group-member Remove a user from a group (use with --group, --access)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --grant-group         Give a group access to a bucket (use with --group, --bucket, --permission)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --revoke-group        Remove a group's access to a bucket (use with --group, --bucket)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --sync-group          Re-apply a group's bucket policy statements (use with --group)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --user-access         Show every bucket a user can access and why (use with --access)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --audit               Show the audit log of mutating operations (optional --since, --operation,")
+		fmt.Fprintln(flag.CommandLine.Output(), "                         --target, --who, --json)")
 		fmt.Fprintln(flag.CommandLine.Output(), "  --config <path>       Path to YAML config file (default: /etc/vgw-manager.yaml)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --context <site>      Site from the config file to manage (default: defaultSite, VGW_CONTEXT)")
 		fmt.Fprintln(flag.CommandLine.Output(), "  --serve                Start HTTP API server instead of TUI")
-		fmt.Fprintln(flag.CommandLine.Output(), "  --listen <addr>        Listen address for API server (default: 127.0.0.1:8080)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --listen <addr>        Listen address for API server (default: 127.0.0.1:8080; \"none\" for apiSocket only)")
+		fmt.Fprintln(flag.CommandLine.Output(), "  --watch-config <interval> With --serve, reload the config when the file changes (SIGHUP always reloads)")
 		fmt.Fprintln(flag.CommandLine.Output(), "  (no flags)            Launch the interactive TUI")
 		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags:")
 		flag.PrintDefaults()
@@ -60,12 +111,14 @@ func main() {
 
 	// Define CLI flags
 	configPath := flag.String("config", config.DefaultConfigPath, "Path to the YAML config file")
+	siteName := flag.String("context", "", "Site (gateway) from the config file to manage (default: defaultSite or VGW_CONTEXT)")
 
 	// Operations
 	listUsers := flag.Bool("list-users", false, "List all users and exit")
 	listBuckets := flag.Bool("list-buckets", false, "List all buckets and exit")
 	selfUpdate := flag.Bool("update", false, "Update the binary to the latest release and exit")
 	showVersion := flag.Bool("version", false, "Print version and exit")
+	doctor := flag.Bool("doctor", false, "Check the configuration and environment and exit")
 	provisionAll := flag.Bool("provision", false, "Create a user, create a bucket, and set the bucket owner")
 	createUser := flag.Bool("create-user", false, "Create a new user")
 	createBucket := flag.Bool("create-bucket", false, "Create a new bucket")
@@ -74,6 +127,34 @@ func main() {
 	makePrivate := flag.Bool("make-private", false, "Make bucket private")
 	deleteUser := flag.Bool("delete-user", false, "Delete a user")
 	deleteBucket := flag.Bool("delete-bucket", false, "Delete a bucket")
+	setTags := flag.Bool("set-tags", false, "Replace bucket tags")
+	showLifecycle := flag.Bool("lifecycle", false, "Show bucket lifecycle rules")
+	putLifecycleRule := flag.Bool("put-lifecycle-rule", false, "Add or replace a bucket lifecycle rule")
+	deleteLifecycleRule := flag.Bool("delete-lifecycle-rule", false, "Remove a bucket lifecycle rule")
+	clearLifecycle := flag.Bool("clear-lifecycle", false, "Remove all bucket lifecycle rules")
+	showCors := flag.Bool("cors", false, "Show bucket CORS rules")
+	corsAllowGet := flag.Bool("cors-allow-get", false, "Allow browser GET/HEAD requests from --origin")
+	clearCors := flag.Bool("clear-cors", false, "Remove all bucket CORS rules")
+	bucketReport := flag.Bool("report", false, "Show an object count, prefix and age usage report for a bucket")
+	listMultipart := flag.Bool("multipart-uploads", false, "List incomplete multipart uploads")
+	abortMultipart := flag.Bool("abort-multipart", false, "Abort stale incomplete multipart uploads")
+	presign := flag.Bool("presign", false, "Generate a presigned URL for an object")
+	createServiceAccount := flag.Bool("create-service-account", false, "Create a service account under --parent")
+	listServiceAccounts := flag.Bool("list-service-accounts", false, "List service accounts")
+	revokeServiceAccount := flag.Bool("revoke-service-account", false, "Delete a service account")
+	rotateServiceAccount := flag.Bool("rotate-service-account", false, "Generate a new secret for a service account")
+	syncServiceAccounts := flag.Bool("sync-service-accounts", false, "Grant a user's current buckets to its service accounts")
+	listGroups := flag.Bool("list-groups", false, "List groups")
+	createGroup := flag.Bool("create-group", false, "Create a group")
+	deleteGroup := flag.Bool("delete-group", false, "Delete a group")
+	addGroupMember := flag.Bool("add-group-member", false, "Add a user to a group")
+	removeGroupMember := flag.Bool("remove-group-member", false, "Remove a user from a group")
+	grantGroup := flag.Bool("grant-group", false, "Give a group access to a bucket")
+	revokeGroup := flag.Bool("revoke-group", false, "Remove a group's access to a bucket")
+	syncGroup := flag.Bool("sync-group", false, "Re-apply a group's bucket policy statements")
+	userAccess := flag.Bool("user-access", false, "Show every bucket a user can access")
+	exportCredentials := flag.Bool("export-credentials", false, "Print a client config with a user's credentials")
+	showAudit := flag.Bool("audit", false, "Show the audit log of mutating operations")
 
 	// Arguments
 	accessKey := flag.String("access", "", "Access key (User)")
@@ -85,10 +166,38 @@ func main() {
 	bucketName := flag.String("bucket", "", "Bucket name")
 	bucketQuota := flag.String("quota", "", "Quota for the bucket (e.g., 2T, 500G)")
 	bucketOwner := flag.String("owner", "", "Bucket owner access key")
+	bucketTags := flag.String("tags", "", "Bucket tags as comma-separated key=value pairs (e.g. cost-center=42,customer=acme)")
+	tagFilter := flag.String("tag", "", "Only list buckets carrying these tags (comma-separated key=value pairs)")
+	ruleID := flag.String("rule-id", "", "Lifecycle rule ID")
+	rulePrefix := flag.String("prefix", "", "Object key prefix (Lifecycle rule)")
+	expireDays := flag.Int("expire-days", 0, "Expire objects this many days after creation (Lifecycle rule)")
+	abortMultipartDays := flag.Int("abort-multipart-days", 0, "Abort incomplete multipart uploads after this many days (Lifecycle rule)")
+	corsOrigin := flag.String("origin", "", "Allowed origins, comma-separated (CORS, e.g. https://app.example.com)")
+	prefixDepth := flag.Int("prefix-depth", services.DefaultReportPrefixDepth, "Number of key segments per reported prefix (Report)")
+	topPrefixes := flag.Int("top", services.DefaultReportTopPrefixes, "Number of largest prefixes to show (Report)")
+	olderThanHours := flag.Int("older-than-hours", 24, "Only include multipart uploads initiated more than this many hours ago")
+	dryRun := flag.Bool("dry-run", false, "Show what would be done without changing anything")
+	force := flag.Bool("force", false, "With --delete-bucket: delete all objects, versions and multipart uploads first")
+	confirmName := flag.String("confirm", "", "Bucket name, to confirm --delete-bucket --force without a prompt")
+	workers := flag.Int("workers", services.DefaultEmptyWorkers, "Parallel deletes for --delete-bucket --force")
+	objectKey := flag.String("key", "", "Object key (Presign)")
+	presignMethod := flag.String("method", "GET", "HTTP method of the presigned URL: GET or PUT (Presign)")
+	presignExpiry := flag.Duration("expires", services.DefaultPresignExpiry, "Presigned URL lifetime, e.g. 30m, 24h (max 168h) (Presign)")
+	presignAsOwner := flag.Bool("as-owner", false, "Sign with the bucket owner's credentials instead of the admin's (Presign)")
+	parentAccess := flag.String("parent", "", "Parent user access key (Service account)")
+	description := flag.String("description", "", "Free-form description, e.g. \"CI pipeline\" (Service account)")
+	groupName := flag.String("group", "", "Group name (Group)")
+	permission := flag.String("permission", services.PermissionReadWrite, "Bucket permission: read or readwrite (Group)")
+	clientFormat := flag.String("format", "", "Client config format: aws, rclone, s3cmd, mc or env (Export credentials)")
+	auditSince := flag.String("since", "", "Only events since a duration ago (24h, 7d), a date or an RFC 3339 time (Audit)")
+	auditOperation := flag.String("operation", "", "Only this operation (bucket.delete) or family of operations (bucket) (Audit)")
+	auditTarget := flag.String("target", "", "Only events on this target; wildcards allowed (Audit)")
+	auditWho := flag.String("who", "", "Only events by this API token or Unix user (Audit)")
 
 	jsonOutput := flag.Bool("json", false, "Output in JSON format")
 	serve := flag.Bool("serve", false, "Start HTTP API server instead of TUI")
-	listenAddr := flag.String("listen", "", "Listen address for API server")
+	listenAddr := flag.String("listen", "", "Listen address for API server (\"none\" to serve only on apiSocket)")
+	watchConfig := flag.Duration("watch-config", 0, "With --serve, also reload the config when the file changes, checking at this interval (e.g. 5s)")
 	flag.Parse()
 
 	if *showVersion {
@@ -110,42 +219,78 @@ func main() {
 		return
 	}
 
-	if err := config.Load(*configPath); err != nil {
+	if runDoctor || *doctor {
+		os.Exit(doctorCommand(*configPath, *siteName, *jsonOutput))
+	}
+
+	root, err := config.Load(*configPath)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
+	site := *siteName
+	if site == "" {
+		site = root.DefaultSite
+	}
+	cfg, err := root.ForSite(site)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --context: %v\n", err)
+		os.Exit(1)
+	}
+	if cfg.TLSInsecureSkipVerify {
+		fmt.Fprintln(os.Stderr, "Warning: tlsInsecureSkipVerify is set; the VersityGW endpoint certificate is not verified.")
+	}
+
+	tags, err := services.ParseTags(*bucketTags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --tags: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Initialize Services
-	vgwService := services.NewVersityGWService()
-	bucketService := services.NewBucketService()
+	vgwService := services.NewVersityGWService(cfg)
+	bucketService := services.NewBucketService(cfg)
 
 	if *serve {
-		if config.APIToken == "" {
-			fmt.Fprintln(os.Stderr, "Error: apiToken is required when serving the API (set apiToken in config or VGW_API_TOKEN env). This service runs as root and writes to ZFS.")
+		if len(cfg.Tokens()) == 0 && cfg.JWT == nil {
+			fmt.Fprintln(os.Stderr, "Error: apiToken, apiTokens or jwt is required when serving the API (set apiToken in config or VGW_API_TOKEN env). This service runs as root and writes to ZFS.")
 			os.Exit(1)
 		}
 
 		addr := *listenAddr
 		if addr == "" {
-			addr = config.APIListen
+			addr = cfg.APIListen
 		}
 		if addr == "" {
 			addr = "127.0.0.1:8080"
 		}
 
-		srv := api.NewServer(version)
-		srv.Addr = addr
+		srv, err := api.NewServer(version, root, cfg.Site)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		listeners, err := api.Listen(cfg, addr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 
-		go func() {
-			slog.Info("API server listening", "addr", addr)
-			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				slog.Error("API server failed", "error", err)
-				os.Exit(1)
-			}
-		}()
+		for _, ln := range listeners {
+			go func() {
+				slog.Info("API server listening", "network", ln.Addr().Network(), "addr", ln.Addr().String())
+				if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+					slog.Error("API server failed", "error", err)
+					os.Exit(1)
+				}
+			}()
+		}
 
 		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 		defer stop()
+		go reloadConfigOnChange(ctx, srv, *configPath, *siteName, *watchConfig)
+		go srv.CollectMetrics(ctx, cfg.MetricsInterval)
+		go srv.WatchQuotas(ctx)
 		<-ctx.Done()
 
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -160,6 +305,10 @@ func main() {
 
 	// Handle Operations
 
+	// Ctrl+C cancels in-flight VersityGW requests and zfs commands of CLI operations.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	if *createUser {
 		if *accessKey == "" || *secretKey == "" {
 			fmt.Fprintln(os.Stderr, "Error: --access and --secret are required for create-user")
@@ -173,7 +322,10 @@ func main() {
 			GroupID:   *groupID,
 			ProjectID: *projectID,
 		}
-		if err := vgwService.CreateUser(req); err != nil {
+		audit := startAudit(cfg, "user.create", *accessKey)
+		err := vgwService.CreateUser(ctx, req)
+		audit.Finish(err)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating user: %v\n", err)
 			os.Exit(1)
 		}
@@ -186,7 +338,10 @@ func main() {
 			fmt.Fprintln(os.Stderr, "Error: --access is required for delete-user")
 			os.Exit(1)
 		}
-		if err := vgwService.DeleteUser(*accessKey); err != nil {
+		audit := startAudit(cfg, "user.delete", *accessKey)
+		err := services.DeleteUser(ctx, cfg, *accessKey)
+		audit.Finish(err)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error deleting user: %v\n", err)
 			os.Exit(1)
 		}
@@ -212,21 +367,63 @@ func main() {
 		}
 
 		// Create ZFS dataset
-		if err := bucketService.CreateBucket(req); err != nil {
+		audit := startAudit(cfg, "bucket.create", *bucketName)
+		if err := bucketService.CreateBucket(ctx, req); err != nil {
+			audit.Finish(err)
 			fmt.Fprintf(os.Stderr, "Error creating ZFS bucket: %v\n", err)
 			os.Exit(1)
 		}
 
+		// Failures after the dataset exists are warnings, but they are
+		// still recorded as the outcome of the operation.
+		var warnings []error
+
+		// Set Tags if specified
+		if len(tags) > 0 {
+			if err := vgwService.PutBucketTagging(ctx, *bucketName, tags); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Bucket created but failed to set tags: %v\n", err)
+				warnings = append(warnings, fmt.Errorf("bucket created but failed to set tags: %w", err))
+			}
+		}
+
 		// Set Owner if specified
+		created := fmt.Sprintf("Bucket '%s' created.\n", *bucketName)
 		if owner != "" {
-			if err := vgwService.ChangeBucketOwner(*bucketName, owner); err != nil {
+			err := services.ChangeBucketOwner(ctx, cfg, *bucketName, owner)
+			switch {
+			case errors.Is(err, services.ErrServiceAccountGrant):
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				warnings = append(warnings, err)
+				created = fmt.Sprintf("Bucket '%s' created with owner '%s'.\n", *bucketName, owner)
+			case err != nil:
 				fmt.Fprintf(os.Stderr, "Warning: Bucket created but failed to set owner: %v\n", err)
-			} else {
-				fmt.Printf("Bucket '%s' created with owner '%s'.\n", *bucketName, owner)
-				return
+				warnings = append(warnings, fmt.Errorf("bucket created but failed to set owner: %w", err))
+			default:
+				created = fmt.Sprintf("Bucket '%s' created with owner '%s'.\n", *bucketName, owner)
 			}
 		}
-		fmt.Printf("Bucket '%s' created.\n", *bucketName)
+		audit.Finish(errors.Join(warnings...))
+		fmt.Print(created)
+		return
+	}
+
+	if *setTags {
+		if *bucketName == "" {
+			fmt.Fprintln(os.Stderr, "Error: --bucket is required for set-tags")
+			os.Exit(1)
+		}
+		audit := startAudit(cfg, "bucket.set_tags", *bucketName)
+		err := services.SetBucketTags(ctx, cfg, *bucketName, tags)
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting tags: %v\n", err)
+			os.Exit(1)
+		}
+		if len(tags) == 0 {
+			fmt.Printf("Tags removed from bucket '%s'.\n", *bucketName)
+		} else {
+			fmt.Printf("Tags of bucket '%s' set to %s.\n", *bucketName, services.FormatTags(tags))
+		}
 		return
 	}
 
@@ -236,7 +433,46 @@ func main() {
 			os.Exit(1)
 		}
 
-		via, err := services.DeleteBucketWithFallback(*bucketName)
+		if *force {
+			confirmed := *confirmName
+			if confirmed == "" {
+				fmt.Printf("This permanently deletes ALL objects, versions and uploads in '%s'.\n", *bucketName)
+				fmt.Print("Type the bucket name to confirm: ")
+				line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				confirmed = strings.TrimSpace(line)
+			}
+			if confirmed != *bucketName {
+				fmt.Fprintln(os.Stderr, "Error: confirmation does not match the bucket name; nothing deleted")
+				os.Exit(1)
+			}
+
+			audit := startAudit(cfg, "bucket.force_delete", *bucketName)
+			via, result, err := services.ForceDeleteBucket(ctx, cfg, *bucketName, services.EmptyOptions{
+				Workers: *workers,
+				Progress: func(p services.EmptyProgress) {
+					fmt.Fprintf(os.Stderr, "\r%-28s uploads aborted: %d  objects deleted: %d  failed: %d",
+						p.Phase, p.UploadsAborted, p.Deleted, p.Failed)
+				},
+			})
+			audit.Finish(err)
+			fmt.Fprintln(os.Stderr)
+			if result != nil {
+				for _, msg := range result.Errors {
+					fmt.Fprintf(os.Stderr, "  %s\n", msg)
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error force-deleting bucket: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Bucket '%s' emptied (%d objects, %d uploads) and deleted (via %s).\n",
+				*bucketName, result.Deleted, result.UploadsAborted, via)
+			return
+		}
+
+		audit := startAudit(cfg, "bucket.delete", *bucketName)
+		via, err := services.DeleteBucketWithFallback(ctx, cfg, *bucketName)
+		audit.Finish(err)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error deleting bucket: %v\n", err)
 			os.Exit(1)
@@ -250,11 +486,18 @@ func main() {
 			fmt.Fprintln(os.Stderr, "Error: --bucket and --owner are required for change-owner")
 			os.Exit(1)
 		}
-		if err := vgwService.ChangeBucketOwner(*bucketName, *bucketOwner); err != nil {
+		audit := startAudit(cfg, "bucket.change_owner", *bucketName)
+		err := services.ChangeBucketOwner(ctx, cfg, *bucketName, *bucketOwner)
+		audit.Finish(err)
+		if err != nil && !errors.Is(err, services.ErrServiceAccountGrant) {
 			fmt.Fprintf(os.Stderr, "Error changing owner: %v\n", err)
 			os.Exit(1)
 		}
 		fmt.Printf("Owner of bucket '%s' changed to '%s'.\n", *bucketName, *bucketOwner)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v (run --sync-service-accounts --parent %s to retry)\n", err, *bucketOwner)
+			os.Exit(1)
+		}
 		return
 	}
 
@@ -264,7 +507,10 @@ func main() {
 			os.Exit(1)
 		}
 
-		if err := services.MakeBucketPublic(*bucketName, *bucketOwner); err != nil {
+		audit := startAudit(cfg, "bucket.make_public", *bucketName)
+		err := services.MakeBucketPublic(ctx, cfg, *bucketName, *bucketOwner)
+		audit.Finish(err)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error making bucket public: %v\n", err)
 			os.Exit(1)
 		}
@@ -272,16 +518,600 @@ func main() {
 		return
 	}
 
+	if *showLifecycle {
+		if *bucketName == "" {
+			fmt.Fprintln(os.Stderr, "Error: --bucket is required for lifecycle")
+			os.Exit(1)
+		}
+		rules, err := vgwService.GetBucketLifecycle(ctx, *bucketName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading lifecycle: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(rules, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Printf("%-20s %-30s %-9s %-12s %-15s\n", "RULE ID", "PREFIX", "STATUS", "EXPIRE DAYS", "ABORT MPU DAYS")
+			fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────")
+			unmanaged := false
+			for _, rule := range rules {
+				id := rule.ID
+				if rule.Unmanaged {
+					id += " *"
+					unmanaged = true
+				}
+				fmt.Printf("%-20s %-30s %-9s %-12s %-15s\n", id, displayPrefix(rule.Prefix), rule.Status(),
+					displayDays(rule.ExpirationDays), displayDays(rule.AbortIncompleteMultipartDays))
+			}
+			if unmanaged {
+				fmt.Println("\n* uses settings that can only be changed with an S3 client; kept unchanged when other rules change")
+			}
+		}
+		return
+	}
+
+	if *putLifecycleRule {
+		if *bucketName == "" || *ruleID == "" {
+			fmt.Fprintln(os.Stderr, "Error: --bucket and --rule-id are required for put-lifecycle-rule")
+			os.Exit(1)
+		}
+		rule := services.LifecycleRule{
+			ID:                           *ruleID,
+			Prefix:                       *rulePrefix,
+			Enabled:                      true,
+			ExpirationDays:               *expireDays,
+			AbortIncompleteMultipartDays: *abortMultipartDays,
+		}
+		audit := startAudit(cfg, "lifecycle.put_rule", *bucketName)
+		err := services.PutLifecycleRule(ctx, cfg, *bucketName, rule)
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting lifecycle rule: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Lifecycle rule '%s' set on bucket '%s'.\n", *ruleID, *bucketName)
+		return
+	}
+
+	if *deleteLifecycleRule {
+		if *bucketName == "" || *ruleID == "" {
+			fmt.Fprintln(os.Stderr, "Error: --bucket and --rule-id are required for delete-lifecycle-rule")
+			os.Exit(1)
+		}
+		audit := startAudit(cfg, "lifecycle.delete_rule", *bucketName)
+		err := services.RemoveLifecycleRule(ctx, cfg, *bucketName, *ruleID)
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing lifecycle rule: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Lifecycle rule '%s' removed from bucket '%s'.\n", *ruleID, *bucketName)
+		return
+	}
+
+	if *clearLifecycle {
+		if *bucketName == "" {
+			fmt.Fprintln(os.Stderr, "Error: --bucket is required for clear-lifecycle")
+			os.Exit(1)
+		}
+		audit := startAudit(cfg, "lifecycle.clear", *bucketName)
+		err := vgwService.DeleteBucketLifecycle(ctx, *bucketName)
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error clearing lifecycle: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Lifecycle rules removed from bucket '%s'.\n", *bucketName)
+		return
+	}
+
+	if *showCors {
+		if *bucketName == "" {
+			fmt.Fprintln(os.Stderr, "Error: --bucket is required for cors")
+			os.Exit(1)
+		}
+		rules, err := vgwService.GetBucketCors(ctx, *bucketName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading CORS: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(rules, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Printf("%-4s %-40s %-20s %-20s %-8s\n", "#", "ORIGINS", "METHODS", "HEADERS", "MAX AGE")
+			fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────")
+			for i, rule := range rules {
+				fmt.Printf("%-4d %-40s %-20s %-20s %-8d\n", i+1, strings.Join(rule.AllowedOrigins, ","),
+					strings.Join(rule.AllowedMethods, ","), strings.Join(rule.AllowedHeaders, ","), rule.MaxAgeSeconds)
+			}
+		}
+		return
+	}
+
+	if *corsAllowGet {
+		origins := services.ParseOrigins(*corsOrigin)
+		if *bucketName == "" || len(origins) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: --bucket and --origin are required for cors-allow-get")
+			os.Exit(1)
+		}
+		audit := startAudit(cfg, "cors.allow_get", *bucketName)
+		_, err := services.AllowCorsGet(ctx, cfg, *bucketName, origins)
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error setting CORS: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Bucket '%s' now allows GET from %s.\n", *bucketName, strings.Join(origins, ", "))
+		return
+	}
+
+	if *clearCors {
+		if *bucketName == "" {
+			fmt.Fprintln(os.Stderr, "Error: --bucket is required for clear-cors")
+			os.Exit(1)
+		}
+		audit := startAudit(cfg, "cors.clear", *bucketName)
+		err := vgwService.DeleteBucketCors(ctx, *bucketName)
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error clearing CORS: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("CORS rules removed from bucket '%s'.\n", *bucketName)
+		return
+	}
+
+	if *bucketReport {
+		if *bucketName == "" {
+			fmt.Fprintln(os.Stderr, "Error: --bucket is required for report")
+			os.Exit(1)
+		}
+		report, err := services.GenerateBucketReport(ctx, cfg, *bucketName, services.ReportOptions{
+			Prefix:      *rulePrefix,
+			PrefixDepth: *prefixDepth,
+			TopPrefixes: *topPrefixes,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating report: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(data))
+			return
+		}
+
+		fmt.Printf("Bucket:  %s%s\n", report.Bucket, displayReportPrefix(report.Prefix))
+		fmt.Printf("Objects: %d\n", report.Objects)
+		fmt.Printf("Size:    %s (%d bytes)\n", services.FormatBytes(report.Bytes), report.Bytes)
+		if report.OldestObject != nil {
+			fmt.Printf("Oldest:  %s\n", report.OldestObject.Local().Format("2006-01-02 15:04:05"))
+			fmt.Printf("Newest:  %s\n", report.NewestObject.Local().Format("2006-01-02 15:04:05"))
+		}
+
+		fmt.Printf("\nLargest prefixes (%d of %d):\n", len(report.LargestPrefixes), report.TotalPrefixes)
+		fmt.Printf("%-50s %12s %12s %8s\n", "PREFIX", "OBJECTS", "SIZE", "SHARE")
+		fmt.Println("────────────────────────────────────────────────────────────────────────────────────")
+		for _, p := range report.LargestPrefixes {
+			fmt.Printf("%-50s %12d %12s %7.1f%%\n", p.Prefix, p.Objects, services.FormatBytes(p.Bytes), report.Share(p.Bytes))
+		}
+
+		fmt.Printf("\nAge (by last modified):\n")
+		fmt.Printf("%-15s %12s %12s %8s\n", "AGE", "OBJECTS", "SIZE", "SHARE")
+		fmt.Println("──────────────────────────────────────────────────")
+		for _, h := range report.AgeHistogram {
+			fmt.Printf("%-15s %12d %12s %7.1f%%\n", h.Label, h.Objects, services.FormatBytes(h.Bytes), report.Share(h.Bytes))
+		}
+		return
+	}
+
+	if (*listMultipart || *abortMultipart) && *olderThanHours < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --older-than-hours must not be negative")
+		os.Exit(1)
+	}
+
+	if *listMultipart {
+		uploads, err := services.FindStaleMultipartUploads(ctx, cfg, *bucketName, time.Duration(*olderThanHours)*time.Hour)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing multipart uploads: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(uploads, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			printMultipartUploads(uploads, nil)
+		}
+		return
+	}
+
+	if *abortMultipart {
+		audit := startAudit(cfg, "multipart.abort", *bucketName)
+		result, err := services.CleanupMultipartUploads(ctx, cfg, *bucketName, time.Duration(*olderThanHours)*time.Hour, *dryRun)
+		if err == nil && result.Failed > 0 {
+			audit.Finish(fmt.Errorf("%d of %d uploads failed to abort", result.Failed, len(result.Uploads)))
+		}
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error cleaning up multipart uploads: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			uploads := make([]services.MultipartUpload, 0, len(result.Uploads))
+			status := make([]string, 0, len(result.Uploads))
+			for _, item := range result.Uploads {
+				uploads = append(uploads, item.MultipartUpload)
+				switch {
+				case result.DryRun:
+					status = append(status, "would abort")
+				case item.Aborted:
+					status = append(status, "aborted")
+				default:
+					status = append(status, "FAILED: "+item.Error)
+				}
+			}
+			printMultipartUploads(uploads, status)
+			if result.DryRun {
+				fmt.Printf("\nDry run: %d upload(s) older than %dh would be aborted.\n", len(result.Uploads), *olderThanHours)
+			} else {
+				fmt.Printf("\nAborted %d upload(s), %d failed.\n", result.Aborted, result.Failed)
+			}
+		}
+		if result.Failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *presign {
+		if *bucketName == "" || *objectKey == "" {
+			fmt.Fprintln(os.Stderr, "Error: --bucket and --key are required for presign")
+			os.Exit(1)
+		}
+		req := services.PresignRequest{
+			Bucket: *bucketName,
+			Key:    *objectKey,
+			Method: *presignMethod,
+			Expiry: *presignExpiry,
+		}
+		if *presignAsOwner {
+			req.SignAs = services.PresignAsOwner
+		}
+		presigned, err := services.PresignObjectURL(ctx, cfg, req)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating presigned URL: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(presigned, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Println(presigned.URL)
+			fmt.Fprintf(os.Stderr, "%s URL signed by %s, expires %s\n", presigned.Method, presigned.SignedBy,
+				presigned.ExpiresAt.Local().Format("2006-01-02 15:04:05"))
+		}
+		return
+	}
+
+	if *createServiceAccount {
+		if *parentAccess == "" {
+			fmt.Fprintln(os.Stderr, "Error: --parent is required for create-service-account")
+			os.Exit(1)
+		}
+		audit := startAudit(cfg, "service_account.create", *parentAccess)
+		created, err := services.CreateServiceAccount(ctx, cfg, services.ServiceAccountRequest{
+			Parent:      *parentAccess,
+			Access:      *accessKey,
+			Secret:      *secretKey,
+			Description: *description,
+		})
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating service account: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(created, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Printf("Service account created under '%s'.\n", created.Parent)
+			fmt.Printf("Access Key: %s\nSecret Key: %s\n", created.Access, created.Secret)
+			fmt.Printf("Granted buckets: %s\n", displayList(created.Buckets))
+		}
+		return
+	}
+
+	if *listServiceAccounts {
+		accounts, err := services.ListServiceAccounts(cfg, *parentAccess)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing service accounts: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(accounts, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Printf("%-30s %-30s %-20s %s\n", "ACCESS KEY", "PARENT", "CREATED", "DESCRIPTION")
+			fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────")
+			for _, account := range accounts {
+				fmt.Printf("%-30s %-30s %-20s %s\n", account.Access, account.Parent,
+					account.CreatedAt.Local().Format("2006-01-02 15:04"), account.Description)
+			}
+		}
+		return
+	}
+
+	if *revokeServiceAccount {
+		if *accessKey == "" {
+			fmt.Fprintln(os.Stderr, "Error: --access is required for revoke-service-account")
+			os.Exit(1)
+		}
+		audit := startAudit(cfg, "service_account.revoke", *accessKey)
+		err := services.RevokeServiceAccount(ctx, cfg, *accessKey)
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error revoking service account: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Service account '%s' revoked.\n", *accessKey)
+		return
+	}
+
+	if *rotateServiceAccount {
+		if *accessKey == "" {
+			fmt.Fprintln(os.Stderr, "Error: --access is required for rotate-service-account")
+			os.Exit(1)
+		}
+		audit := startAudit(cfg, "service_account.rotate", *accessKey)
+		rotated, err := services.RotateServiceAccount(ctx, cfg, *accessKey)
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error rotating service account: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(rotated, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Printf("Access Key: %s\nSecret Key: %s\n", rotated.Access, rotated.Secret)
+		}
+		return
+	}
+
+	if *syncServiceAccounts {
+		if *parentAccess == "" {
+			fmt.Fprintln(os.Stderr, "Error: --parent is required for sync-service-accounts")
+			os.Exit(1)
+		}
+		audit := startAudit(cfg, "service_account.sync", *parentAccess)
+		buckets, err := services.SyncServiceAccountGrants(ctx, cfg, *parentAccess)
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error syncing service accounts: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Service account grants of '%s' updated on: %s\n", *parentAccess, displayList(buckets))
+		return
+	}
+
+	if *listGroups {
+		groups, err := services.ListGroups(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing groups: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(groups, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Printf("%-25s %-40s %s\n", "GROUP", "MEMBERS", "BUCKETS")
+			fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────")
+			for _, group := range groups {
+				fmt.Printf("%-25s %-40s %s\n", group.Name, displayList(group.Members), displayGrants(group.Buckets))
+			}
+		}
+		return
+	}
+
+	if *createGroup || *deleteGroup || *addGroupMember || *removeGroupMember || *grantGroup || *revokeGroup || *syncGroup {
+		if *groupName == "" {
+			fmt.Fprintln(os.Stderr, "Error: --group is required")
+			os.Exit(1)
+		}
+		if (*addGroupMember || *removeGroupMember) && *accessKey == "" {
+			fmt.Fprintln(os.Stderr, "Error: --access is required to add or remove a group member")
+			os.Exit(1)
+		}
+		if (*grantGroup || *revokeGroup) && *bucketName == "" {
+			fmt.Fprintln(os.Stderr, "Error: --bucket is required to grant or revoke group access")
+			os.Exit(1)
+		}
+
+		var operation string
+		switch {
+		case *createGroup:
+			operation = "group.create"
+		case *deleteGroup:
+			operation = "group.delete"
+		case *addGroupMember:
+			operation = "group.add_member"
+		case *removeGroupMember:
+			operation = "group.remove_member"
+		case *grantGroup:
+			operation = "group.grant_bucket"
+		case *revokeGroup:
+			operation = "group.revoke_bucket"
+		case *syncGroup:
+			operation = "group.sync"
+		}
+		audit := startAudit(cfg, operation, *groupName)
+
+		var group *models.Group
+		var err error
+		switch {
+		case *createGroup:
+			group, err = services.CreateGroup(cfg, *groupName, *description)
+		case *deleteGroup:
+			err = services.DeleteGroup(ctx, cfg, *groupName)
+		case *addGroupMember:
+			group, err = services.AddGroupMember(ctx, cfg, *groupName, *accessKey)
+		case *removeGroupMember:
+			group, err = services.RemoveGroupMember(ctx, cfg, *groupName, *accessKey)
+		case *grantGroup:
+			group, err = services.GrantGroupBucket(ctx, cfg, *groupName, *bucketName, *permission)
+		case *revokeGroup:
+			group, err = services.RevokeGroupBucket(ctx, cfg, *groupName, *bucketName)
+		case *syncGroup:
+			var buckets []string
+			if buckets, err = services.SyncGroup(ctx, cfg, *groupName); err == nil {
+				audit.Finish(nil)
+				fmt.Printf("Group '%s' statements re-applied on: %s\n", *groupName, displayList(buckets))
+				return
+			}
+		}
+		audit.Finish(err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if group == nil {
+			fmt.Printf("Group '%s' deleted.\n", *groupName)
+		} else if *jsonOutput {
+			data, _ := json.MarshalIndent(group, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Printf("Group '%s' updated.\n", group.Name)
+			fmt.Printf("Members: %s\nBuckets: %s\n", displayList(group.Members), displayGrants(group.Buckets))
+		}
+		return
+	}
+
+	if *userAccess {
+		if *accessKey == "" {
+			fmt.Fprintln(os.Stderr, "Error: --access is required for user-access")
+			os.Exit(1)
+		}
+		report, err := services.UserAccess(ctx, cfg, *accessKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			if report.ServiceAccountOf != "" {
+				fmt.Printf("Service account of: %s\n", report.ServiceAccountOf)
+			}
+			fmt.Printf("Groups: %s\n\n", displayList(report.Groups))
+			fmt.Printf("%-30s %-10s %s\n", "BUCKET", "ACCESS", "VIA")
+			fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────")
+			for _, entry := range report.Buckets {
+				fmt.Printf("%-30s %-10s %s\n", entry.Bucket, entry.Access, strings.Join(entry.Via, ", "))
+			}
+			if len(report.Buckets) == 0 {
+				fmt.Println("No accessible buckets.")
+			}
+		}
+		return
+	}
+
+	if *showAudit {
+		filter := services.AuditFilter{Operation: *auditOperation, Target: *auditTarget, Who: *auditWho}
+		if *auditSince != "" {
+			since, err := services.ParseAuditSince(*auditSince, time.Now())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			filter.Since = since
+		}
+		events, err := services.ReadAuditEvents(cfg, filter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading audit log: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			// One event per line, like the audit log itself.
+			for _, event := range events {
+				data, _ := json.Marshal(event)
+				fmt.Println(string(data))
+			}
+		} else {
+			fmt.Printf("%-20s %-4s %-10s %-20s %-24s %-30s %-8s %s\n", "TIME", "VIA", "SITE", "WHO", "OPERATION", "TARGET", "OUTCOME", "DURATION")
+			fmt.Println("──────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────")
+			for _, event := range events {
+				fmt.Printf("%-20s %-4s %-10s %-20s %-24s %-30s %-8s %s\n", event.Time.Local().Format("2006-01-02 15:04:05"),
+					event.Source, event.Site, displayAuditWho(event), event.Operation, event.Target, event.Outcome,
+					(time.Duration(event.DurationMs) * time.Millisecond).String())
+				if event.Error != "" {
+					fmt.Printf("%-20s error: %s\n", "", event.Error)
+				}
+			}
+			if len(events) == 0 {
+				fmt.Println("No audit events found.")
+			}
+		}
+		return
+	}
+
+	if *exportCredentials {
+		if *accessKey == "" || *clientFormat == "" {
+			fmt.Fprintf(os.Stderr, "Error: --access and --format (%s) are required for export-credentials\n",
+				strings.Join(services.ClientConfigFormats, ", "))
+			os.Exit(1)
+		}
+		user, err := services.NewUserService(cfg).GetUser(*accessKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		clientConfig, err := services.RenderClientConfig(cfg, *user, *clientFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if *jsonOutput {
+			data, _ := json.MarshalIndent(clientConfig, "", "  ")
+			fmt.Println(string(data))
+		} else {
+			fmt.Print(clientConfig.Content)
+		}
+		return
+	}
+
 	if *makePrivate {
 		if *bucketName == "" {
 			fmt.Fprintln(os.Stderr, "Error: --bucket is required for make-private")
 			os.Exit(1)
 		}
-		if err := vgwService.DeleteBucketPolicy(*bucketName); err != nil {
+		audit := startAudit(cfg, "bucket.make_private", *bucketName)
+		err := services.MakeBucketPrivate(ctx, cfg, *bucketName)
+		audit.Finish(err)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error removing policy: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("Bucket '%s' is now PRIVATE (policy removed).\n", *bucketName)
+		fmt.Printf("Bucket '%s' is now PRIVATE (public policy removed).\n", *bucketName)
 		return
 	}
 
@@ -296,9 +1126,12 @@ func main() {
 			Bucket:    *bucketName,
 			Quota:     *bucketQuota,
 			Owner:     *bucketOwner,
+			Tags:      tags,
 		}
 
-		summary, err := services.Provision(req)
+		audit := startAudit(cfg, "provision", *accessKey)
+		summary, err := services.Provision(ctx, cfg, req)
+		audit.Finish(err)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error provisioning user/bucket: %v\n", err)
 			os.Exit(1)
@@ -311,6 +1144,9 @@ func main() {
 			fmt.Printf("User '%s' created with role '%s'\n", summary.Access, summary.Role)
 			fmt.Printf("Secret key: %s\n", summary.Secret)
 			fmt.Printf("Bucket '%s' created with quota %s and owner '%s'\n", summary.Bucket, summary.Quota, summary.Owner)
+			if len(summary.Tags) > 0 {
+				fmt.Printf("Bucket tags: %s\n", services.FormatTags(summary.Tags))
+			}
 			if summary.SecretGenerated {
 				fmt.Println("(Secret key was auto-generated)")
 			}
@@ -320,7 +1156,7 @@ func main() {
 
 	// Handle CLI flags
 	if *listUsers {
-		userService := services.NewUserService()
+		userService := services.NewUserService(cfg)
 		users, err := userService.ListUsers()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error listing users: %v\n", err)
@@ -341,34 +1177,245 @@ func main() {
 	}
 
 	if *listBuckets {
-		buckets, err := services.ListMergedBuckets()
+		filter, err := services.ParseTags(*tagFilter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --tag: %v\n", err)
+			os.Exit(1)
+		}
+
+		buckets, err := services.ListMergedBuckets(ctx, cfg)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error listing buckets: %v\n", err)
 			os.Exit(1)
 		}
+		buckets = services.FilterBucketsByTags(buckets, filter)
 
 		if *jsonOutput {
 			data, _ := json.MarshalIndent(buckets, "", "  ")
 			fmt.Println(string(data))
 		} else {
-			fmt.Printf("%-30s %-20s %-8s %-15s %-15s %-15s\n", "NAME", "OWNER", "PUBLIC", "QUOTA", "USED", "AVAILABLE")
-			fmt.Println("────────────────────────────────────────────────────────────────────────────────────────────────────────────")
+			fmt.Printf("%-30s %-20s %-8s %-15s %-15s %-15s %-6s %s\n", "NAME", "OWNER", "PUBLIC", "QUOTA", "USED", "AVAILABLE", "ALERT", "TAGS")
+			fmt.Println("───────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────────")
 			for _, bucket := range buckets {
 				visibility := "Private"
 				if bucket.Public {
 					visibility = "Public"
+				} else if bucket.Error != "" {
+					visibility = "?"
+				}
+				fmt.Printf("%-30s %-20s %-8s %-15s %-15s %-15s %-6s %s\n",
+					bucket.Name, bucket.Owner, visibility, bucket.Quota, bucket.Used, bucket.Available, services.FormatQuotaAlert(bucket), services.FormatTags(bucket.Tags))
+			}
+			for _, bucket := range buckets {
+				if bucket.Error != "" {
+					fmt.Fprintf(os.Stderr, "Warning: bucket '%s' could not be fully read: %s\n", bucket.Name, bucket.Error)
 				}
-				fmt.Printf("%-30s %-20s %-8s %-15s %-15s %-15s\n",
-					bucket.Name, bucket.Owner, visibility, bucket.Quota, bucket.Used, bucket.Available)
 			}
 		}
 		return
 	}
 
-	// Run TUI if no flags specified
-	p := tea.NewProgram(ui.NewModel(), tea.WithAltScreen())
+	// Run TUI if no flags specified; it handles Ctrl+C itself
+	stop()
+	p := tea.NewProgram(ui.NewModel(root, cfg), tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running application: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// doctorCommand runs the doctor checks for the selected site, prints them and
+// returns the exit code: 1 if any check failed.
+func doctorCommand(configPath, site string, jsonOutput bool) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	path := config.ResolvePath(configPath)
+	checks := []services.DoctorCheck{{Name: "config", Status: services.CheckPass, Detail: path}}
+
+	root, err := config.Load(configPath)
+	var cfg *config.Config
+	if err == nil {
+		if site == "" {
+			site = root.DefaultSite
+		}
+		cfg, err = root.ForSite(site)
+	}
+	if err != nil {
+		checks[0] = services.DoctorCheck{Name: "config", Status: services.CheckFail, Detail: err.Error(),
+			Remediation: "Fix the config file or environment variables; the remaining checks need a valid configuration."}
+	} else {
+		checks = append(checks, services.RunDoctor(ctx, cfg)...)
+	}
+
+	failed := false
+	for _, check := range checks {
+		failed = failed || check.Status == services.CheckFail
+	}
+
+	if jsonOutput {
+		data, _ := json.MarshalIndent(checks, "", "  ")
+		fmt.Println(string(data))
+	} else {
+		for _, check := range checks {
+			fmt.Printf("[%s] %-14s %s\n", strings.ToUpper(check.Status), check.Name, check.Detail)
+			if check.Remediation != "" && check.Status != services.CheckPass {
+				fmt.Printf("       %-14s → %s\n", "", check.Remediation)
+			}
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// reloadConfigOnChange reloads the API server's configuration on SIGHUP and,
+// when interval is positive, whenever the config file's modification time
+// changes. It returns when ctx is done.
+func reloadConfigOnChange(ctx context.Context, srv *api.Server, configPath, site string, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	path := config.ResolvePath(configPath)
+	lastMod := fileModTime(path)
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			lastMod = fileModTime(path)
+			reloadConfig(srv, configPath, site, "SIGHUP")
+		case <-tick:
+			if mod := fileModTime(path); !mod.Equal(lastMod) {
+				lastMod = mod
+				reloadConfig(srv, configPath, site, "file change")
+			}
+		}
+	}
+}
+
+// reloadConfig loads and validates the configuration again and swaps it into
+// the API server. On failure the previous configuration stays active.
+func reloadConfig(srv *api.Server, configPath, site, trigger string) {
+	root, err := config.Load(configPath)
+	if err == nil {
+		if site == "" {
+			site = root.DefaultSite
+		}
+		err = srv.Reload(root, site)
+	}
+	if err != nil {
+		slog.Error("config reload failed, keeping previous configuration", "trigger", trigger, "error", err)
+		return
+	}
+	if site == "" {
+		site = config.DefaultSiteName
+	}
+	slog.Info("config reloaded", "trigger", trigger, "path", config.ResolvePath(configPath), "site", site, "sites", len(root.SiteNames()))
+}
+
+// fileModTime returns the modification time of path, or the zero time if it
+// cannot be read.
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// displayPrefix renders an empty prefix as "(all objects)".
+func displayPrefix(prefix string) string {
+	if prefix == "" {
+		return "(all objects)"
+	}
+	return prefix
+}
+
+// displayDays renders a day count, or "-" when unset.
+func displayDays(days int) string {
+	if days <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", days)
+}
+
+// displayReportPrefix renders the prefix a report was limited to, if any
+func displayReportPrefix(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	return " (prefix " + prefix + ")"
+}
+
+// printMultipartUploads prints uploads as a table, with an optional per-upload status column
+func printMultipartUploads(uploads []services.MultipartUpload, status []string) {
+	fmt.Printf("%-25s %-45s %-20s %-8s %s\n", "BUCKET", "KEY", "INITIATED", "AGE", "STATUS")
+	fmt.Println("─────────────────────────────────────────────────────────────────────────────────────────────────────────────")
+	for i, upload := range uploads {
+		st := ""
+		if status != nil {
+			st = status[i]
+		}
+		age := time.Since(upload.Initiated).Truncate(time.Hour)
+		fmt.Printf("%-25s %-45s %-20s %-8s %s\n", upload.Bucket, upload.Key,
+			upload.Initiated.Local().Format("2006-01-02 15:04:05"), fmt.Sprintf("%dh", int(age.Hours())), st)
+	}
+	if len(uploads) == 0 {
+		fmt.Println("No incomplete multipart uploads found.")
+	}
+}
+
+// displayAuditWho renders who performed an audited operation: the API token,
+// or the Unix user and, under sudo, the invoking user.
+func displayAuditWho(event models.AuditEvent) string {
+	switch {
+	case event.Token != "":
+		return event.Token
+	case event.SudoUser != "":
+		return event.SudoUser + " (sudo)"
+	default:
+		return event.UnixUser
+	}
+}
+
+// displayList joins names for display, or "(none)" if there are none
+func displayList(names []string) string {
+	if len(names) == 0 {
+		return "(none)"
+	}
+	return strings.Join(names, ", ")
+}
+
+// displayGrants renders group bucket grants as "bucket (permission)"
+func displayGrants(grants []models.GroupGrant) string {
+	names := make([]string, len(grants))
+	for i, grant := range grants {
+		names[i] = fmt.Sprintf("%s (%s)", grant.Bucket, grant.Permission)
+	}
+	return displayList(names)
+}
+
+// startAudit begins the audit record of a mutating CLI operation. The flags
+// given on the command line are its parameters.
+func startAudit(cfg *config.Config, operation, target string) *services.Audit {
+	params := map[string]any{}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "config", "context", "json", "confirm":
+			return
+		}
+		params[f.Name] = f.Value.String()
+	})
+	return services.StartAudit(cfg, services.LocalActor("cli"), operation, target, params)
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // User represents a VersityGW user account
 type User struct {
 	Access    string `json:"access"`
@@ -17,13 +19,20 @@ type UsersJSON struct {
 
 // Bucket represents a ZFS bucket with quota and owner information
 type Bucket struct {
-	Name       string `json:"name"`
-	Mountpoint string `json:"mountpoint"`
-	Quota      string `json:"quota"`
-	Used       string `json:"used"`
-	Available  string `json:"available"`
-	Owner      string `json:"owner"`
-	Public     bool   `json:"public"`
+	Name       string            `json:"name"`
+	Mountpoint string            `json:"mountpoint"`
+	Quota      string            `json:"quota"`
+	Used       string            `json:"used"`
+	Available  string            `json:"available"`
+	Owner      string            `json:"owner"`
+	Public     bool              `json:"public"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	// QuotaAlert is the highest quota alert threshold (percent of the quota)
+	// the bucket's usage has crossed; 0 when no alert is active.
+	QuotaAlert int `json:"quotaAlert,omitempty"`
+	// Error reports why owner, visibility or tags could not be read; the
+	// other fields may then be incomplete.
+	Error string `json:"error,omitempty"`
 }
 
 // BucketCreateRequest represents the data needed to create a new bucket
@@ -53,3 +62,64 @@ type UserUpdateRequest struct {
 	GroupID   int
 	ProjectID int
 }
+
+// ServiceAccount is a child access key created under a parent user. It is a
+// gateway user of its own, granted access to the parent's buckets.
+type ServiceAccount struct {
+	Access      string    `json:"access"`
+	Parent      string    `json:"parent"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Group is a named set of users sharing access to buckets.
+type Group struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description,omitempty"`
+	Members     []string     `json:"members"`
+	Buckets     []GroupGrant `json:"buckets"`
+}
+
+// GroupGrant gives the members of a group access to one bucket.
+type GroupGrant struct {
+	Bucket     string `json:"bucket"`
+	Permission string `json:"permission"` // "read" or "readwrite"
+}
+
+// AuditEvent records one mutating operation: who ran it, on what, with which
+// parameters (secrets redacted) and how it ended.
+type AuditEvent struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"` // "cli", "tui" or "api"
+	Site   string    `json:"site"`
+	// Token is the name of the API token or JWT subject of an API request.
+	Token      string `json:"token,omitempty"`
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+	// UnixUser runs the CLI or TUI; SudoUser is the user who invoked sudo.
+	UnixUser   string         `json:"unixUser,omitempty"`
+	SudoUser   string         `json:"sudoUser,omitempty"`
+	Operation  string         `json:"operation"` // e.g. "bucket.delete"
+	Target     string         `json:"target"`
+	Params     map[string]any `json:"params,omitempty"`
+	Outcome    string         `json:"outcome"` // "success" or "failure"
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"durationMs"`
+}
+
+// QuotaAlert is a notification that a bucket's usage rose to a threshold
+// (a percentage of its quota) or fell back below all thresholds (Resolved).
+type QuotaAlert struct {
+	Time   time.Time `json:"time"`
+	Site   string    `json:"site"`
+	Bucket string    `json:"bucket"`
+	Owner  string    `json:"owner,omitempty"`
+	// Threshold is the threshold now in effect, 0 when resolved; Previous
+	// is the one in effect before.
+	Threshold    int     `json:"threshold"`
+	Previous     int     `json:"previous"`
+	Resolved     bool    `json:"resolved"`
+	UsagePercent float64 `json:"usagePercent"`
+	Used         string  `json:"used"`
+	Quota        string  `json:"quota"`
+	Message      string  `json:"message"`
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// Access levels reported by UserAccess.
+const (
+	AccessOwner     = "owner"
+	AccessReadWrite = PermissionReadWrite
+	AccessRead      = PermissionRead
+)
+
+// BucketAccess is one bucket a user can access, and why.
+type BucketAccess struct {
+	Bucket string `json:"bucket"`
+	Access string `json:"access"` // AccessOwner, AccessReadWrite or AccessRead
+	// Via lists the sources of the access: "owner", "group <name>",
+	// "service account of <parent>", "public" or "bucket policy <sid>".
+	Via []string `json:"via"`
+}
+
+// UserAccessReport answers "what can user X access".
+type UserAccessReport struct {
+	Access           string         `json:"access"`
+	ServiceAccountOf string         `json:"serviceAccountOf,omitempty"`
+	Groups           []string       `json:"groups"`
+	Buckets          []BucketAccess `json:"buckets"`
+}
+
+// UserAccess reports every bucket a user can access, derived from the bucket
+// owners and the bucket policies actually set on the gateway (so hand-written
+// policies are included as well as grants made by vgw-manager).
+func UserAccess(ctx context.Context, cfg *config.Config, access string) (*UserAccessReport, error) {
+	if _, err := NewUserService(cfg).GetUser(access); err != nil {
+		return nil, err
+	}
+
+	report := &UserAccessReport{Access: access, Buckets: []BucketAccess{}}
+	if account, err := GetServiceAccount(cfg, access); err == nil {
+		report.ServiceAccountOf = account.Parent
+	}
+	groups, err := GroupsOf(cfg, access)
+	if err != nil {
+		return nil, err
+	}
+	report.Groups = groups
+
+	vgwService := NewVersityGWService(cfg)
+	infos, err := vgwService.ListBuckets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+
+	for _, info := range infos {
+		owner := info.Owner
+		if trueOwner, err := vgwService.GetBucketOwner(ctx, info.Name); err == nil && trueOwner != "" {
+			owner = trueOwner
+		}
+		policy, err := vgwService.currentBucketPolicy(ctx, info.Name)
+		if err != nil {
+			return nil, fmt.Errorf("bucket %s: %w", info.Name, err)
+		}
+		grants, err := policyGrantsFor(policy, access)
+		if err != nil {
+			return nil, fmt.Errorf("bucket %s: %w", info.Name, err)
+		}
+
+		entry := BucketAccess{Bucket: info.Name, Via: []string{}}
+		if owner == access {
+			entry.Access = AccessOwner
+			entry.Via = append(entry.Via, AccessOwner)
+		}
+		for _, grant := range grants {
+			if entry.Access == "" || (entry.Access == AccessRead && grant.write) {
+				entry.Access = AccessRead
+				if grant.write {
+					entry.Access = AccessReadWrite
+				}
+			}
+			entry.Via = append(entry.Via, grantSource(grant, owner))
+		}
+		if entry.Access != "" {
+			report.Buckets = append(report.Buckets, entry)
+		}
+	}
+
+	return report, nil
+}
+
+// policyGrant is an Allow statement of a bucket policy that applies to a user.
+type policyGrant struct {
+	sid    string
+	public bool
+	write  bool
+}
+
+// policyGrantsFor returns the Allow statements of policy whose principal is
+// access or everyone.
+func policyGrantsFor(policy, access string) ([]policyGrant, error) {
+	if strings.TrimSpace(policy) == "" {
+		return nil, nil
+	}
+	var document struct {
+		Statement []struct {
+			Sid       string          `json:"Sid"`
+			Effect    string          `json:"Effect"`
+			Principal json.RawMessage `json:"Principal"`
+			Action    json.RawMessage `json:"Action"`
+		} `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(policy), &document); err != nil {
+		return nil, fmt.Errorf("parsing bucket policy: %w", err)
+	}
+
+	grants := []policyGrant{}
+	for _, statement := range document.Statement {
+		if statement.Effect != "Allow" {
+			continue
+		}
+		grant := policyGrant{sid: statement.Sid}
+		matched := false
+		for _, principal := range principalList(statement.Principal) {
+			if principal == "*" {
+				grant.public, matched = true, true
+			} else if principal == access {
+				matched = true
+			}
+		}
+		if !matched {
+			continue
+		}
+		for _, action := range stringOrList(statement.Action) {
+			if action == "*" || action == "s3:*" || strings.HasPrefix(action, "s3:Put") || strings.HasPrefix(action, "s3:Delete") {
+				grant.write = true
+			}
+		}
+		grants = append(grants, grant)
+	}
+	return grants, nil
+}
+
+// principalList flattens the Principal forms "*", ["a", "b"] and {"AWS": "a" | ["a", "b"]}.
+func principalList(raw json.RawMessage) []string {
+	if values := stringOrList(raw); values != nil {
+		return values
+	}
+	var principals map[string]json.RawMessage
+	if json.Unmarshal(raw, &principals) != nil {
+		return nil
+	}
+	values := []string{}
+	for _, value := range principals {
+		values = append(values, stringOrList(value)...)
+	}
+	return values
+}
+
+// stringOrList decodes a JSON string or array of strings.
+func stringOrList(raw json.RawMessage) []string {
+	var value string
+	if json.Unmarshal(raw, &value) == nil {
+		return []string{value}
+	}
+	var values []string
+	if json.Unmarshal(raw, &values) == nil {
+		return values
+	}
+	return nil
+}
+
+// grantSource describes where a policy grant comes from.
+func grantSource(grant policyGrant, owner string) string {
+	switch {
+	case grant.public:
+		return "public"
+	case grant.sid == serviceAccountsSid:
+		return "service account of " + owner
+	case strings.HasPrefix(grant.sid, groupSidPrefix):
+		return "group " + strings.TrimPrefix(grant.sid, groupSidPrefix)
+	case grant.sid != "":
+		return "bucket policy " + grant.sid
+	default:
+		return "bucket policy"
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+func TestPolicyGrantsFor(t *testing.T) {
+	group := models.Group{Name: "dev", Members: []string{"carol"}, Buckets: []models.GroupGrant{{Bucket: "data", Permission: PermissionRead}}}
+	statement, _ := groupStatement(group, "data")
+	policy, err := mergePolicyStatements(GeneratePublicPolicy("data", "alice"), nil, []policyStatement{
+		statement,
+		bucketGrantStatement(serviceAccountsSid, "data", []string{"alice-ci"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		access  string
+		sources []string
+		write   bool
+	}{
+		{"carol", []string{"public", "group dev"}, false},
+		{"alice-ci", []string{"public", "service account of alice"}, true},
+		{"alice", []string{"public", "bucket policy UserWriteDelete"}, true},
+		{"mallory", []string{"public"}, false},
+	}
+	for _, tt := range tests {
+		grants, err := policyGrantsFor(policy, tt.access)
+		if err != nil {
+			t.Fatalf("policyGrantsFor(%s) error = %v", tt.access, err)
+		}
+		if len(grants) != len(tt.sources) {
+			t.Fatalf("policyGrantsFor(%s) = %+v, want %v", tt.access, grants, tt.sources)
+		}
+		write := false
+		for i, grant := range grants {
+			if got := grantSource(grant, "alice"); got != tt.sources[i] {
+				t.Errorf("policyGrantsFor(%s)[%d] source = %q, want %q", tt.access, i, got, tt.sources[i])
+			}
+			write = write || grant.write
+		}
+		if write != tt.write {
+			t.Errorf("policyGrantsFor(%s) write = %v, want %v", tt.access, write, tt.write)
+		}
+	}
+}
+
+func TestPrincipalList(t *testing.T) {
+	tests := map[string]int{
+		`"*"`:                      1,
+		`["a", "b"]`:               2,
+		`{"AWS": "a"}`:             1,
+		`{"AWS": ["a", "b", "c"]}`: 3,
+		`42`:                       0,
+	}
+	for raw, want := range tests {
+		if got := principalList([]byte(raw)); len(got) != want {
+			t.Errorf("principalList(%s) = %v, want %d entries", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+// Audit outcomes.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+const (
+	journalSocket     = "/run/systemd/journal/socket"
+	journalIdentifier = "vgw-manager"
+)
+
+// auditFileMu serializes appends of this process to the audit log file.
+var auditFileMu sync.Mutex
+
+// AuditActor identifies who performs audited operations.
+type AuditActor struct {
+	Source     string // "cli", "tui" or "api"
+	Token      string
+	RemoteAddr string
+	UnixUser   string
+	SudoUser   string
+}
+
+// LocalActor returns the actor of the CLI or TUI: the Unix user running
+// vgw-manager and, under sudo, the user who invoked it.
+func LocalActor(source string) AuditActor {
+	actor := AuditActor{Source: source, SudoUser: os.Getenv("SUDO_USER")}
+	if u, err := user.Current(); err == nil {
+		actor.UnixUser = u.Username
+	} else {
+		actor.UnixUser = strconv.Itoa(os.Getuid())
+	}
+	return actor
+}
+
+// Audit is a mutating operation in progress. A nil *Audit (audit log
+// disabled) is valid and records nothing.
+type Audit struct {
+	auditLog string
+	event    models.AuditEvent
+	start    time.Time
+	once     sync.Once
+}
+
+// StartAudit begins recording operation on target, e.g. "bucket.delete" on a
+// bucket name. Params are redacted with RedactParams. It returns nil if no
+// audit log is configured.
+func StartAudit(cfg *config.Config, actor AuditActor, operation, target string, params map[string]any) *Audit {
+	if cfg.AuditLog == "" {
+		return nil
+	}
+	start := time.Now()
+	return &Audit{
+		auditLog: cfg.AuditLog,
+		start:    start,
+		event: models.AuditEvent{
+			Time:       start.UTC(),
+			Source:     actor.Source,
+			Site:       cfg.Site,
+			Token:      actor.Token,
+			RemoteAddr: actor.RemoteAddr,
+			UnixUser:   actor.UnixUser,
+			SudoUser:   actor.SudoUser,
+			Operation:  operation,
+			Target:     target,
+			Params:     RedactParams(params),
+		},
+	}
+}
+
+// Finish records the outcome of the operation; a nil err is a success. Only
+// the first call records an event. Failing to write the audit log is logged
+// but does not fail the operation, which has already happened.
+func (a *Audit) Finish(err error) {
+	if a == nil {
+		return
+	}
+	a.once.Do(func() {
+		a.event.DurationMs = time.Since(a.start).Milliseconds()
+		a.event.Outcome = AuditSuccess
+		if err != nil {
+			a.event.Outcome = AuditFailure
+			a.event.Error = err.Error()
+		}
+		if werr := writeAuditEvent(a.auditLog, a.event); werr != nil {
+			slog.Warn("failed to write audit event", "operation", a.event.Operation, "target", a.event.Target, "error", werr)
+		}
+	})
+}
+
+// isSecretParam reports whether a parameter name denotes a credential.
+func isSecretParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"secret", "token", "password", "credential"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactParams returns a copy of params in which the values of credential
+// parameters (e.g. "secret", "apiToken"), also in nested objects, are masked.
+func RedactParams(params map[string]any) map[string]any {
+	if len(params) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(params))
+	for k, v := range params {
+		if isSecretParam(k) {
+			if v != nil && v != "" {
+				out[k] = "***"
+			}
+			continue
+		}
+		out[k] = redactValue(v)
+	}
+	return out
+}
+
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return RedactParams(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func writeAuditEvent(auditLog string, event models.AuditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if auditLog == config.AuditJournald {
+		return sendJournal(journalFields(event, data))
+	}
+	return appendAuditFile(auditLog, append(data, '\n'))
+}
+
+// appendAuditFile appends one line with a single write, so that lines of
+// concurrent writers (e.g. the API server and a CLI run) do not interleave.
+func appendAuditFile(path string, line []byte) error {
+	auditFileMu.Lock()
+	defer auditFileMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// journalFields returns the journal fields of an event. MESSAGE holds the
+// event as JSON; the other fields allow filtering with journalctl.
+func journalFields(event models.AuditEvent, data []byte) [][2]string {
+	priority := "6" // info
+	if event.Outcome == AuditFailure {
+		priority = "4" // warning
+	}
+	return [][2]string{
+		{"MESSAGE", string(data)},
+		{"PRIORITY", priority},
+		{"SYSLOG_IDENTIFIER", journalIdentifier},
+		{"VGW_AUDIT", "1"},
+		{"VGW_SOURCE", event.Source},
+		{"VGW_SITE", event.Site},
+		{"VGW_OPERATION", event.Operation},
+		{"VGW_TARGET", event.Target},
+		{"VGW_OUTCOME", event.Outcome},
+	}
+}
+
+// encodeJournalEntry encodes fields in the journal's native protocol. Values
+// containing a newline are sent length-prefixed.
+func encodeJournalEntry(fields [][2]string) []byte {
+	var buf bytes.Buffer
+	for _, f := range fields {
+		key, value := f[0], f[1]
+		if !strings.Contains(value, "\n") {
+			buf.WriteString(key + "=" + value + "\n")
+			continue
+		}
+		buf.WriteString(key + "\n")
+		binary.Write(&buf, binary.LittleEndian, uint64(len(value)))
+		buf.WriteString(value + "\n")
+	}
+	return buf.Bytes()
+}
+
+func sendJournal(fields [][2]string) error {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("failed to connect to the systemd journal: %w", err)
+	}
+	defer conn.Close()
+	_, err = conn.Write(encodeJournalEntry(fields))
+	return err
+}
+
+// AuditFilter selects audit events. Zero fields match every event.
+type AuditFilter struct {
+	Since time.Time
+	// Operation matches the operation or, without a dot, a family of
+	// operations (e.g. "bucket" matches "bucket.delete").
+	Operation string
+	// Target matches the target exactly or as a path.Match pattern.
+	Target string
+	// Who matches the token, Unix user or sudo user.
+	Who string
+}
+
+// Matches reports whether the event passes the filter.
+func (f AuditFilter) Matches(e models.AuditEvent) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if f.Operation != "" && e.Operation != f.Operation && !strings.HasPrefix(e.Operation, f.Operation+".") {
+		return false
+	}
+	if f.Target != "" && e.Target != f.Target {
+		if ok, _ := path.Match(f.Target, e.Target); !ok {
+			return false
+		}
+	}
+	if f.Who != "" && f.Who != e.Token && f.Who != e.UnixUser && f.Who != e.SudoUser {
+		return false
+	}
+	return true
+}
+
+// ParseAuditSince parses the --since value of the audit query: a duration
+// before now such as "24h" or "7d", a date ("2006-01-02") or an RFC 3339
+// time.
+func ParseAuditSince(s string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: want a duration (24h, 7d), a date (2006-01-02) or an RFC 3339 time", s)
+}
+
+// ReadAuditEvents returns the events of the configured audit log that match
+// the filter, oldest first.
+func ReadAuditEvents(cfg *config.Config, filter AuditFilter) ([]models.AuditEvent, error) {
+	switch cfg.AuditLog {
+	case "":
+		return nil, errors.New("no audit log configured (set auditLog)")
+	case config.AuditJournald:
+		args := []string{"--output=cat", "--no-pager", "SYSLOG_IDENTIFIER=" + journalIdentifier, "VGW_AUDIT=1"}
+		if !filter.Since.IsZero() {
+			args = append(args, "--since=@"+strconv.FormatInt(filter.Since.Unix(), 10))
+		}
+		var stderr bytes.Buffer
+		cmd := exec.Command("journalctl", args...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("journalctl failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return parseAuditEvents(bytes.NewReader(out), "journal", filter)
+	default:
+		f, err := os.Open(cfg.AuditLog)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return parseAuditEvents(f, cfg.AuditLog, filter)
+	}
+}
+
+// parseAuditEvents reads JSON lines, skipping empty ones.
+func parseAuditEvents(r io.Reader, name string, filter AuditFilter) ([]models.AuditEvent, error) {
+	events := []models.AuditEvent{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e models.AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", name, line, err)
+		}
+		if filter.Matches(e) {
+			events = append(events, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return events, nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+func TestRedactParams(t *testing.T) {
+	got := RedactParams(map[string]any{
+		"access":      "alice",
+		"secret":      "s3cr3t",
+		"apiToken":    "tok",
+		"emptySecret": "",
+		"user": map[string]any{
+			"Password": "pw",
+			"role":     "user",
+		},
+		"accounts": []any{map[string]any{"secretKey": "x", "access": "ci"}},
+	})
+	want := map[string]any{
+		"access":   "alice",
+		"secret":   "***",
+		"apiToken": "***",
+		"user": map[string]any{
+			"Password": "***",
+			"role":     "user",
+		},
+		"accounts": []any{map[string]any{"secretKey": "***", "access": "ci"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RedactParams() = %v, want %v", got, want)
+	}
+	if RedactParams(nil) != nil {
+		t.Error("RedactParams(nil) should be nil")
+	}
+}
+
+func TestAuditFilterMatches(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	event := models.AuditEvent{
+		Time:      now,
+		Token:     "portal",
+		UnixUser:  "root",
+		SudoUser:  "alice",
+		Operation: "bucket.delete",
+		Target:    "acme-backups",
+	}
+
+	tests := []struct {
+		name   string
+		filter AuditFilter
+		want   bool
+	}{
+		{"empty filter", AuditFilter{}, true},
+		{"since before", AuditFilter{Since: now.Add(-time.Hour)}, true},
+		{"since after", AuditFilter{Since: now.Add(time.Hour)}, false},
+		{"exact operation", AuditFilter{Operation: "bucket.delete"}, true},
+		{"operation family", AuditFilter{Operation: "bucket"}, true},
+		{"operation prefix is not a family", AuditFilter{Operation: "buck"}, false},
+		{"other operation", AuditFilter{Operation: "user.delete"}, false},
+		{"exact target", AuditFilter{Target: "acme-backups"}, true},
+		{"target pattern", AuditFilter{Target: "acme-*"}, true},
+		{"other target", AuditFilter{Target: "globex-*"}, false},
+		{"who token", AuditFilter{Who: "portal"}, true},
+		{"who sudo user", AuditFilter{Who: "alice"}, true},
+		{"who unix user", AuditFilter{Who: "root"}, true},
+		{"who other", AuditFilter{Who: "bob"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(event); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAuditSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"24h", now.Add(-24 * time.Hour)},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"2026-03-01T08:00:00Z", time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := ParseAuditSince(tt.in, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseAuditSince(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "yesterday", "-1h", "3x"} {
+		if _, err := ParseAuditSince(in, now); err == nil {
+			t.Errorf("ParseAuditSince(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestAuditFileRoundTrip(t *testing.T) {
+	cfg := &config.Config{AuditLog: filepath.Join(t.TempDir(), "log", "audit.log"), Site: "default"}
+	actor := AuditActor{Source: "api", Token: "portal", RemoteAddr: "10.0.0.5:4711"}
+
+	StartAudit(cfg, actor, "bucket.create", "acme", map[string]any{"quota": "1T"}).Finish(nil)
+	failed := StartAudit(cfg, actor, "user.create", "bob", map[string]any{"secret": "s3cr3t"})
+	failed.Finish(errors.New("user exists"))
+	failed.Finish(nil) // only the first outcome is recorded
+
+	events, err := ReadAuditEvents(cfg, AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("ReadAuditEvents() returned %d events, want 2", len(events))
+	}
+	first, second := events[0], events[1]
+	if first.Operation != "bucket.create" || first.Target != "acme" || first.Outcome != AuditSuccess ||
+		first.Token != "portal" || first.Site != "default" || first.Params["quota"] != "1T" {
+		t.Errorf("first event = %+v", first)
+	}
+	if second.Outcome != AuditFailure || second.Error != "user exists" || second.Params["secret"] != "***" {
+		t.Errorf("second event = %+v", second)
+	}
+
+	events, err = ReadAuditEvents(cfg, AuditFilter{Operation: "user"})
+	if err != nil || len(events) != 1 || events[0].Target != "bob" {
+		t.Errorf("ReadAuditEvents(user) = %+v, %v", events, err)
+	}
+
+	if a := StartAudit(&config.Config{}, actor, "bucket.delete", "acme", nil); a != nil {
+		t.Error("StartAudit() without auditLog should return nil")
+	}
+	var disabled *Audit
+	disabled.Finish(nil) // must not panic
+}
+
+func TestEncodeJournalEntry(t *testing.T) {
+	got := encodeJournalEntry([][2]string{{"MESSAGE", "a\nb"}, {"VGW_AUDIT", "1"}})
+
+	var want bytes.Buffer
+	want.WriteString("MESSAGE\n")
+	binary.Write(&want, binary.LittleEndian, uint64(3))
+	want.WriteString("a\nb\nVGW_AUDIT=1\n")
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("encodeJournalEntry() = %q, want %q", got, want.Bytes())
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+// enrichWorkers bounds the concurrent gateway calls made by ListMergedBuckets.
+const enrichWorkers = 16
+
+// bucketMeta is what ListMergedBuckets reads from the gateway for each bucket.
+type bucketMeta struct {
+	owner  string
+	public bool
+	tags   map[string]string
+}
+
+type bucketMetaEntry struct {
+	meta    bucketMeta
+	expires time.Time
+}
+
+// bucketMetaCache caches bucketMeta by endpoint and bucket name for the
+// bucketCacheTTL of the site. Only complete reads are cached, so buckets with
+// enrichment errors are retried on the next listing.
+type bucketMetaCache struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[string]bucketMetaEntry
+}
+
+// bucketMetas is shared by every listing in the process (API server and TUI).
+// VersityGWService methods that change an owner, policy or tags invalidate
+// the bucket's entry.
+var bucketMetas = newBucketMetaCache()
+
+func newBucketMetaCache() *bucketMetaCache {
+	return &bucketMetaCache{now: time.Now, entries: map[string]bucketMetaEntry{}}
+}
+
+// bucketMetaKey scopes a bucket to the gateway it lives on.
+func bucketMetaKey(endpoint, bucket string) string {
+	return endpoint + "|" + bucket
+}
+
+func (c *bucketMetaCache) get(endpoint, bucket string) (bucketMeta, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := bucketMetaKey(endpoint, bucket)
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return bucketMeta{}, false
+	}
+	return entry.meta, true
+}
+
+func (c *bucketMetaCache) put(endpoint, bucket string, meta bucketMeta, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[bucketMetaKey(endpoint, bucket)] = bucketMetaEntry{meta: meta, expires: c.now().Add(ttl)}
+}
+
+func (c *bucketMetaCache) invalidate(endpoint, bucket string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, bucketMetaKey(endpoint, bucket))
+}
+
+// enrichBuckets fills in owner, visibility and tags of every bucket with at
+// most enrichWorkers concurrent lookups. Per-bucket failures are recorded in
+// Bucket.Error; only cancellation of ctx fails the whole call.
+func enrichBuckets(ctx context.Context, vgwService *VersityGWService, buckets []models.Bucket) error {
+	sem := make(chan struct{}, enrichWorkers)
+	var wg sync.WaitGroup
+	for i := range buckets {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(bucket *models.Bucket) {
+			defer func() { <-sem; wg.Done() }()
+			meta, errs := loadBucketMeta(ctx, vgwService, bucket.Name)
+			applyBucketMeta(bucket, meta, errs)
+		}(&buckets[i])
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// loadBucketMeta returns the cached metadata of a bucket or reads it from the
+// gateway. Failed lookups are returned as "<field>: <error>" messages.
+func loadBucketMeta(ctx context.Context, vgwService *VersityGWService, bucket string) (bucketMeta, []string) {
+	if meta, ok := bucketMetas.get(vgwService.cfg.EndpointURL, bucket); ok {
+		return meta, nil
+	}
+
+	var meta bucketMeta
+	var errs []string
+	var err error
+	if meta.owner, err = vgwService.GetBucketOwner(ctx, bucket); err != nil {
+		errs = append(errs, "owner: "+err.Error())
+	}
+	if meta.public, err = bucketIsPublic(ctx, vgwService, bucket); err != nil {
+		errs = append(errs, "policy: "+err.Error())
+	}
+	if meta.tags, err = vgwService.GetBucketTagging(ctx, bucket); err != nil {
+		errs = append(errs, "tags: "+err.Error())
+	}
+
+	if len(errs) == 0 {
+		bucketMetas.put(vgwService.cfg.EndpointURL, bucket, meta, vgwService.cfg.BucketCacheTTL)
+	}
+	return meta, errs
+}
+
+// applyBucketMeta copies looked-up metadata onto a bucket. The owner from the
+// bucket listing is kept when the ACL owner is unknown. The tags are copied,
+// since meta may be shared with the cache.
+func applyBucketMeta(bucket *models.Bucket, meta bucketMeta, errs []string) {
+	if meta.owner != "" {
+		bucket.Owner = meta.owner
+	}
+	bucket.Public = meta.public
+	if len(meta.tags) > 0 {
+		bucket.Tags = maps.Clone(meta.tags)
+	}
+	bucket.Error = strings.Join(errs, "; ")
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+func TestBucketMetaCache(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := newBucketMetaCache()
+	cache.now = func() time.Time { return now }
+
+	cache.put("http://a", "data", bucketMeta{owner: "alice", public: true}, 30*time.Second)
+	if _, ok := cache.get("http://b", "data"); ok {
+		t.Error("get() returned an entry of another endpoint")
+	}
+	if meta, ok := cache.get("http://a", "data"); !ok || meta.owner != "alice" || !meta.public {
+		t.Fatalf("get() = %+v, %v; want cached entry", meta, ok)
+	}
+
+	now = now.Add(30 * time.Second)
+	if _, ok := cache.get("http://a", "data"); ok {
+		t.Error("get() returned an expired entry")
+	}
+
+	cache.put("http://a", "data", bucketMeta{owner: "bob"}, 30*time.Second)
+	cache.invalidate("http://a", "data")
+	if _, ok := cache.get("http://a", "data"); ok {
+		t.Error("get() returned an invalidated entry")
+	}
+}
+
+func TestApplyBucketMeta(t *testing.T) {
+	bucket := models.Bucket{Name: "data", Owner: "listed"}
+	applyBucketMeta(&bucket, bucketMeta{public: true}, []string{"owner: timeout", "tags: API error (status 500)"})
+	if bucket.Owner != "listed" || !bucket.Public || bucket.Error != "owner: timeout; tags: API error (status 500)" {
+		t.Errorf("applyBucketMeta() with errors = %+v", bucket)
+	}
+
+	meta := bucketMeta{owner: "alice", tags: map[string]string{"team": "web"}}
+	applyBucketMeta(&bucket, meta, nil)
+	if bucket.Owner != "alice" || bucket.Public || bucket.Tags["team"] != "web" || bucket.Error != "" {
+		t.Errorf("applyBucketMeta() = %+v", bucket)
+	}
+	bucket.Tags["team"] = "changed"
+	if meta.tags["team"] != "web" {
+		t.Error("applyBucketMeta() shares the tags map with the cached meta")
+	}
+}
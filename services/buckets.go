@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/monobilisim/vgw-manager/config"
@@ -11,16 +13,18 @@ import (
 )
 
 // BucketService handles bucket-related operations
-type BucketService struct{}
+type BucketService struct {
+	cfg *config.Config
+}
 
 // NewBucketService creates a new BucketService instance
-func NewBucketService() *BucketService {
-	return &BucketService{}
+func NewBucketService(cfg *config.Config) *BucketService {
+	return &BucketService{cfg: cfg}
 }
 
 // ListBuckets returns all ZFS buckets with their properties
-func (s *BucketService) ListBuckets() ([]models.Bucket, error) {
-	cmd := exec.Command("zfs", "list", "-H", "-o", "name,mountpoint,quota,used,avail", "-t", "filesystem", "-r", config.ZFSPoolBase)
+func (s *BucketService) ListBuckets(ctx context.Context) ([]models.Bucket, error) {
+	cmd := exec.CommandContext(ctx, "zfs", "list", "-H", "-o", "name,mountpoint,quota,used,avail", "-t", "filesystem", "-r", s.cfg.ZFSPoolBase)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("failed to list ZFS filesystems: %w (output: %s)", err, string(output))
@@ -40,17 +44,17 @@ func (s *BucketService) ListBuckets() ([]models.Bucket, error) {
 		}
 
 		// Skip the base pool itself
-		if fields[0] == config.ZFSPoolBase {
+		if fields[0] == s.cfg.ZFSPoolBase {
 			continue
 		}
 
 		// Check prefix
-		if !strings.HasPrefix(fields[0], config.ZFSPoolBase+"/") {
+		if !strings.HasPrefix(fields[0], s.cfg.ZFSPoolBase+"/") {
 			continue
 		}
 
 		// Extract bucket name from ZFS path
-		name := strings.TrimPrefix(fields[0], config.ZFSPoolBase+"/")
+		name := strings.TrimPrefix(fields[0], s.cfg.ZFSPoolBase+"/")
 
 		bucket := models.Bucket{
 			Name:       name,
@@ -73,12 +77,12 @@ func (s *BucketService) ListBuckets() ([]models.Bucket, error) {
 
 // CreateBucket creates a new ZFS bucket with specified quota.
 // Ownership is handled separately via the change-bucket-owner API.
-func (s *BucketService) CreateBucket(req models.BucketCreateRequest) error {
+func (s *BucketService) CreateBucket(ctx context.Context, req models.BucketCreateRequest) error {
 	if req.Mountpoint == "" {
-		req.Mountpoint = fmt.Sprintf("%s/%s", config.MountBase, req.Name)
+		req.Mountpoint = fmt.Sprintf("%s/%s", s.cfg.MountBase, req.Name)
 	}
 
-	zfsPath := fmt.Sprintf("%s/%s", config.ZFSPoolBase, req.Name)
+	zfsPath := fmt.Sprintf("%s/%s", s.cfg.ZFSPoolBase, req.Name)
 
 	args := []string{"create"}
 	args = append(args, "-o", fmt.Sprintf("mountpoint=%s", req.Mountpoint))
@@ -89,7 +93,7 @@ func (s *BucketService) CreateBucket(req models.BucketCreateRequest) error {
 
 	args = append(args, zfsPath)
 
-	cmd := exec.Command("zfs", args...)
+	cmd := exec.CommandContext(ctx, "zfs", args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to create ZFS bucket: %w (output: %s)", err, string(output))
@@ -99,11 +103,11 @@ func (s *BucketService) CreateBucket(req models.BucketCreateRequest) error {
 }
 
 // DeleteBucket deletes a ZFS bucket using zfs destroy
-func (s *BucketService) DeleteBucket(name string) error {
-	zfsPath := fmt.Sprintf("%s/%s", config.ZFSPoolBase, name)
+func (s *BucketService) DeleteBucket(ctx context.Context, name string) error {
+	zfsPath := fmt.Sprintf("%s/%s", s.cfg.ZFSPoolBase, name)
 
 	// zfs destroy -r ensures snapshots/clones are also removed if standard
-	cmd := exec.Command("zfs", "destroy", "-r", zfsPath)
+	cmd := exec.CommandContext(ctx, "zfs", "destroy", "-r", zfsPath)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to delete ZFS bucket: %w (output: %s)", err, string(output))
@@ -112,9 +116,29 @@ func (s *BucketService) DeleteBucket(name string) error {
 	return nil
 }
 
+// PoolUsage returns the bytes used by and available to zfsPoolBase, the
+// dataset all buckets are created under.
+func (s *BucketService) PoolUsage(ctx context.Context) (used, available int64, err error) {
+	output, err := exec.CommandContext(ctx, "zfs", "list", "-Hp", "-o", "used,avail", s.cfg.ZFSPoolBase).CombinedOutput()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read ZFS pool usage: %w (output: %s)", err, strings.TrimSpace(string(output)))
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected zfs list output: %q", strings.TrimSpace(string(output)))
+	}
+	if used, err = strconv.ParseInt(fields[0], 10, 64); err == nil {
+		available, err = strconv.ParseInt(fields[1], 10, 64)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("unexpected zfs list output: %w", err)
+	}
+	return used, available, nil
+}
+
 // GetBucket returns information about a specific bucket
-func (s *BucketService) GetBucket(name string) (*models.Bucket, error) {
-	buckets, err := s.ListBuckets()
+func (s *BucketService) GetBucket(ctx context.Context, name string) (*models.Bucket, error) {
+	buckets, err := s.ListBuckets(ctx)
 	if err != nil {
 		return nil, err
 	}
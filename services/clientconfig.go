@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+// Client config formats accepted by RenderClientConfig.
+const (
+	ClientConfigAWS    = "aws"
+	ClientConfigRclone = "rclone"
+	ClientConfigS3cmd  = "s3cmd"
+	ClientConfigMc     = "mc"
+	ClientConfigEnv    = "env"
+)
+
+// ClientConfigFormats lists the supported client config formats in display order.
+var ClientConfigFormats = []string{
+	ClientConfigAWS,
+	ClientConfigRclone,
+	ClientConfigS3cmd,
+	ClientConfigMc,
+	ClientConfigEnv,
+}
+
+// ClientConfigDescriptions is a short human-readable label per format.
+var ClientConfigDescriptions = map[string]string{
+	ClientConfigAWS:    "AWS CLI profile (~/.aws/credentials + ~/.aws/config)",
+	ClientConfigRclone: "rclone remote (rclone.conf)",
+	ClientConfigS3cmd:  "s3cmd config (~/.s3cfg)",
+	ClientConfigMc:     "MinIO client alias (mc alias set)",
+	ClientConfigEnv:    "Environment variables (AWS SDKs)",
+}
+
+// ClientConfig is a rendered client configuration for one user.
+type ClientConfig struct {
+	Access   string `json:"access"`
+	Format   string `json:"format"`
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"`
+	Content  string `json:"content"`
+}
+
+// clientProfileChars matches characters not allowed in profile, remote and alias names.
+var clientProfileChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// RenderClientConfig renders a user's credentials, the public endpoint and the
+// region as a ready-to-use configuration for the given client.
+func RenderClientConfig(cfg *config.Config, user models.User, format string) (*ClientConfig, error) {
+	content, err := renderClientConfig(format, user.Access, user.Secret, cfg.PublicEndpointURL, cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientConfig{
+		Access:   user.Access,
+		Format:   format,
+		Endpoint: cfg.PublicEndpointURL,
+		Region:   cfg.Region,
+		Content:  content,
+	}, nil
+}
+
+func renderClientConfig(format, access, secret, endpoint, region string) (string, error) {
+	endpoint = strings.TrimRight(endpoint, "/")
+	name := clientProfileName(access)
+
+	var b strings.Builder
+	switch format {
+	case ClientConfigAWS:
+		fmt.Fprintf(&b, "# ~/.aws/credentials\n")
+		fmt.Fprintf(&b, "[%s]\n", name)
+		fmt.Fprintf(&b, "aws_access_key_id = %s\n", access)
+		fmt.Fprintf(&b, "aws_secret_access_key = %s\n", secret)
+		fmt.Fprintf(&b, "\n# ~/.aws/config\n")
+		fmt.Fprintf(&b, "[profile %s]\n", name)
+		fmt.Fprintf(&b, "region = %s\n", region)
+		fmt.Fprintf(&b, "endpoint_url = %s\n", endpoint)
+		fmt.Fprintf(&b, "s3 =\n    addressing_style = path\n")
+		fmt.Fprintf(&b, "\n# Usage: aws --profile %s s3 ls\n", name)
+
+	case ClientConfigRclone:
+		fmt.Fprintf(&b, "[%s]\n", name)
+		fmt.Fprintf(&b, "type = s3\n")
+		fmt.Fprintf(&b, "provider = Other\n")
+		fmt.Fprintf(&b, "access_key_id = %s\n", access)
+		fmt.Fprintf(&b, "secret_access_key = %s\n", secret)
+		fmt.Fprintf(&b, "endpoint = %s\n", endpoint)
+		fmt.Fprintf(&b, "region = %s\n", region)
+		fmt.Fprintf(&b, "force_path_style = true\n")
+
+	case ClientConfigS3cmd:
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Host == "" {
+			return "", fmt.Errorf("invalid endpoint URL %q", endpoint)
+		}
+		useHTTPS := "False"
+		if u.Scheme == "https" {
+			useHTTPS = "True"
+		}
+		fmt.Fprintf(&b, "[default]\n")
+		fmt.Fprintf(&b, "access_key = %s\n", access)
+		fmt.Fprintf(&b, "secret_key = %s\n", secret)
+		fmt.Fprintf(&b, "host_base = %s\n", u.Host)
+		// Path-style addressing: the bucket is not part of the host name.
+		fmt.Fprintf(&b, "host_bucket = %s\n", u.Host)
+		fmt.Fprintf(&b, "bucket_location = %s\n", region)
+		fmt.Fprintf(&b, "use_https = %s\n", useHTTPS)
+		fmt.Fprintf(&b, "signature_v2 = False\n")
+
+	case ClientConfigMc:
+		fmt.Fprintf(&b, "mc alias set %s %s %s %s --api S3v4 --path on\n",
+			name, shellQuote(endpoint), shellQuote(access), shellQuote(secret))
+
+	case ClientConfigEnv:
+		fmt.Fprintf(&b, "export AWS_ACCESS_KEY_ID=%s\n", shellQuote(access))
+		fmt.Fprintf(&b, "export AWS_SECRET_ACCESS_KEY=%s\n", shellQuote(secret))
+		fmt.Fprintf(&b, "export AWS_REGION=%s\n", shellQuote(region))
+		fmt.Fprintf(&b, "export AWS_DEFAULT_REGION=%s\n", shellQuote(region))
+		fmt.Fprintf(&b, "export AWS_ENDPOINT_URL=%s\n", shellQuote(endpoint))
+
+	default:
+		return "", fmt.Errorf("unknown client config format %q (supported: %s)", format, strings.Join(ClientConfigFormats, ", "))
+	}
+
+	return b.String(), nil
+}
+
+// clientProfileName derives a profile/remote/alias name from an access key.
+func clientProfileName(access string) string {
+	name := strings.Trim(clientProfileChars.ReplaceAllString(access, "-"), "-")
+	if name == "" {
+		return "vgw"
+	}
+	return name
+}
+
+// shellQuote quotes s for POSIX shells when it contains anything beyond a safe set.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@+,%", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderClientConfig(t *testing.T) {
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{ClientConfigAWS, []string{"[alice]", "aws_secret_access_key = s3cr/et", "[profile alice]", "endpoint_url = https://s3.example.com:9000", "region = eu-1"}},
+		{ClientConfigRclone, []string{"[alice]", "type = s3", "endpoint = https://s3.example.com:9000", "force_path_style = true"}},
+		{ClientConfigS3cmd, []string{"host_base = s3.example.com:9000", "host_bucket = s3.example.com:9000", "use_https = True", "bucket_location = eu-1"}},
+		{ClientConfigMc, []string{"mc alias set alice https://s3.example.com:9000 alice s3cr/et --api S3v4 --path on"}},
+		{ClientConfigEnv, []string{"export AWS_SECRET_ACCESS_KEY=s3cr/et", "export AWS_ENDPOINT_URL=https://s3.example.com:9000"}},
+	}
+	for _, tt := range tests {
+		got, err := renderClientConfig(tt.format, "alice", "s3cr/et", "https://s3.example.com:9000/", "eu-1")
+		if err != nil {
+			t.Fatalf("renderClientConfig(%s) error = %v", tt.format, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("renderClientConfig(%s) missing %q in:\n%s", tt.format, want, got)
+			}
+		}
+	}
+
+	if _, err := renderClientConfig("cyberduck", "alice", "x", "http://h", "r"); err == nil {
+		t.Error("unknown format succeeded, want error")
+	}
+}
+
+func TestClientConfigQuoting(t *testing.T) {
+	got, err := renderClientConfig(ClientConfigEnv, "bob", "it's $ecret", "http://h:7070", "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, `AWS_SECRET_ACCESS_KEY='it'\''s $ecret'`) {
+		t.Errorf("secret not shell-quoted:\n%s", got)
+	}
+
+	if got := clientProfileName("team lead@corp"); got != "team-lead-corp" {
+		t.Errorf("clientProfileName() = %q", got)
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// CORSRule is a single S3 bucket CORS rule.
+type CORSRule struct {
+	ID             string   `json:"id,omitempty" xml:"ID,omitempty"`
+	AllowedOrigins []string `json:"allowedOrigins" xml:"AllowedOrigin"`
+	AllowedMethods []string `json:"allowedMethods" xml:"AllowedMethod"`
+	AllowedHeaders []string `json:"allowedHeaders,omitempty" xml:"AllowedHeader,omitempty"`
+	ExposeHeaders  []string `json:"exposeHeaders,omitempty" xml:"ExposeHeader,omitempty"`
+	MaxAgeSeconds  int      `json:"maxAgeSeconds,omitempty" xml:"MaxAgeSeconds,omitempty"`
+}
+
+// corsConfiguration is the S3 Put/GetBucketCors document.
+type corsConfiguration struct {
+	XMLName xml.Name   `xml:"CORSConfiguration"`
+	Rules   []CORSRule `xml:"CORSRule"`
+}
+
+// corsMethods are the methods S3 accepts in AllowedMethod.
+var corsMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPut:    true,
+	http.MethodPost:   true,
+	http.MethodDelete: true,
+}
+
+// Validate checks that a CORS rule has origins and only S3-supported methods.
+func (r CORSRule) Validate() error {
+	if len(r.AllowedOrigins) == 0 {
+		return fmt.Errorf("CORS rule requires at least one allowed origin")
+	}
+	if len(r.AllowedMethods) == 0 {
+		return fmt.Errorf("CORS rule requires at least one allowed method")
+	}
+	for _, method := range r.AllowedMethods {
+		if !corsMethods[method] {
+			return fmt.Errorf("unsupported CORS method %q (use GET, HEAD, PUT, POST, or DELETE)", method)
+		}
+	}
+	if r.MaxAgeSeconds < 0 {
+		return fmt.Errorf("CORS max age must not be negative")
+	}
+	return nil
+}
+
+// GenerateAllowGetCORSRule returns the CORS rule for browser apps that read objects
+// from a public bucket: GET/HEAD from the given origins, any request header,
+// and ETag exposed to scripts.
+func GenerateAllowGetCORSRule(origins []string) CORSRule {
+	return CORSRule{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{http.MethodGet, http.MethodHead},
+		AllowedHeaders: []string{"*"},
+		ExposeHeaders:  []string{"ETag"},
+		MaxAgeSeconds:  3600,
+	}
+}
+
+// GetBucketCors retrieves the CORS rules of a bucket.
+// A bucket without a CORS configuration returns no rules.
+func (s *VersityGWService) GetBucketCors(ctx context.Context, bucket string) ([]CORSRule, error) {
+	url := fmt.Sprintf("%s/%s?cors", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	body, err := s.signAndSend(httpReq, []byte{})
+	if err != nil {
+		if isNotFoundError(err) {
+			return []CORSRule{}, nil
+		}
+		return nil, err
+	}
+
+	var doc corsConfiguration
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse bucket CORS: %w", err)
+	}
+	if doc.Rules == nil {
+		doc.Rules = []CORSRule{}
+	}
+	return doc.Rules, nil
+}
+
+// PutBucketCors replaces the CORS configuration of a bucket.
+func (s *VersityGWService) PutBucketCors(ctx context.Context, bucket string, rules []CORSRule) error {
+	payload, err := xml.Marshal(corsConfiguration{Rules: rules})
+	if err != nil {
+		return fmt.Errorf("failed to marshal bucket CORS: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s?cors", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	setContentMD5(httpReq, payload)
+
+	if _, err := s.signAndSend(httpReq, payload); err != nil {
+		return fmt.Errorf("failed to set bucket CORS: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBucketCors removes the CORS configuration of a bucket.
+func (s *VersityGWService) DeleteBucketCors(ctx context.Context, bucket string) error {
+	url := fmt.Sprintf("%s/%s?cors", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if _, err := s.signAndSend(httpReq, []byte{}); err != nil {
+		return fmt.Errorf("failed to delete bucket CORS: %w", err)
+	}
+
+	return nil
+}
+
+// SetBucketCors validates and applies CORS rules to a bucket. An empty rule set
+// removes the CORS configuration.
+func SetBucketCors(ctx context.Context, cfg *config.Config, bucket string, rules []CORSRule) error {
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+
+	vgwService := NewVersityGWService(cfg)
+	if len(rules) == 0 {
+		return vgwService.DeleteBucketCors(ctx, bucket)
+	}
+	return vgwService.PutBucketCors(ctx, bucket, rules)
+}
+
+// AllowCorsGet allows GET from origins on a bucket. The origins are merged
+// into an existing "allow GET" rule (see GenerateAllowGetCORSRule), which is
+// only added when the bucket has none, so repeated calls do not pile up rules.
+// Returns the resulting rule.
+func AllowCorsGet(ctx context.Context, cfg *config.Config, bucket string, origins []string) (CORSRule, error) {
+	if err := GenerateAllowGetCORSRule(origins).Validate(); err != nil {
+		return CORSRule{}, err
+	}
+
+	rules, err := NewVersityGWService(cfg).GetBucketCors(ctx, bucket)
+	if err != nil {
+		return CORSRule{}, fmt.Errorf("failed to read bucket CORS: %w", err)
+	}
+
+	rules, index, changed := mergeAllowGetRule(rules, origins)
+	if !changed {
+		return rules[index], nil
+	}
+	return rules[index], SetBucketCors(ctx, cfg, bucket, rules)
+}
+
+// mergeAllowGetRule adds origins to the first "allow GET" rule in rules, or
+// appends a new one. It returns the rules, the index of the "allow GET" rule
+// and whether anything changed.
+func mergeAllowGetRule(rules []CORSRule, origins []string) ([]CORSRule, int, bool) {
+	template := GenerateAllowGetCORSRule(nil)
+	for i, rule := range rules {
+		if rule.ID != "" || !slices.Equal(rule.AllowedMethods, template.AllowedMethods) ||
+			!slices.Equal(rule.AllowedHeaders, template.AllowedHeaders) ||
+			!slices.Equal(rule.ExposeHeaders, template.ExposeHeaders) || rule.MaxAgeSeconds != template.MaxAgeSeconds {
+			continue
+		}
+		changed := false
+		merged := append([]string{}, rule.AllowedOrigins...)
+		for _, origin := range origins {
+			if !slices.Contains(merged, origin) {
+				merged = append(merged, origin)
+				changed = true
+			}
+		}
+		rules[i].AllowedOrigins = merged
+		return rules, i, changed
+	}
+	return append(rules, GenerateAllowGetCORSRule(origins)), len(rules), true
+}
+
+// RemoveCorsRule removes the CORS rule at index (0-based) from a bucket.
+func RemoveCorsRule(ctx context.Context, cfg *config.Config, bucket string, index int) error {
+	rules, err := NewVersityGWService(cfg).GetBucketCors(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to read bucket CORS: %w", err)
+	}
+	if index < 0 || index >= len(rules) {
+		return fmt.Errorf("CORS rule %d not found (bucket has %d rules)", index+1, len(rules))
+	}
+
+	return SetBucketCors(ctx, cfg, bucket, append(rules[:index], rules[index+1:]...))
+}
+
+// ParseOrigins splits a comma-separated origin list, dropping empty entries.
+func ParseOrigins(s string) []string {
+	var origins []string
+	for _, origin := range strings.Split(s, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGenerateAllowGetCORSRule(t *testing.T) {
+	rule := GenerateAllowGetCORSRule([]string{"https://app.example.com"})
+	if err := rule.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !slices.Equal(rule.AllowedOrigins, []string{"https://app.example.com"}) ||
+		!slices.Equal(rule.AllowedMethods, []string{"GET", "HEAD"}) ||
+		!slices.Equal(rule.AllowedHeaders, []string{"*"}) ||
+		!slices.Equal(rule.ExposeHeaders, []string{"ETag"}) || rule.MaxAgeSeconds != 3600 {
+		t.Errorf("GenerateAllowGetCORSRule() = %+v", rule)
+	}
+}
+
+func TestCORSRuleValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    CORSRule
+		wantErr bool
+	}{
+		{"valid", CORSRule{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET", "PUT"}}, false},
+		{"no origins", CORSRule{AllowedMethods: []string{"GET"}}, true},
+		{"no methods", CORSRule{AllowedOrigins: []string{"*"}}, true},
+		{"unsupported method", CORSRule{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"PATCH"}}, true},
+		{"lowercase method", CORSRule{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"get"}}, true},
+		{"negative max age", CORSRule{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}, MaxAgeSeconds: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseOrigins(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"https://a.example.com", []string{"https://a.example.com"}},
+		{" https://a.example.com , https://b.example.com ", []string{"https://a.example.com", "https://b.example.com"}},
+		{"https://a.example.com,,", []string{"https://a.example.com"}},
+	}
+	for _, tt := range tests {
+		if got := ParseOrigins(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseOrigins(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMergeAllowGetRule(t *testing.T) {
+	const a, b = "https://a.example.com", "https://b.example.com"
+	put := CORSRule{AllowedOrigins: []string{a}, AllowedMethods: []string{"PUT"}}
+	custom := GenerateAllowGetCORSRule([]string{a})
+	custom.MaxAgeSeconds = 60
+
+	tests := []struct {
+		name        string
+		rules       []CORSRule
+		origins     []string
+		wantIndex   int
+		wantOrigins []string
+		wantChanged bool
+		wantRules   int
+	}{
+		{"no rules", nil, []string{a}, 0, []string{a}, true, 1},
+		{"other rules only", []CORSRule{put, custom}, []string{a}, 2, []string{a}, true, 3},
+		{"new origin merged", []CORSRule{put, GenerateAllowGetCORSRule([]string{a})}, []string{b}, 1, []string{a, b}, true, 2},
+		{"known origin", []CORSRule{GenerateAllowGetCORSRule([]string{a, b})}, []string{b}, 0, []string{a, b}, false, 1},
+		{"partly known origins", []CORSRule{GenerateAllowGetCORSRule([]string{a})}, []string{a, b}, 0, []string{a, b}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, index, changed := mergeAllowGetRule(tt.rules, tt.origins)
+			if index != tt.wantIndex || changed != tt.wantChanged || len(rules) != tt.wantRules {
+				t.Fatalf("mergeAllowGetRule() = %d rules, index %d, changed %v, want %d, %d, %v",
+					len(rules), index, changed, tt.wantRules, tt.wantIndex, tt.wantChanged)
+			}
+			if !slices.Equal(rules[index].AllowedOrigins, tt.wantOrigins) {
+				t.Errorf("origins = %q, want %q", rules[index].AllowedOrigins, tt.wantOrigins)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+// Results of a DoctorCheck.
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// DoctorCheck is the outcome of one environment check.
+type DoctorCheck struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Detail      string `json:"detail"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// minTokenLength is the API token length below which the token is reported
+// as weak.
+const minTokenLength = 32
+
+// RunDoctor checks the environment vgw-manager runs in: privileges, the zfs
+// tooling and datasets, users.json, the gateway credentials and the API token.
+// Checks never stop early; every problem found is reported.
+func RunDoctor(ctx context.Context, cfg *config.Config) []DoctorCheck {
+	checks := []DoctorCheck{checkRoot(os.Geteuid())}
+
+	zfsCheck, zfsFound := checkZFSBinary()
+	checks = append(checks, zfsCheck)
+	if zfsFound {
+		poolCheck := checkZFSPool(ctx, cfg)
+		checks = append(checks, poolCheck)
+		if poolCheck.Status == CheckPass {
+			buckets, err := NewBucketService(cfg).ListBuckets(ctx)
+			if err != nil {
+				checks = append(checks, DoctorCheck{Name: "mountBase", Status: CheckFail, Detail: err.Error(),
+					Remediation: "Check that the datasets under zfsPoolBase can be listed with zfs list."})
+			} else {
+				checks = append(checks, checkMountpoints(buckets, cfg.MountBase))
+			}
+		}
+	}
+
+	checks = append(checks, checkUsersJSON(cfg), checkGateway(ctx, cfg))
+	if cfg.APIToken != "" || len(cfg.APITokens) == 0 {
+		checks = append(checks, checkAPIToken("apiToken", cfg.APIToken))
+	}
+	for _, token := range cfg.APITokens {
+		checks = append(checks, checkAPIToken("apiTokens "+token.Name, token.Token))
+	}
+	if cfg.AuditLog != "" {
+		checks = append(checks, checkAuditLog(cfg.AuditLog))
+	}
+	return checks
+}
+
+// checkAuditLog reports whether audit events can be written.
+func checkAuditLog(auditLog string) DoctorCheck {
+	if auditLog == config.AuditJournald {
+		if _, err := os.Stat(journalSocket); err != nil {
+			return DoctorCheck{Name: "auditLog", Status: CheckFail, Detail: "systemd journal socket not found",
+				Remediation: "Run under systemd-journald or set auditLog to a file path."}
+		}
+		return DoctorCheck{Name: "auditLog", Status: CheckPass, Detail: "systemd journal"}
+	}
+	if err := os.MkdirAll(filepath.Dir(auditLog), 0o750); err != nil {
+		return DoctorCheck{Name: "auditLog", Status: CheckFail, Detail: err.Error(),
+			Remediation: "Create the directory of auditLog or choose another path."}
+	}
+	f, err := os.OpenFile(auditLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return DoctorCheck{Name: "auditLog", Status: CheckFail, Detail: err.Error(),
+			Remediation: "Make auditLog writable by the user running vgw-manager."}
+	}
+	f.Close()
+	return DoctorCheck{Name: "auditLog", Status: CheckPass, Detail: auditLog + " is writable"}
+}
+
+func checkRoot(euid int) DoctorCheck {
+	if euid == 0 {
+		return DoctorCheck{Name: "root", Status: CheckPass, Detail: "running as root"}
+	}
+	return DoctorCheck{Name: "root", Status: CheckWarn, Detail: fmt.Sprintf("running as uid %d", euid),
+		Remediation: "Run vgw-manager as root: creating and deleting buckets changes ZFS datasets."}
+}
+
+func checkZFSBinary() (DoctorCheck, bool) {
+	zfsPath, err := exec.LookPath("zfs")
+	if err != nil {
+		return DoctorCheck{Name: "zfs", Status: CheckFail, Detail: "zfs binary not found in PATH",
+			Remediation: "Install the ZFS utilities (e.g. zfsutils-linux) or add their directory to PATH."}, false
+	}
+	return DoctorCheck{Name: "zfs", Status: CheckPass, Detail: zfsPath}, true
+}
+
+func checkZFSPool(ctx context.Context, cfg *config.Config) DoctorCheck {
+	output, err := exec.CommandContext(ctx, "zfs", "list", "-H", "-o", "name", cfg.ZFSPoolBase).CombinedOutput()
+	if err != nil {
+		return DoctorCheck{Name: "zfsPoolBase", Status: CheckFail,
+			Detail:      fmt.Sprintf("dataset %s: %s", cfg.ZFSPoolBase, strings.TrimSpace(string(output))),
+			Remediation: fmt.Sprintf("Create it with zfs create -p %s or correct zfsPoolBase.", cfg.ZFSPoolBase)}
+	}
+	return DoctorCheck{Name: "zfsPoolBase", Status: CheckPass, Detail: cfg.ZFSPoolBase + " exists"}
+}
+
+// checkMountpoints reports buckets whose dataset is not mounted at
+// <mountBase>/<bucket>, where the gateway expects it.
+func checkMountpoints(buckets []models.Bucket, mountBase string) DoctorCheck {
+	var mismatched []string
+	for _, bucket := range buckets {
+		if strings.Contains(bucket.Name, "/") {
+			continue // nested datasets are not buckets
+		}
+		if want := path.Join(mountBase, bucket.Name); path.Clean(bucket.Mountpoint) != want {
+			mismatched = append(mismatched, fmt.Sprintf("%s (%s, want %s)", bucket.Name, bucket.Mountpoint, want))
+		}
+	}
+	if len(mismatched) == 0 {
+		return DoctorCheck{Name: "mountBase", Status: CheckPass, Detail: fmt.Sprintf("%d bucket datasets mounted under %s", len(buckets), mountBase)}
+	}
+	detail := strings.Join(mismatched, ", ")
+	if len(mismatched) > 5 {
+		detail = strings.Join(mismatched[:5], ", ") + fmt.Sprintf(" and %d more", len(mismatched)-5)
+	}
+	return DoctorCheck{Name: "mountBase", Status: CheckWarn, Detail: "mountpoints differ: " + detail,
+		Remediation: "Set mountBase to the directory the gateway serves buckets from, or fix the datasets with zfs set mountpoint=<mountBase>/<bucket>."}
+}
+
+func checkUsersJSON(cfg *config.Config) DoctorCheck {
+	users, err := NewUserService(cfg).ListUsers()
+	if err != nil {
+		return DoctorCheck{Name: "usersJSONPath", Status: CheckFail, Detail: err.Error(),
+			Remediation: "Point usersJSONPath at the gateway's users.json (the IAM file of versitygw --iam-dir) and make it readable."}
+	}
+	return DoctorCheck{Name: "usersJSONPath", Status: CheckPass, Detail: fmt.Sprintf("%s: %d users", cfg.UsersJSONPath, len(users))}
+}
+
+func checkGateway(ctx context.Context, cfg *config.Config) DoctorCheck {
+	buckets, err := NewVersityGWService(cfg).ListBuckets(ctx)
+	if err != nil {
+		remediation := "Check that endpointURL is reachable from this host and the gateway is running."
+		if strings.Contains(err.Error(), "API error (status 403)") {
+			remediation = "Check adminAccess and adminSecret against the gateway's root or admin account, and region."
+		}
+		return DoctorCheck{Name: "gateway", Status: CheckFail, Detail: err.Error(), Remediation: remediation}
+	}
+	check := DoctorCheck{Name: "gateway", Status: CheckPass, Detail: fmt.Sprintf("%s: signed request succeeded, %d buckets", cfg.EndpointURL, len(buckets))}
+	if cfg.AdminSecret == "changeme-secret" {
+		check.Status = CheckWarn
+		check.Remediation = "The admin secret is the example value; rotate it on the gateway."
+	}
+	return check
+}
+
+// checkAPIToken rates an API token: missing tokens only matter for --serve,
+// example, short or single-class tokens are weak.
+func checkAPIToken(name, token string) DoctorCheck {
+	const remediation = "Generate a random token, e.g. with openssl rand -hex 32, and set apiToken (or apiTokenFile)."
+	switch {
+	case token == "":
+		return DoctorCheck{Name: name, Status: CheckWarn, Detail: "not set; required for --serve", Remediation: remediation}
+	case token == "changeme-token":
+		return DoctorCheck{Name: name, Status: CheckFail, Detail: "the example token is in use", Remediation: remediation}
+	case len(token) < minTokenLength:
+		return DoctorCheck{Name: name, Status: CheckFail, Detail: fmt.Sprintf("%d characters, want at least %d", len(token), minTokenLength), Remediation: remediation}
+	case characterClasses(token) < 2:
+		return DoctorCheck{Name: name, Status: CheckWarn, Detail: "uses a single character class", Remediation: remediation}
+	}
+	return DoctorCheck{Name: name, Status: CheckPass, Detail: fmt.Sprintf("%d characters", len(token))}
+}
+
+// characterClasses counts the kinds of characters (lower, upper, digits,
+// other) in s.
+func characterClasses(s string) int {
+	var lower, upper, digit, other int
+	for _, r := range s {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+	return lower + upper + digit + other
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+func TestCheckAPIToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"", CheckWarn},
+		{"changeme-token", CheckFail},
+		{"short-Token-1", CheckFail},
+		{strings.Repeat("a", 40), CheckWarn},
+		{"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", CheckPass},
+	}
+	for _, tt := range tests {
+		if got := checkAPIToken("apiToken", tt.token); got.Status != tt.want {
+			t.Errorf("checkAPIToken(%q) = %s (%s), want %s", tt.token, got.Status, got.Detail, tt.want)
+		}
+	}
+}
+
+func TestCheckAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	if got := checkAuditLog(filepath.Join(dir, "audit", "audit.log")); got.Status != CheckPass {
+		t.Errorf("checkAuditLog(writable) = %s (%s), want pass", got.Status, got.Detail)
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := checkAuditLog(filepath.Join(file, "audit.log")); got.Status != CheckFail {
+		t.Errorf("checkAuditLog(below a file) = %s (%s), want fail", got.Status, got.Detail)
+	}
+}
+
+func TestCheckMountpoints(t *testing.T) {
+	buckets := []models.Bucket{
+		{Name: "data", Mountpoint: "/tank/s3/buckets/data"},
+		{Name: "logs", Mountpoint: "/tank/s3/buckets/logs/"},
+		{Name: "logs/archive", Mountpoint: "/somewhere"},
+	}
+	if got := checkMountpoints(buckets, "/tank/s3/buckets"); got.Status != CheckPass {
+		t.Errorf("checkMountpoints() = %+v, want pass", got)
+	}
+
+	buckets = append(buckets, models.Bucket{Name: "media", Mountpoint: "/mnt/media"})
+	got := checkMountpoints(buckets, "/tank/s3/buckets")
+	if got.Status != CheckWarn || !strings.Contains(got.Detail, "media (/mnt/media, want /tank/s3/buckets/media)") || got.Remediation == "" {
+		t.Errorf("checkMountpoints() = %+v, want warning about media", got)
+	}
+}
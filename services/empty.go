@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// DefaultEmptyWorkers is the number of parallel deletes used when emptying a bucket.
+const DefaultEmptyWorkers = 16
+
+// maxEmptyErrors caps the number of individual delete errors kept in an EmptyResult.
+const maxEmptyErrors = 10
+
+// ObjectVersion is one version (or delete marker) of an object.
+type ObjectVersion struct {
+	Key            string    `json:"key" xml:"Key"`
+	VersionID      string    `json:"versionId" xml:"VersionId"`
+	IsLatest       bool      `json:"isLatest" xml:"IsLatest"`
+	IsDeleteMarker bool      `json:"isDeleteMarker" xml:"-"`
+	Size           int64     `json:"size" xml:"Size"`
+	LastModified   time.Time `json:"lastModified" xml:"LastModified"`
+}
+
+// ObjectVersionListing is one page of a ListObjectVersions response.
+type ObjectVersionListing struct {
+	Versions            []ObjectVersion `json:"versions"`
+	IsTruncated         bool            `json:"isTruncated"`
+	NextKeyMarker       string          `json:"nextKeyMarker,omitempty"`
+	NextVersionIDMarker string          `json:"nextVersionIdMarker,omitempty"`
+}
+
+// listVersionsResult is the S3 ListObjectVersions response document.
+type listVersionsResult struct {
+	Versions            []ObjectVersion `xml:"Version"`
+	DeleteMarkers       []ObjectVersion `xml:"DeleteMarker"`
+	IsTruncated         bool            `xml:"IsTruncated"`
+	NextKeyMarker       string          `xml:"NextKeyMarker"`
+	NextVersionIDMarker string          `xml:"NextVersionIdMarker"`
+}
+
+// ListObjectVersions lists one page of object versions and delete markers.
+// Pass the previous page's NextKeyMarker/NextVersionIDMarker to continue.
+func (s *VersityGWService) ListObjectVersions(ctx context.Context, bucket, keyMarker, versionIDMarker string) (ObjectVersionListing, error) {
+	query := "versions"
+	if keyMarker != "" {
+		query += "&key-marker=" + url.QueryEscape(keyMarker)
+	}
+	if versionIDMarker != "" {
+		query += "&version-id-marker=" + url.QueryEscape(versionIDMarker)
+	}
+	query = strings.ReplaceAll(query, "+", "%20")
+
+	url := fmt.Sprintf("%s/%s?%s", s.cfg.EndpointURL, bucket, query)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return ObjectVersionListing{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	body, err := s.signAndSend(httpReq, []byte{})
+	if err != nil {
+		return ObjectVersionListing{}, fmt.Errorf("failed to list object versions: %w", err)
+	}
+
+	return parseVersionListing(body)
+}
+
+func parseVersionListing(body []byte) (ObjectVersionListing, error) {
+	var result listVersionsResult
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return ObjectVersionListing{}, fmt.Errorf("failed to parse object versions: %w", err)
+	}
+
+	listing := ObjectVersionListing{
+		Versions:            make([]ObjectVersion, 0, len(result.Versions)+len(result.DeleteMarkers)),
+		IsTruncated:         result.IsTruncated,
+		NextKeyMarker:       result.NextKeyMarker,
+		NextVersionIDMarker: result.NextVersionIDMarker,
+	}
+	listing.Versions = append(listing.Versions, result.Versions...)
+	for _, marker := range result.DeleteMarkers {
+		marker.IsDeleteMarker = true
+		listing.Versions = append(listing.Versions, marker)
+	}
+	return listing, nil
+}
+
+// DeleteObjectVersion permanently deletes one version of an object. An empty
+// or "null" version ID deletes the unversioned object.
+func (s *VersityGWService) DeleteObjectVersion(ctx context.Context, bucket, key, versionID string) error {
+	if versionID == "" || versionID == "null" {
+		return s.DeleteObject(ctx, bucket, key)
+	}
+
+	url := fmt.Sprintf("%s?versionId=%s", objectURL(s.cfg.EndpointURL, bucket, key), url.QueryEscape(versionID))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if _, err := s.signAndSend(httpReq, []byte{}); err != nil {
+		return fmt.Errorf("failed to delete object version: %w", err)
+	}
+
+	return nil
+}
+
+// Phases reported while emptying a bucket.
+const (
+	EmptyPhaseUploads = "aborting multipart uploads"
+	EmptyPhaseObjects = "deleting objects"
+	EmptyPhaseBucket  = "deleting bucket"
+)
+
+// EmptyProgress is a snapshot of a running bucket emptying operation.
+type EmptyProgress struct {
+	Phase          string `json:"phase"`
+	UploadsAborted int64  `json:"uploadsAborted"`
+	Deleted        int64  `json:"deleted"`
+	Failed         int64  `json:"failed"`
+}
+
+// EmptyOptions controls EmptyBucket.
+type EmptyOptions struct {
+	// Workers is the number of parallel delete requests (DefaultEmptyWorkers if 0).
+	Workers int
+	// Progress, if set, is called after every delete/abort. Calls are serialized.
+	Progress func(EmptyProgress)
+	// DeleteLock, if set, is held by ForceDeleteBucket while it deletes the
+	// emptied bucket, but not while emptying it.
+	DeleteLock sync.Locker
+}
+
+// EmptyResult summarizes an EmptyBucket run.
+type EmptyResult struct {
+	UploadsAborted int64    `json:"uploadsAborted"`
+	Deleted        int64    `json:"deleted"`
+	Failed         int64    `json:"failed"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// emptyRun tracks counters and errors of one EmptyBucket call.
+type emptyRun struct {
+	opts    EmptyOptions
+	phase   string
+	aborted atomic.Int64
+	deleted atomic.Int64
+	failed  atomic.Int64
+	mu      sync.Mutex
+	errs    []string
+}
+
+func (r *emptyRun) report() {
+	if r.opts.Progress == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.opts.Progress(EmptyProgress{
+		Phase:          r.phase,
+		UploadsAborted: r.aborted.Load(),
+		Deleted:        r.deleted.Load(),
+		Failed:         r.failed.Load(),
+	})
+}
+
+func (r *emptyRun) fail(err error) {
+	r.failed.Add(1)
+	r.mu.Lock()
+	if len(r.errs) < maxEmptyErrors {
+		r.errs = append(r.errs, err.Error())
+	}
+	r.mu.Unlock()
+}
+
+// parallel runs fn over items with at most opts.Workers concurrent calls.
+func (r *emptyRun) parallel(n int, fn func(i int)) {
+	sem := make(chan struct{}, r.opts.Workers)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() { <-sem; wg.Done() }()
+			fn(i)
+			r.report()
+		}(i)
+	}
+	wg.Wait()
+}
+
+// EmptyBucket removes everything from a bucket: incomplete multipart uploads,
+// then every object version and delete marker (or every object when the
+// gateway does not support version listing). Deletes run in parallel.
+// Individual failures are counted and do not stop the run.
+func EmptyBucket(ctx context.Context, cfg *config.Config, bucket string, opts EmptyOptions) (*EmptyResult, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultEmptyWorkers
+	}
+	vgwService := NewVersityGWService(cfg)
+	run := &emptyRun{opts: opts}
+
+	run.phase = EmptyPhaseUploads
+	uploads, err := vgwService.ListMultipartUploads(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	run.parallel(len(uploads), func(i int) {
+		u := uploads[i]
+		if err := vgwService.AbortMultipartUpload(ctx, bucket, u.Key, u.UploadID); err != nil {
+			run.fail(fmt.Errorf("abort %s: %w", u.Key, err))
+			return
+		}
+		run.aborted.Add(1)
+	})
+
+	run.phase = EmptyPhaseObjects
+	run.report()
+	if err := emptyVersions(ctx, vgwService, bucket, run); err != nil {
+		return nil, err
+	}
+
+	return &EmptyResult{
+		UploadsAborted: run.aborted.Load(),
+		Deleted:        run.deleted.Load(),
+		Failed:         run.failed.Load(),
+		Errors:         run.errs,
+	}, nil
+}
+
+// emptyVersions deletes all object versions page by page, falling back to a
+// plain object listing if the gateway does not implement ListObjectVersions.
+func emptyVersions(ctx context.Context, vgwService *VersityGWService, bucket string, run *emptyRun) error {
+	deleteAll := func(versions []ObjectVersion) {
+		run.parallel(len(versions), func(i int) {
+			v := versions[i]
+			if err := vgwService.DeleteObjectVersion(ctx, bucket, v.Key, v.VersionID); err != nil {
+				run.fail(fmt.Errorf("delete %s: %w", v.Key, err))
+				return
+			}
+			run.deleted.Add(1)
+		})
+	}
+
+	keyMarker, versionMarker := "", ""
+	for page := 0; ; page++ {
+		listing, err := vgwService.ListObjectVersions(ctx, bucket, keyMarker, versionMarker)
+		if err != nil {
+			if page == 0 && isNotImplementedError(err) {
+				return emptyObjects(ctx, vgwService, bucket, deleteAll)
+			}
+			return err
+		}
+		deleteAll(listing.Versions)
+		if !listing.IsTruncated {
+			return nil
+		}
+		keyMarker, versionMarker = listing.NextKeyMarker, listing.NextVersionIDMarker
+	}
+}
+
+// emptyObjects deletes all current objects via ListObjectsV2 pages.
+func emptyObjects(ctx context.Context, vgwService *VersityGWService, bucket string, deleteAll func([]ObjectVersion)) error {
+	token := ""
+	for {
+		listing, err := vgwService.ListObjects(ctx, bucket, "", "", token, 1000)
+		if err != nil {
+			return err
+		}
+		versions := make([]ObjectVersion, len(listing.Objects))
+		for i, obj := range listing.Objects {
+			versions[i] = ObjectVersion{Key: obj.Key}
+		}
+		deleteAll(versions)
+		if !listing.IsTruncated || listing.NextContinuationToken == "" {
+			return nil
+		}
+		token = listing.NextContinuationToken
+	}
+}
+
+// ForceDeleteBucket empties a bucket and then deletes it (ZFS first, then API).
+// The bucket is only deleted if every object and upload was removed.
+// Returns the deletion method ("zfs" or "api") and the emptying summary.
+func ForceDeleteBucket(ctx context.Context, cfg *config.Config, name string, opts EmptyOptions) (string, *EmptyResult, error) {
+	result, err := EmptyBucket(ctx, cfg, name, opts)
+	if err != nil {
+		return "", nil, fmt.Errorf("emptying bucket: %w", err)
+	}
+	if result.Failed > 0 {
+		return "", result, fmt.Errorf("%d item(s) could not be removed; bucket kept", result.Failed)
+	}
+
+	if opts.Progress != nil {
+		opts.Progress(EmptyProgress{Phase: EmptyPhaseBucket, UploadsAborted: result.UploadsAborted, Deleted: result.Deleted})
+	}
+	if opts.DeleteLock != nil {
+		opts.DeleteLock.Lock()
+		defer opts.DeleteLock.Unlock()
+	}
+	via, err := DeleteBucketWithFallback(ctx, cfg, name)
+	if err != nil {
+		return "", result, err
+	}
+	return via, result, nil
+}
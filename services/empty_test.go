@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+func TestParseVersionListing(t *testing.T) {
+	body := `<ListVersionsResult>
+  <IsTruncated>false</IsTruncated>
+  <Version><Key>a.txt</Key><VersionId>v2</VersionId><IsLatest>true</IsLatest><Size>3</Size></Version>
+  <Version><Key>a.txt</Key><VersionId>v1</VersionId><IsLatest>false</IsLatest><Size>2</Size></Version>
+  <DeleteMarker><Key>b.txt</Key><VersionId>m1</VersionId><IsLatest>true</IsLatest></DeleteMarker>
+</ListVersionsResult>`
+
+	listing, err := parseVersionListing([]byte(body))
+	if err != nil {
+		t.Fatalf("parseVersionListing() error = %v", err)
+	}
+	if len(listing.Versions) != 3 {
+		t.Fatalf("got %d versions, want 3", len(listing.Versions))
+	}
+	if marker := listing.Versions[2]; !marker.IsDeleteMarker || marker.Key != "b.txt" || marker.VersionID != "m1" {
+		t.Fatalf("delete marker = %+v", marker)
+	}
+	if listing.Versions[0].IsDeleteMarker {
+		t.Fatal("version parsed as delete marker")
+	}
+}
+
+func TestEmptyBucketFallsBackOnlyWhenVersionsUnsupported(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantErr     bool
+		wantDeleted int64
+	}{
+		{"not implemented", http.StatusNotImplemented, "", false, 2},
+		{"NotImplemented code", http.StatusBadRequest, "<Error><Code>NotImplemented</Code></Error>", false, 2},
+		{"server error", http.StatusInternalServerError, "<Error><Code>InternalError</Code></Error>", true, 0},
+		{"access denied", http.StatusForbidden, "<Error><Code>AccessDenied</Code></Error>", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query := r.URL.Query()
+				switch {
+				case r.Method == http.MethodDelete:
+					w.WriteHeader(http.StatusNoContent)
+				case query.Has("uploads"):
+					w.Write([]byte("<ListMultipartUploadsResult></ListMultipartUploadsResult>"))
+				case query.Has("versions"):
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.body))
+				default:
+					w.Write([]byte("<ListBucketResult><Contents><Key>a</Key></Contents><Contents><Key>b</Key></Contents></ListBucketResult>"))
+				}
+			}))
+			defer gateway.Close()
+			cfg := &config.Config{EndpointURL: gateway.URL, Region: "local", AdminAccess: "admin", AdminSecret: "secret", MaxAttempts: 1}
+
+			result, err := EmptyBucket(context.Background(), cfg, "data", EmptyOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EmptyBucket() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && result.Deleted != tt.wantDeleted {
+				t.Errorf("deleted = %d, want %d", result.Deleted, tt.wantDeleted)
+			}
+		})
+	}
+}
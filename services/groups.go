@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+// groupsFile stores the groups in the data directory.
+const groupsFile = "groups.json"
+
+// groupSidPrefix starts the Sid of the bucket policy statement compiled for a
+// group; the group name follows.
+const groupSidPrefix = managedSidPrefix + "Group"
+
+// Permissions a group can be granted on a bucket.
+const (
+	PermissionRead      = "read"
+	PermissionReadWrite = "readwrite"
+)
+
+// bucketReadActions are the actions granted with PermissionRead.
+var bucketReadActions = []string{
+	"s3:GetBucketLocation",
+	"s3:GetObject",
+	"s3:ListBucket",
+}
+
+// groupNamePattern restricts group names to characters that are safe in a policy Sid.
+var groupNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,62}$`)
+
+// groupsState is the on-disk format of groupsFile.
+type groupsState struct {
+	Groups map[string]models.Group `json:"groups"`
+}
+
+func loadGroups(cfg *config.Config) (groupsState, error) {
+	state := groupsState{}
+	if err := readStateFile(cfg.DataDir, groupsFile, &state); err != nil {
+		return state, err
+	}
+	if state.Groups == nil {
+		state.Groups = map[string]models.Group{}
+	}
+	return state, nil
+}
+
+// ListGroups returns all groups sorted by name.
+func ListGroups(cfg *config.Config) ([]models.Group, error) {
+	state, err := loadGroups(cfg)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]models.Group, 0, len(state.Groups))
+	for _, group := range state.Groups {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	return groups, nil
+}
+
+// GetGroup returns a group by name.
+func GetGroup(cfg *config.Config, name string) (*models.Group, error) {
+	state, err := loadGroups(cfg)
+	if err != nil {
+		return nil, err
+	}
+	group, ok := state.Groups[name]
+	if !ok {
+		return nil, fmt.Errorf("group not found: %s", name)
+	}
+	return &group, nil
+}
+
+// GroupsOf returns the names of the groups access is a member of.
+func GroupsOf(cfg *config.Config, access string) ([]string, error) {
+	groups, err := ListGroups(cfg)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, group := range groups {
+		if containsString(group.Members, access) {
+			names = append(names, group.Name)
+		}
+	}
+	return names, nil
+}
+
+// CreateGroup creates an empty group.
+func CreateGroup(cfg *config.Config, name, description string) (*models.Group, error) {
+	if !groupNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("group name must be 1-63 letters, digits, '-' or '_', starting with a letter or digit")
+	}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := loadGroups(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := state.Groups[name]; ok {
+		return nil, fmt.Errorf("group already exists: %s", name)
+	}
+
+	group := models.Group{Name: name, Description: description, Members: []string{}, Buckets: []models.GroupGrant{}}
+	state.Groups[name] = group
+	if err := writeStateFile(cfg.DataDir, groupsFile, state); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// DeleteGroup removes the group's statements from its buckets and deletes it.
+func DeleteGroup(ctx context.Context, cfg *config.Config, name string) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := loadGroups(cfg)
+	if err != nil {
+		return err
+	}
+	group, ok := state.Groups[name]
+	if !ok {
+		return fmt.Errorf("group not found: %s", name)
+	}
+
+	if err := syncGroupBuckets(ctx, NewVersityGWService(cfg), models.Group{Name: name}, grantedBuckets(group)); err != nil {
+		return err
+	}
+	delete(state.Groups, name)
+	return writeStateFile(cfg.DataDir, groupsFile, state)
+}
+
+// AddGroupMember adds a user to a group, giving them the group's bucket access.
+func AddGroupMember(ctx context.Context, cfg *config.Config, name, access string) (*models.Group, error) {
+	if _, err := NewUserService(cfg).GetUser(access); err != nil {
+		return nil, err
+	}
+	return updateGroup(ctx, cfg, name, func(group *models.Group) error {
+		if containsString(group.Members, access) {
+			return fmt.Errorf("%s is already a member of %s", access, name)
+		}
+		group.Members = append(group.Members, access)
+		sort.Strings(group.Members)
+		return nil
+	})
+}
+
+// RemoveGroupMember removes a user from a group and its bucket policies.
+func RemoveGroupMember(ctx context.Context, cfg *config.Config, name, access string) (*models.Group, error) {
+	return updateGroup(ctx, cfg, name, func(group *models.Group) error {
+		if !containsString(group.Members, access) {
+			return fmt.Errorf("%s is not a member of %s", access, name)
+		}
+		members := group.Members[:0]
+		for _, member := range group.Members {
+			if member != access {
+				members = append(members, member)
+			}
+		}
+		group.Members = members
+		return nil
+	})
+}
+
+// leaveGroups removes access from every group it is a member of.
+func leaveGroups(ctx context.Context, cfg *config.Config, access string) error {
+	groups, err := GroupsOf(cfg, access)
+	if err != nil {
+		return err
+	}
+	for _, name := range groups {
+		if _, err := RemoveGroupMember(ctx, cfg, name, access); err != nil {
+			return fmt.Errorf("failed to remove %s from group %s: %w", access, name, err)
+		}
+	}
+	return nil
+}
+
+// GrantGroupBucket gives a group read or read/write access to a bucket,
+// replacing an existing grant on the same bucket.
+func GrantGroupBucket(ctx context.Context, cfg *config.Config, name, bucket, permission string) (*models.Group, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("bucket name is required")
+	}
+	if permission == "" {
+		permission = PermissionReadWrite
+	}
+	if permission != PermissionRead && permission != PermissionReadWrite {
+		return nil, fmt.Errorf("permission must be %q or %q", PermissionRead, PermissionReadWrite)
+	}
+	return updateGroup(ctx, cfg, name, func(group *models.Group) error {
+		for i, grant := range group.Buckets {
+			if grant.Bucket == bucket {
+				group.Buckets[i].Permission = permission
+				return nil
+			}
+		}
+		group.Buckets = append(group.Buckets, models.GroupGrant{Bucket: bucket, Permission: permission})
+		sort.Slice(group.Buckets, func(i, j int) bool {
+			return group.Buckets[i].Bucket < group.Buckets[j].Bucket
+		})
+		return nil
+	})
+}
+
+// RevokeGroupBucket removes a group's access to a bucket.
+func RevokeGroupBucket(ctx context.Context, cfg *config.Config, name, bucket string) (*models.Group, error) {
+	return updateGroup(ctx, cfg, name, func(group *models.Group) error {
+		grants := group.Buckets[:0]
+		for _, grant := range group.Buckets {
+			if grant.Bucket != bucket {
+				grants = append(grants, grant)
+			}
+		}
+		if len(grants) == len(group.Buckets) {
+			return fmt.Errorf("group %s has no grant on bucket %s", name, bucket)
+		}
+		group.Buckets = grants
+		return nil
+	})
+}
+
+// SyncGroup re-applies a group's statements to all of its buckets, e.g. after
+// a bucket policy was replaced by hand. Returns the buckets that were updated.
+func SyncGroup(ctx context.Context, cfg *config.Config, name string) ([]string, error) {
+	group, err := GetGroup(cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	buckets := grantedBuckets(*group)
+	if err := syncGroupBuckets(ctx, NewVersityGWService(cfg), *group, buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// updateGroup applies change to a group, compiles the result into the bucket
+// policies of every bucket the group had or now has access to, and saves it.
+// If a policy cannot be updated, the previous state is re-applied and kept.
+func updateGroup(ctx context.Context, cfg *config.Config, name string, change func(group *models.Group) error) (*models.Group, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := loadGroups(cfg)
+	if err != nil {
+		return nil, err
+	}
+	previous, ok := state.Groups[name]
+	if !ok {
+		return nil, fmt.Errorf("group not found: %s", name)
+	}
+
+	group := previous
+	group.Members = append([]string{}, previous.Members...)
+	group.Buckets = append([]models.GroupGrant{}, previous.Buckets...)
+	if err := change(&group); err != nil {
+		return nil, err
+	}
+
+	buckets := grantedBuckets(previous)
+	for _, bucket := range grantedBuckets(group) {
+		if !containsString(buckets, bucket) {
+			buckets = append(buckets, bucket)
+		}
+	}
+
+	vgwService := NewVersityGWService(cfg)
+	if err := syncGroupBuckets(ctx, vgwService, group, buckets); err != nil {
+		_ = syncGroupBuckets(context.WithoutCancel(ctx), vgwService, previous, buckets)
+		return nil, err
+	}
+
+	state.Groups[name] = group
+	if err := writeStateFile(cfg.DataDir, groupsFile, state); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// syncGroupBuckets rewrites the group's statement on each bucket: members get
+// the granted permission, and the statement is removed where the group has no
+// grant or no members.
+func syncGroupBuckets(ctx context.Context, vgwService *VersityGWService, group models.Group, buckets []string) error {
+	sid := groupSidPrefix + group.Name
+	drop := func(s string) bool { return s == sid }
+
+	for _, bucket := range buckets {
+		var add []policyStatement
+		if statement, ok := groupStatement(group, bucket); ok {
+			add = []policyStatement{statement}
+		}
+		if err := vgwService.rewriteBucketPolicy(ctx, bucket, drop, add); err != nil {
+			return fmt.Errorf("bucket %s: %w", bucket, err)
+		}
+	}
+	return nil
+}
+
+// groupStatement compiles a group's grant on bucket into a policy statement.
+func groupStatement(group models.Group, bucket string) (policyStatement, bool) {
+	if len(group.Members) == 0 {
+		return policyStatement{}, false
+	}
+	for _, grant := range group.Buckets {
+		if grant.Bucket != bucket {
+			continue
+		}
+		statement := bucketGrantStatement(groupSidPrefix+group.Name, bucket, group.Members)
+		if grant.Permission == PermissionRead {
+			statement.Action = bucketReadActions
+		}
+		return statement, true
+	}
+	return policyStatement{}, false
+}
+
+func grantedBuckets(group models.Group) []string {
+	buckets := make([]string, 0, len(group.Buckets))
+	for _, grant := range group.Buckets {
+		buckets = append(buckets, grant.Bucket)
+	}
+	return buckets
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+func TestGroupStatement(t *testing.T) {
+	group := models.Group{
+		Name:    "dev-team",
+		Members: []string{"alice", "bob"},
+		Buckets: []models.GroupGrant{
+			{Bucket: "logs", Permission: PermissionRead},
+			{Bucket: "builds", Permission: PermissionReadWrite},
+		},
+	}
+
+	read, ok := groupStatement(group, "logs")
+	if !ok || read.Sid != "VgwmGroupdev-team" || len(read.Action) != len(bucketReadActions) {
+		t.Errorf("groupStatement(logs) = %+v, %v", read, ok)
+	}
+	if write, ok := groupStatement(group, "builds"); !ok || len(write.Action) != len(bucketAccessActions) {
+		t.Errorf("groupStatement(builds) = %+v, %v", write, ok)
+	}
+	if _, ok := groupStatement(group, "other"); ok {
+		t.Error("groupStatement(other) returned a statement for a bucket without grant")
+	}
+	group.Members = nil
+	if _, ok := groupStatement(group, "logs"); ok {
+		t.Error("groupStatement() returned a statement for a group without members")
+	}
+}
+
+func TestDeleteUserLeavesGroups(t *testing.T) {
+	gw, cfg := newFakeGateway(t, map[string]string{"shared": "admin", "private": "admin"})
+	saveServiceAccounts(t, cfg, models.ServiceAccount{Access: "alice-ci", Parent: "alice"})
+	state := groupsState{Groups: map[string]models.Group{
+		"dev":  {Name: "dev", Members: []string{"alice", "alice-ci", "bob"}, Buckets: []models.GroupGrant{{Bucket: "shared", Permission: PermissionReadWrite}}},
+		"solo": {Name: "solo", Members: []string{"alice"}, Buckets: []models.GroupGrant{{Bucket: "private", Permission: PermissionRead}}},
+	}}
+	if err := writeStateFile(cfg.DataDir, groupsFile, state); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"dev", "solo"} {
+		if _, err := SyncGroup(context.Background(), cfg, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := DeleteUser(context.Background(), cfg, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if got := gw.principals(t, "shared", groupSidPrefix+"dev"); strings.Join(got, ",") != "bob" {
+		t.Errorf("dev statement principals = %v, want [bob]", got)
+	}
+	if got := gw.principals(t, "private", groupSidPrefix+"solo"); got != nil {
+		t.Errorf("solo statement principals = %v, want no statement", got)
+	}
+	for name, want := range map[string]string{"dev": "bob", "solo": ""} {
+		group, err := GetGroup(cfg, name)
+		if err != nil || strings.Join(group.Members, ",") != want {
+			t.Errorf("group %s members = %+v, %v, want %q", name, group, err, want)
+		}
+	}
+}
@@ -0,0 +1,455 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+const (
+	// jwksRefreshInterval is how long keys fetched from a jwksURL are used
+	// before they are fetched again.
+	jwksRefreshInterval = time.Hour
+	// jwksMinRefetch limits fetches triggered by tokens with unknown key IDs.
+	jwksMinRefetch = time.Minute
+	// jwksFetchTimeout bounds a JWKS fetch. Fetches are shared by concurrent
+	// requests, so they do not use the context of the request that started
+	// them.
+	jwksFetchTimeout = 10 * time.Second
+	// maxJWKSSize bounds the JWKS document read from a URL.
+	maxJWKSSize = 1 << 20
+	// minRSAKeyBits is the smallest RSA key accepted from a JWKS.
+	minRSAKeyBits = 2048
+)
+
+// jwtHashes maps the supported signature algorithms to their hash. EdDSA
+// signs the message itself.
+var jwtHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// jwtCurves maps the ECDSA algorithms to the curve their keys must use.
+var jwtCurves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(), "ES384": elliptic.P384(), "ES512": elliptic.P521(),
+}
+
+// JWTIdentity is the caller described by a verified JWT.
+type JWTIdentity struct {
+	Name string
+	config.Permissions
+}
+
+// JWTVerifier validates JWT bearer tokens against the keys of a JWKS and maps
+// their claims to API permissions. It is safe for concurrent use.
+type JWTVerifier struct {
+	cfg    config.JWTConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu          sync.Mutex
+	keys        []jwtKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	// fetching is closed when the fetch in progress is done; nil if there
+	// is none. fetchErr is the error of the last fetch.
+	fetching chan struct{}
+	fetchErr error
+}
+
+// jwtKey is a public key from a JWKS.
+type jwtKey struct {
+	kid string
+	alg string // optional; restricts the key to one algorithm
+	key crypto.PublicKey
+}
+
+// jwk is one key of a JWKS document (RFC 7517).
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// NewJWTVerifier returns a verifier for cfg. A jwksFile is read immediately; a
+// jwksURL is fetched on first use.
+func NewJWTVerifier(cfg config.JWTConfig) (*JWTVerifier, error) {
+	v := &JWTVerifier{
+		cfg:    cfg.WithDefaults(),
+		client: &http.Client{Timeout: jwksFetchTimeout},
+		now:    time.Now,
+	}
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwksFile: %w", err)
+		}
+		if v.keys, err = parseJWKS(data); err != nil {
+			return nil, fmt.Errorf("jwksFile %s: %w", cfg.JWKSFile, err)
+		}
+	}
+	return v, nil
+}
+
+// Verify checks the signature, issuer, audience and validity period of a
+// compact JWT and returns the identity its claims describe.
+func (v *JWTVerifier) Verify(ctx context.Context, raw string) (*JWTIdentity, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid claims: %w", err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return v.identity(claims)
+}
+
+// checkClaims validates iss, aud, exp and nbf.
+func (v *JWTVerifier) checkClaims(claims map[string]any) error {
+	if iss, _ := claims["iss"].(string); iss != v.cfg.Issuer {
+		return fmt.Errorf("unexpected issuer %q", iss)
+	}
+	if !slices.Contains(claimStrings(claims["aud"]), v.cfg.Audience) {
+		return fmt.Errorf("token is not issued for audience %q", v.cfg.Audience)
+	}
+
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("token has no exp claim")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(v.cfg.Leeway)) {
+		return fmt.Errorf("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.cfg.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("token not valid yet")
+	}
+	return nil
+}
+
+// identity maps the configured claims to a name and permissions. Values of
+// the scopes claim that are not scopes are ignored.
+func (v *JWTVerifier) identity(claims map[string]any) (*JWTIdentity, error) {
+	name, _ := claims[v.cfg.NameClaim].(string)
+	if name == "" {
+		name, _ = claims["sub"].(string)
+	}
+
+	identity := &JWTIdentity{Name: name}
+	for _, scope := range claimStrings(claims[v.cfg.ScopesClaim]) {
+		if slices.Contains(config.AllScopes, scope) {
+			identity.Scopes = append(identity.Scopes, scope)
+		}
+	}
+	identity.Buckets = claimStrings(claims[v.cfg.BucketsClaim])
+	identity.Users = claimStrings(claims[v.cfg.UsersClaim])
+	if err := identity.Permissions.Validate(); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// claimStrings returns a claim that is a space-separated string or a list of
+// strings as a slice.
+func claimStrings(value any) []string {
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []any:
+		values := []string{}
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func decodeJWTSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// verifyJWTSignature checks the signature of signed with key for alg. Only
+// asymmetric algorithms are supported, so "none" and HMAC tokens are rejected.
+func verifyJWTSignature(alg string, key jwtKey, signed string, signature []byte) error {
+	if key.alg != "" && key.alg != alg {
+		return fmt.Errorf("key %q is for %s, token uses %s", key.kid, key.alg, alg)
+	}
+	if alg == "EdDSA" {
+		pub, ok := key.key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(pub, []byte(signed), signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	}
+
+	hash, ok := jwtHashes[alg]
+	if !ok {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	var valid bool
+	switch pub := key.key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			valid = rsa.VerifyPKCS1v15(pub, hash, digest, signature) == nil
+		case "PS":
+			valid = rsa.VerifyPSS(pub, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if jwtCurves[alg] == pub.Curve && len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+			valid = ecdsa.Verify(pub, digest, r, s)
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// key returns the key with the given ID, or the only key if the token names
+// none. Keys from a jwksURL are fetched when missing, stale or unknown; the
+// fetch runs without holding v.mu and is shared by concurrent callers, which
+// stop waiting for it when ctx is done.
+func (v *JWTVerifier) key(ctx context.Context, kid string) (jwtKey, error) {
+	v.mu.Lock()
+	key, found := findJWTKey(v.keys, kid)
+	var done chan struct{}
+	if v.cfg.JWKSURL != "" {
+		now := v.now()
+		stale := now.Sub(v.fetchedAt) > jwksRefreshInterval
+		if (stale || !found) && (v.fetching != nil || v.lastAttempt.IsZero() || now.Sub(v.lastAttempt) >= jwksMinRefetch) {
+			done = v.refresh()
+		}
+	}
+	v.mu.Unlock()
+
+	if done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return jwtKey{}, ctx.Err()
+		}
+		v.mu.Lock()
+		if v.keys == nil {
+			err := v.fetchErr
+			v.mu.Unlock()
+			return jwtKey{}, err
+		}
+		key, found = findJWTKey(v.keys, kid)
+		v.mu.Unlock()
+	}
+	if !found {
+		return jwtKey{}, fmt.Errorf("no key with id %q in the JWKS", kid)
+	}
+	return key, nil
+}
+
+// refresh starts fetching the JWKS unless a fetch is already in progress and
+// returns the channel closed when it is done. v.mu must be held.
+func (v *JWTVerifier) refresh() chan struct{} {
+	if v.fetching != nil {
+		return v.fetching
+	}
+	done := make(chan struct{})
+	v.fetching = done
+	v.lastAttempt = v.now()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+		defer cancel()
+		keys, err := v.fetchJWKS(ctx)
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		switch {
+		case err == nil:
+			v.keys, v.fetchedAt = keys, v.now()
+		case v.keys != nil:
+			slog.Warn("jwks refresh failed, using cached keys", "url", v.cfg.JWKSURL, "error", err)
+		}
+		v.fetchErr = err
+		v.fetching = nil
+		close(done)
+	}()
+	return done
+}
+
+func findJWTKey(keys []jwtKey, kid string) (jwtKey, bool) {
+	if kid == "" {
+		if len(keys) == 1 {
+			return keys[0], true
+		}
+		return jwtKey{}, false
+	}
+	for _, key := range keys {
+		if key.kid == kid {
+			return key, true
+		}
+	}
+	return jwtKey{}, false
+}
+
+func (v *JWTVerifier) fetchJWKS(ctx context.Context) ([]jwtKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	return parseJWKS(data)
+}
+
+// parseJWKS returns the signing keys of a JWKS document. Keys of unsupported
+// types or for encryption are skipped.
+func parseJWKS(data []byte) ([]jwtKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := []jwtKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		if pub != nil {
+			keys = append(keys, jwtKey{kid: k.Kid, alg: k.Alg, key: pub})
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+// publicKey decodes an RSA, EC or Ed25519 key; other key types return nil.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid n: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return nil, fmt.Errorf("invalid e")
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key has %d bits, want at least %d", pub.N.BitLen(), minRSAKeyBits)
+		}
+		return pub, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("invalid x or y")
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		// ECDH fails for points that are not on the curve.
+		if _, err := pub.ECDH(); err != nil {
+			return nil, fmt.Errorf("invalid EC point: %w", err)
+		}
+		return pub, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid x")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// jwtTestKeys are the key pairs published by their jwks.
+type jwtTestKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+	ed  ed25519.PrivateKey
+}
+
+func newJWTTestKeys(t *testing.T) jwtTestKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwtTestKeys{rsa: rsaKey, ec: ecKey, ed: edKey}
+}
+
+func (k jwtTestKeys) jwks() []byte {
+	b64 := base64.RawURLEncoding.EncodeToString
+	ecPub := k.ec.PublicKey
+	data, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "alg": "ES256", "crv": "P-256", "x": b64(ecPub.X.FillBytes(make([]byte, 32))), "y": b64(ecPub.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(k.ed.Public().(ed25519.PublicKey))},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": b64(k.rsa.N.Bytes()), "e": "AQAB"},
+	}})
+	return data
+}
+
+// sign returns a compact JWT signed with alg by the matching test key.
+func (k jwtTestKeys) sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+
+	var signature []byte
+	var err error
+	if alg == "EdDSA" {
+		signature = ed25519.Sign(k.ed, []byte(signed))
+	} else if hash, ok := jwtHashes[alg]; ok {
+		h := hash.New()
+		h.Write([]byte(signed))
+		digest := h.Sum(nil)
+		switch alg[:2] {
+		case "RS":
+			signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, hash, digest)
+		case "PS":
+			signature, err = rsa.SignPSS(rand.Reader, k.rsa, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		case "ES":
+			var r, s *big.Int
+			r, s, err = ecdsa.Sign(rand.Reader, k.ec, digest)
+			if err == nil {
+				signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+			}
+		}
+	} else {
+		signature = []byte("whatever")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + b64(signature)
+}
+
+func testJWTConfig(t *testing.T, keys jwtTestKeys) config.JWTConfig {
+	t.Helper()
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, keys.jwks(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return config.JWTConfig{Issuer: "https://sso.example.com", Audience: "vgw-manager", JWKSFile: jwksFile}
+}
+
+func TestJWTVerifierVerify(t *testing.T) {
+	keys := newJWTTestKeys(t)
+	verifier, err := NewJWTVerifier(testJWTConfig(t, keys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	verifier.now = func() time.Time { return now }
+
+	claims := func(changes map[string]any) map[string]any {
+		c := map[string]any{
+			"iss":   "https://sso.example.com",
+			"aud":   []string{"portal", "vgw-manager"},
+			"sub":   "alice",
+			"exp":   now.Add(5 * time.Minute).Unix(),
+			"scope": "openid buckets:read",
+		}
+		for key, value := range changes {
+			if value == nil {
+				delete(c, key)
+			} else {
+				c[key] = value
+			}
+		}
+		return c
+	}
+	tampered := keys.sign(t, "RS256", "rsa", claims(nil))
+	tampered = tampered[:len(tampered)-4] + "AAAA"
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"RS256", keys.sign(t, "RS256", "rsa", claims(nil)), ""},
+		{"PS384", keys.sign(t, "PS384", "rsa", claims(nil)), ""},
+		{"ES256", keys.sign(t, "ES256", "ec", claims(nil)), ""},
+		{"EdDSA", keys.sign(t, "EdDSA", "ed", claims(nil)), ""},
+		{"audience as string", keys.sign(t, "RS256", "rsa", claims(map[string]any{"aud": "vgw-manager"})), ""},
+		{"expired within leeway", keys.sign(t, "RS256", "rsa", claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})), ""},
+		{"expired", keys.sign(t, "RS256", "rsa", claims(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()})), "expired"},
+		{"no exp", keys.sign(t, "RS256", "rsa", claims(map[string]any{"exp": nil})), "no exp"},
+		{"not valid yet", keys.sign(t, "RS256", "rsa", claims(map[string]any{"nbf": now.Add(2 * time.Minute).Unix()})), "not valid yet"},
+		{"wrong issuer", keys.sign(t, "RS256", "rsa", claims(map[string]any{"iss": "https://evil.example.com"})), "issuer"},
+		{"wrong audience", keys.sign(t, "RS256", "rsa", claims(map[string]any{"aud": "portal"})), "audience"},
+		{"tampered signature", tampered, "invalid signature"},
+		{"alg none", keys.sign(t, "none", "rsa", claims(nil)), "unsupported algorithm"},
+		{"HMAC", keys.sign(t, "HS256", "rsa", claims(nil)), "unsupported algorithm"},
+		{"key restricted to another alg", keys.sign(t, "RS256", "ec", claims(nil)), "is for ES256"},
+		{"wrong key type", keys.sign(t, "ES256", "rsa", claims(nil)), "invalid signature"},
+		{"unknown kid", keys.sign(t, "RS256", "other", claims(nil)), "no key"},
+		{"encryption key", keys.sign(t, "RS256", "enc", claims(nil)), "no key"},
+		{"no kid with several keys", keys.sign(t, "RS256", "", claims(nil)), "no key"},
+		{"malformed", "not-a-jwt", "malformed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTVerifierIdentity(t *testing.T) {
+	keys := newJWTTestKeys(t)
+	cfg := testJWTConfig(t, keys)
+	cfg.NameClaim = "preferred_username"
+	verifier, err := NewJWTVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	token := keys.sign(t, "ES256", "ec", map[string]any{
+		"iss": "https://sso.example.com", "aud": "vgw-manager", "exp": exp,
+		"sub": "0b5f", "preferred_username": "alice",
+		"scope":       "openid profile buckets:write provision",
+		"vgw_buckets": []string{"cust-*"},
+		"vgw_users":   "cust-* ops",
+	})
+	identity, err := verifier.Verify(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &JWTIdentity{Name: "alice", Permissions: config.Permissions{
+		Scopes:  []string{config.ScopeBucketsWrite, config.ScopeProvision},
+		Buckets: []string{"cust-*"},
+		Users:   []string{"cust-*", "ops"},
+	}}
+	if !reflect.DeepEqual(identity, want) {
+		t.Errorf("Verify() = %+v, want %+v", identity, want)
+	}
+
+	token = keys.sign(t, "ES256", "ec", map[string]any{
+		"iss": "https://sso.example.com", "aud": "vgw-manager", "exp": exp, "sub": "bob",
+		"vgw_buckets": []string{"[unterminated"},
+	})
+	if _, err := verifier.Verify(context.Background(), token); err == nil {
+		t.Error("Verify() accepted an invalid bucket pattern")
+	}
+}
+
+func TestJWTVerifierFetchesJWKS(t *testing.T) {
+	keys := newJWTTestKeys(t)
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write(keys.jwks())
+	}))
+	defer server.Close()
+
+	verifier, err := NewJWTVerifier(config.JWTConfig{Issuer: "iss", Audience: "aud", JWKSURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	verifier.now = func() time.Time { return now }
+	claims := map[string]any{"iss": "iss", "aud": "aud", "sub": "alice", "exp": now.Add(24 * time.Hour).Unix()}
+
+	verify := func(kid string) error {
+		_, err := verifier.Verify(context.Background(), keys.sign(t, "RS256", kid, claims))
+		return err
+	}
+	if err := verify("rsa"); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if err := verify("rsa"); err != nil || fetches.Load() != 1 {
+		t.Fatalf("second Verify() error = %v, fetches = %d, want cached keys", err, fetches.Load())
+	}
+
+	// Unknown key IDs refetch the JWKS, at most once per jwksMinRefetch.
+	now = now.Add(2 * jwksMinRefetch)
+	if err := verify("rotated"); err == nil || fetches.Load() != 2 {
+		t.Fatalf("Verify(unknown kid) error = %v, fetches = %d, want a refetch", err, fetches.Load())
+	}
+	if err := verify("rotated"); err == nil || fetches.Load() != 2 {
+		t.Fatalf("Verify(unknown kid) error = %v, fetches = %d, want no refetch", err, fetches.Load())
+	}
+
+	// Stale keys are refetched; a failing refetch keeps the cached keys.
+	server.Close()
+	now = now.Add(2 * jwksRefreshInterval)
+	if err := verify("rsa"); err != nil {
+		t.Errorf("Verify() with unreachable JWKS error = %v, want cached keys", err)
+	}
+}
+
+func TestJWTVerifierSharesJWKSFetch(t *testing.T) {
+	keys := newJWTTestKeys(t)
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		w.Write(keys.jwks())
+	}))
+	defer server.Close()
+
+	verifier, err := NewJWTVerifier(config.JWTConfig{Issuer: "iss", Audience: "aud", JWKSURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := keys.sign(t, "RS256", "rsa", map[string]any{"iss": "iss", "aud": "aud", "sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix()})
+
+	// The caller that starts the fetch gives up; the fetch goes on.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := verifier.Verify(ctx, token); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Verify() with cancelled context error = %v, want context.Canceled", err)
+	}
+
+	results := make(chan error, 3)
+	for range 3 {
+		go func() {
+			_, err := verifier.Verify(context.Background(), token)
+			results <- err
+		}()
+	}
+	close(release)
+	for range 3 {
+		if err := <-results; err != nil {
+			t.Errorf("Verify() error = %v", err)
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+}
+
+func TestParseJWKSRejectsWeakKeys(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+	tests := map[string]string{
+		"small RSA key":       `{"keys":[{"kty":"RSA","kid":"a","n":"` + b64(small.N.Bytes()) + `","e":"AQAB"}]}`,
+		"point off the curve": `{"keys":[{"kty":"EC","kid":"a","crv":"P-256","x":"` + b64(make([]byte, 32)) + `","y":"` + b64([]byte{1}) + `"}]}`,
+		"no signing keys":     `{"keys":[{"kty":"oct","kid":"a","k":"c2VjcmV0"}]}`,
+		"not JSON":            `keys`,
+	}
+	for name, jwks := range tests {
+		if _, err := parseJWKS([]byte(jwks)); err == nil {
+			t.Errorf("%s: parseJWKS() accepted it", name)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// LifecycleRule is a simplified S3 lifecycle rule covering object expiration
+// and cleanup of incomplete multipart uploads under a prefix.
+type LifecycleRule struct {
+	ID                           string `json:"id"`
+	Prefix                       string `json:"prefix"`
+	Enabled                      bool   `json:"enabled"`
+	ExpirationDays               int    `json:"expirationDays,omitempty"`
+	AbortIncompleteMultipartDays int    `json:"abortIncompleteMultipartDays,omitempty"`
+	// Unmanaged is set on rules using settings this type cannot represent
+	// (e.g. transitions, noncurrent version expiration, date-based expiration
+	// or tag filters). They are kept unchanged when other rules are edited.
+	Unmanaged bool `json:"unmanaged,omitempty"`
+
+	// raw is the XML of an unmanaged rule as read from the gateway.
+	raw string
+}
+
+// UnmarshalJSON decodes a rule, defaulting Enabled to true when the field is
+// absent.
+func (r *LifecycleRule) UnmarshalJSON(data []byte) error {
+	type plain LifecycleRule
+	rule := plain{Enabled: true}
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return err
+	}
+	*r = LifecycleRule(rule)
+	return nil
+}
+
+// lifecycleConfiguration is the S3 Put/GetBucketLifecycleConfiguration document.
+type lifecycleConfiguration struct {
+	XMLName xml.Name           `xml:"LifecycleConfiguration"`
+	Rules   []lifecycleRuleXML `xml:"Rule"`
+}
+
+type lifecycleRuleXML struct {
+	ID     string           `xml:"ID,omitempty"`
+	Filter *lifecycleFilter `xml:"Filter,omitempty"`
+	// Prefix is the legacy (pre-Filter) location of the rule prefix; only read, never written.
+	Prefix                         string                   `xml:"Prefix,omitempty"`
+	Status                         string                   `xml:"Status,omitempty"`
+	Expiration                     *lifecycleExpiration     `xml:"Expiration,omitempty"`
+	AbortIncompleteMultipartUpload *lifecycleAbortMultipart `xml:"AbortIncompleteMultipartUpload,omitempty"`
+	// Other collects the elements LifecycleRule cannot represent.
+	Other []unknownXMLElement `xml:",any"`
+	// Raw is the rule as read; unmanaged rules are written back from it.
+	Raw string `xml:",innerxml"`
+}
+
+type lifecycleFilter struct {
+	Prefix string              `xml:"Prefix"`
+	Other  []unknownXMLElement `xml:",any"`
+}
+
+type lifecycleExpiration struct {
+	Days  int                 `xml:"Days"`
+	Other []unknownXMLElement `xml:",any"`
+}
+
+type lifecycleAbortMultipart struct {
+	DaysAfterInitiation int                 `xml:"DaysAfterInitiation"`
+	Other               []unknownXMLElement `xml:",any"`
+}
+
+// unknownXMLElement records the name of an element that was not expected.
+type unknownXMLElement struct {
+	XMLName xml.Name
+}
+
+// unmanaged reports whether the rule has elements LifecycleRule cannot represent.
+func (r lifecycleRuleXML) unmanaged() bool {
+	return len(r.Other) > 0 ||
+		(r.Filter != nil && len(r.Filter.Other) > 0) ||
+		(r.Expiration != nil && len(r.Expiration.Other) > 0) ||
+		(r.AbortIncompleteMultipartUpload != nil && len(r.AbortIncompleteMultipartUpload.Other) > 0)
+}
+
+// Validate checks that a lifecycle rule is complete enough to be applied.
+func (r LifecycleRule) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("lifecycle rule id is required")
+	}
+	if r.Unmanaged {
+		return nil
+	}
+	if r.ExpirationDays < 0 || r.AbortIncompleteMultipartDays < 0 {
+		return fmt.Errorf("lifecycle rule %q: days must not be negative", r.ID)
+	}
+	if r.ExpirationDays == 0 && r.AbortIncompleteMultipartDays == 0 {
+		return fmt.Errorf("lifecycle rule %q: set expiration days and/or abort incomplete multipart days", r.ID)
+	}
+	return nil
+}
+
+// Status returns the S3 status string of the rule ("Enabled" or "Disabled").
+func (r LifecycleRule) Status() string {
+	if r.Enabled {
+		return "Enabled"
+	}
+	return "Disabled"
+}
+
+// GetBucketLifecycle retrieves the lifecycle rules of a bucket.
+// A bucket without a lifecycle configuration returns no rules.
+func (s *VersityGWService) GetBucketLifecycle(ctx context.Context, bucket string) ([]LifecycleRule, error) {
+	url := fmt.Sprintf("%s/%s?lifecycle", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	body, err := s.signAndSend(httpReq, []byte{})
+	if err != nil {
+		if isNotFoundError(err) {
+			return []LifecycleRule{}, nil
+		}
+		return nil, err
+	}
+
+	return parseLifecycleConfiguration(body)
+}
+
+// PutBucketLifecycle replaces the lifecycle configuration of a bucket.
+func (s *VersityGWService) PutBucketLifecycle(ctx context.Context, bucket string, rules []LifecycleRule) error {
+	payload, err := marshalLifecycleConfiguration(rules)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/%s?lifecycle", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	setContentMD5(httpReq, payload)
+
+	if _, err := s.signAndSend(httpReq, payload); err != nil {
+		return fmt.Errorf("failed to set bucket lifecycle: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBucketLifecycle removes the lifecycle configuration of a bucket.
+func (s *VersityGWService) DeleteBucketLifecycle(ctx context.Context, bucket string) error {
+	url := fmt.Sprintf("%s/%s?lifecycle", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if _, err := s.signAndSend(httpReq, []byte{}); err != nil {
+		return fmt.Errorf("failed to delete bucket lifecycle: %w", err)
+	}
+
+	return nil
+}
+
+// SetBucketLifecycle validates and applies rules to a bucket. An empty rule set
+// removes the lifecycle configuration. Unmanaged rules are kept as currently
+// configured on the bucket.
+func SetBucketLifecycle(ctx context.Context, cfg *config.Config, bucket string, rules []LifecycleRule) error {
+	seen := make(map[string]bool, len(rules))
+	needsCurrent := false
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		if seen[rule.ID] {
+			return fmt.Errorf("duplicate lifecycle rule id %q", rule.ID)
+		}
+		seen[rule.ID] = true
+		if rule.Unmanaged && rule.raw == "" {
+			needsCurrent = true
+		}
+	}
+
+	vgwService := NewVersityGWService(cfg)
+	if needsCurrent {
+		current, err := vgwService.GetBucketLifecycle(ctx, bucket)
+		if err != nil {
+			return fmt.Errorf("failed to read bucket lifecycle: %w", err)
+		}
+		rules, err = keepUnmanagedRules(rules, current)
+		if err != nil {
+			return err
+		}
+	}
+	if len(rules) == 0 {
+		return vgwService.DeleteBucketLifecycle(ctx, bucket)
+	}
+	return vgwService.PutBucketLifecycle(ctx, bucket, rules)
+}
+
+// PutLifecycleRule adds a rule to a bucket's lifecycle configuration, replacing
+// any existing rule with the same ID.
+func PutLifecycleRule(ctx context.Context, cfg *config.Config, bucket string, rule LifecycleRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+
+	rules, err := NewVersityGWService(cfg).GetBucketLifecycle(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to read bucket lifecycle: %w", err)
+	}
+
+	return SetBucketLifecycle(ctx, cfg, bucket, upsertLifecycleRule(rules, rule))
+}
+
+// RemoveLifecycleRule removes the rule with the given ID from a bucket's lifecycle configuration.
+func RemoveLifecycleRule(ctx context.Context, cfg *config.Config, bucket, id string) error {
+	rules, err := NewVersityGWService(cfg).GetBucketLifecycle(ctx, bucket)
+	if err != nil {
+		return fmt.Errorf("failed to read bucket lifecycle: %w", err)
+	}
+
+	remaining := make([]LifecycleRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.ID != id {
+			remaining = append(remaining, rule)
+		}
+	}
+	if len(remaining) == len(rules) {
+		return fmt.Errorf("lifecycle rule not found: %s", id)
+	}
+
+	return SetBucketLifecycle(ctx, cfg, bucket, remaining)
+}
+
+// keepUnmanagedRules fills in unmanaged rules from the bucket's current rules.
+func keepUnmanagedRules(rules, current []LifecycleRule) ([]LifecycleRule, error) {
+	raw := make(map[string]string, len(current))
+	for _, rule := range current {
+		if rule.Unmanaged {
+			raw[rule.ID] = rule.raw
+		}
+	}
+	kept := make([]LifecycleRule, len(rules))
+	for i, rule := range rules {
+		if rule.Unmanaged && rule.raw == "" {
+			r, ok := raw[rule.ID]
+			if !ok {
+				return nil, fmt.Errorf("lifecycle rule %q is marked unmanaged but is not an unmanaged rule of the bucket", rule.ID)
+			}
+			rule.raw = r
+		}
+		kept[i] = rule
+	}
+	return kept, nil
+}
+
+func upsertLifecycleRule(rules []LifecycleRule, rule LifecycleRule) []LifecycleRule {
+	for i := range rules {
+		if rules[i].ID == rule.ID {
+			rules[i] = rule
+			return rules
+		}
+	}
+	rules = append(rules, rule)
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+	return rules
+}
+
+func parseLifecycleConfiguration(body []byte) ([]LifecycleRule, error) {
+	var doc lifecycleConfiguration
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse bucket lifecycle: %w", err)
+	}
+
+	rules := make([]LifecycleRule, 0, len(doc.Rules))
+	for _, r := range doc.Rules {
+		rule := LifecycleRule{
+			ID:      r.ID,
+			Prefix:  r.Prefix,
+			Enabled: r.Status == "Enabled",
+		}
+		if r.unmanaged() {
+			rule.Unmanaged = true
+			rule.raw = r.Raw
+		}
+		if r.Filter != nil && r.Filter.Prefix != "" {
+			rule.Prefix = r.Filter.Prefix
+		}
+		if r.Expiration != nil {
+			rule.ExpirationDays = r.Expiration.Days
+		}
+		if r.AbortIncompleteMultipartUpload != nil {
+			rule.AbortIncompleteMultipartDays = r.AbortIncompleteMultipartUpload.DaysAfterInitiation
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func marshalLifecycleConfiguration(rules []LifecycleRule) ([]byte, error) {
+	var doc lifecycleConfiguration
+	for _, rule := range rules {
+		if rule.raw != "" {
+			doc.Rules = append(doc.Rules, lifecycleRuleXML{Raw: rule.raw})
+			continue
+		}
+		r := lifecycleRuleXML{
+			ID:     rule.ID,
+			Filter: &lifecycleFilter{Prefix: rule.Prefix},
+			Status: rule.Status(),
+		}
+		if rule.ExpirationDays > 0 {
+			r.Expiration = &lifecycleExpiration{Days: rule.ExpirationDays}
+		}
+		if rule.AbortIncompleteMultipartDays > 0 {
+			r.AbortIncompleteMultipartUpload = &lifecycleAbortMultipart{DaysAfterInitiation: rule.AbortIncompleteMultipartDays}
+		}
+		doc.Rules = append(doc.Rules, r)
+	}
+
+	payload, err := xml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bucket lifecycle: %w", err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLifecycleConfigurationRoundTrip(t *testing.T) {
+	rules := []LifecycleRule{
+		{ID: "expire-logs", Prefix: "logs/", Enabled: true, ExpirationDays: 30},
+		{ID: "abort-mpu", Enabled: false, AbortIncompleteMultipartDays: 7},
+	}
+
+	payload, err := marshalLifecycleConfiguration(rules)
+	if err != nil {
+		t.Fatalf("marshalLifecycleConfiguration() error = %v", err)
+	}
+
+	got, err := parseLifecycleConfiguration(payload)
+	if err != nil {
+		t.Fatalf("parseLifecycleConfiguration() error = %v", err)
+	}
+	if len(got) != len(rules) {
+		t.Fatalf("got %d rules, want %d", len(got), len(rules))
+	}
+	for i := range rules {
+		if got[i] != rules[i] {
+			t.Fatalf("rule %d = %+v, want %+v", i, got[i], rules[i])
+		}
+	}
+}
+
+func TestParseLifecycleConfigurationLegacyPrefix(t *testing.T) {
+	body := `<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Rule><ID>old</ID><Prefix>tmp/</Prefix><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule>
+</LifecycleConfiguration>`
+
+	rules, err := parseLifecycleConfiguration([]byte(body))
+	if err != nil {
+		t.Fatalf("parseLifecycleConfiguration() error = %v", err)
+	}
+	want := LifecycleRule{ID: "old", Prefix: "tmp/", Enabled: true, ExpirationDays: 1}
+	if len(rules) != 1 || rules[0] != want {
+		t.Fatalf("parseLifecycleConfiguration() = %+v, want [%+v]", rules, want)
+	}
+}
+
+func TestLifecycleRuleValidate(t *testing.T) {
+	if err := (LifecycleRule{ExpirationDays: 1}).Validate(); err == nil {
+		t.Fatal("expected error for missing id")
+	}
+	if err := (LifecycleRule{ID: "noop"}).Validate(); err == nil {
+		t.Fatal("expected error for rule without actions")
+	}
+	if err := (LifecycleRule{ID: "neg", ExpirationDays: -1}).Validate(); err == nil {
+		t.Fatal("expected error for negative days")
+	}
+	if err := (LifecycleRule{ID: "ok", AbortIncompleteMultipartDays: 3}).Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
+
+func TestLifecycleUnmanagedRulesKept(t *testing.T) {
+	body := `<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Rule><ID>archive</ID><Filter><Prefix>data/</Prefix></Filter><Status>Enabled</Status><Transition><Days>30</Days><StorageClass>GLACIER</StorageClass></Transition></Rule>
+  <Rule><ID>tagged</ID><Filter><And><Prefix>tmp/</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></And></Filter><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule>
+  <Rule><ID>dated</ID><Filter><Prefix></Prefix></Filter><Status>Disabled</Status><Expiration><Date>2030-01-01T00:00:00Z</Date></Expiration></Rule>
+  <Rule><ID>plain</ID><Filter><Prefix>logs/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>7</Days></Expiration></Rule>
+</LifecycleConfiguration>`
+
+	rules, err := parseLifecycleConfiguration([]byte(body))
+	if err != nil {
+		t.Fatalf("parseLifecycleConfiguration() error = %v", err)
+	}
+	for _, rule := range rules {
+		if want := rule.ID != "plain"; rule.Unmanaged != want {
+			t.Errorf("rule %s: Unmanaged = %v, want %v", rule.ID, rule.Unmanaged, want)
+		}
+	}
+
+	// Editing one rule writes the others back unchanged.
+	rules = upsertLifecycleRule(rules, LifecycleRule{ID: "plain", Prefix: "logs/", Enabled: true, ExpirationDays: 14})
+	payload, err := marshalLifecycleConfiguration(rules)
+	if err != nil {
+		t.Fatalf("marshalLifecycleConfiguration() error = %v", err)
+	}
+	for _, want := range []string{
+		"<StorageClass>GLACIER</StorageClass>",
+		"<Tag><Key>k</Key><Value>v</Value></Tag>",
+		"<Date>2030-01-01T00:00:00Z</Date>",
+		"<Expiration><Days>14</Days></Expiration>",
+	} {
+		if !strings.Contains(string(payload), want) {
+			t.Errorf("payload %s does not contain %s", payload, want)
+		}
+	}
+	if strings.Count(string(payload), "<Status>") != 4 {
+		t.Errorf("payload %s: want one Status per rule", payload)
+	}
+
+	again, err := parseLifecycleConfiguration(payload)
+	if err != nil || len(again) != 4 || !again[0].Unmanaged || again[3].ExpirationDays != 14 {
+		t.Fatalf("parseLifecycleConfiguration(payload) = %+v, %v", again, err)
+	}
+}
+
+func TestKeepUnmanagedRules(t *testing.T) {
+	current := []LifecycleRule{{ID: "archive", Unmanaged: true, raw: "<ID>archive</ID>"}}
+
+	kept, err := keepUnmanagedRules([]LifecycleRule{{ID: "archive", Unmanaged: true}, {ID: "new", ExpirationDays: 1}}, current)
+	if err != nil || kept[0].raw != "<ID>archive</ID>" || kept[1].raw != "" {
+		t.Fatalf("keepUnmanagedRules() = %+v, %v", kept, err)
+	}
+	if _, err := keepUnmanagedRules([]LifecycleRule{{ID: "other", Unmanaged: true}}, current); err == nil {
+		t.Fatal("expected error for an unknown unmanaged rule")
+	}
+}
+
+func TestLifecycleRuleJSONEnabledDefault(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{`{"expirationDays": 30}`, true},
+		{`{"enabled": true, "expirationDays": 30}`, true},
+		{`{"enabled": false, "expirationDays": 30}`, false},
+	}
+	for _, tt := range tests {
+		var rule LifecycleRule
+		if err := json.Unmarshal([]byte(tt.body), &rule); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", tt.body, err)
+		}
+		if rule.Enabled != tt.want || rule.ExpirationDays != 30 {
+			t.Errorf("Unmarshal(%s) = %+v, want enabled %v", tt.body, rule, tt.want)
+		}
+	}
+}
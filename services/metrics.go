@@ -0,0 +1,296 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of latency
+// histograms.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// MetricWriter writes metrics in the Prometheus text exposition format.
+// Write errors are kept and returned by Err.
+type MetricWriter struct {
+	w   io.Writer
+	err error
+}
+
+// NewMetricWriter returns a MetricWriter writing to w.
+func NewMetricWriter(w io.Writer) *MetricWriter {
+	return &MetricWriter{w: w}
+}
+
+// Family starts a metric family: its HELP and TYPE lines.
+func (m *MetricWriter) Family(name, typ, help string) {
+	m.printf("# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+}
+
+// Sample writes one sample. Labels are given as name, value pairs.
+func (m *MetricWriter) Sample(name string, value float64, labels ...string) {
+	m.printf("%s%s %s\n", name, formatLabels(labels), formatMetricValue(value))
+}
+
+// Err returns the first write error.
+func (m *MetricWriter) Err() error {
+	return m.err
+}
+
+func (m *MetricWriter) printf(format string, args ...any) {
+	if m.err == nil {
+		_, m.err = fmt.Fprintf(m.w, format, args...)
+	}
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// formatLabels renders name, value pairs as {a="1",b="2"}.
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labels[i])
+		b.WriteString(`="`)
+		b.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[i+1]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatMetricValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// CounterVec is a counter family partitioned by label values.
+type CounterVec struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec creates a counter family with the given label names.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labels: labels, values: map[string]*counterSeries{}}
+}
+
+// Inc adds one to the counter with the given label values.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta to the counter with the given label values.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.values[key]
+	if !ok {
+		s = &counterSeries{labelValues: append([]string(nil), labelValues...)}
+		c.values[key] = s
+	}
+	s.value += delta
+}
+
+// Write writes the family, series sorted by label values.
+func (c *CounterVec) Write(m *MetricWriter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m.Family(c.name, "counter", c.help)
+	for _, key := range sortedKeys(c.values) {
+		s := c.values[key]
+		m.Sample(c.name, s.value, pairLabels(c.labels, s.labelValues)...)
+	}
+}
+
+// HistogramVec is a histogram family partitioned by label values.
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	bounds     []float64
+
+	mu     sync.Mutex
+	values map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // per bound, not cumulative
+	count       uint64
+	sum         float64
+}
+
+// NewHistogramVec creates a histogram family with the given bucket upper
+// bounds (ascending) and label names.
+func NewHistogramVec(name, help string, bounds []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{name: name, help: help, labels: labels, bounds: bounds, values: map[string]*histogramSeries{}}
+}
+
+// Observe records one value for the given label values.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.values[key]
+	if !ok {
+		s = &histogramSeries{labelValues: append([]string(nil), labelValues...), counts: make([]uint64, len(h.bounds))}
+		h.values[key] = s
+	}
+	if i := sort.SearchFloat64s(h.bounds, v); i < len(h.bounds) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// Write writes the family with cumulative buckets, series sorted by label
+// values.
+func (h *HistogramVec) Write(m *MetricWriter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m.Family(h.name, "histogram", h.help)
+	for _, key := range sortedKeys(h.values) {
+		s := h.values[key]
+		labels := pairLabels(h.labels, s.labelValues)
+		var cumulative uint64
+		for i, bound := range h.bounds {
+			cumulative += s.counts[i]
+			m.Sample(h.name+"_bucket", float64(cumulative), append(labels, "le", formatMetricValue(bound))...)
+		}
+		m.Sample(h.name+"_bucket", float64(s.count), append(labels, "le", "+Inf")...)
+		m.Sample(h.name+"_sum", s.sum, labels...)
+		m.Sample(h.name+"_count", float64(s.count), labels...)
+	}
+}
+
+func pairLabels(names, values []string) []string {
+	labels := make([]string, 0, 2*len(names)+2)
+	for i, name := range names {
+		labels = append(labels, name, values[i])
+	}
+	return labels
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Gateway call metrics, recorded by every VersityGWService in the process.
+var (
+	gatewayRequests = NewCounterVec("vgw_gateway_requests_total",
+		"VersityGW requests by operation and HTTP status code (\"error\" if no response was received).",
+		"site", "method", "operation", "code")
+	gatewayErrors = NewCounterVec("vgw_gateway_errors_total",
+		"VersityGW requests that failed without a response or with a 5xx status, after retries.",
+		"site", "method", "operation")
+	gatewayDuration = NewHistogramVec("vgw_gateway_request_duration_seconds",
+		"Duration of VersityGW requests, including retries.",
+		DefaultLatencyBuckets, "site", "method", "operation")
+)
+
+// WriteGatewayMetrics writes the VersityGW call metrics.
+func WriteGatewayMetrics(m *MetricWriter) {
+	gatewayRequests.Write(m)
+	gatewayErrors.Write(m)
+	gatewayDuration.Write(m)
+}
+
+// recordGatewayCall records a finished VersityGW request.
+func recordGatewayCall(site string, req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	op := gatewayOperation(req.Method, req.URL)
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	gatewayRequests.Inc(site, req.Method, op, code)
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		gatewayErrors.Inc(site, req.Method, op)
+	}
+	gatewayDuration.Observe(elapsed.Seconds(), site, req.Method, op)
+}
+
+// gatewaySubresources are the S3 query parameters that name the operation
+// of a request, in order of precedence.
+var gatewaySubresources = []string{"policy", "tagging", "acl", "lifecycle", "cors", "versioning", "uploads", "versions", "delete", "uploadId", "versionId", "list-type"}
+
+// gatewayOperation names a VersityGW request without bucket names or object
+// keys, so the label has few values: the admin action (e.g. "create-user")
+// or the S3 resource with its subresource (e.g. "bucket?policy", "object").
+func gatewayOperation(method string, u *url.URL) string {
+	path := strings.Trim(u.Path, "/")
+	if method == http.MethodPatch {
+		return path
+	}
+	resource := "service"
+	if path != "" {
+		resource = "bucket"
+		if strings.Contains(path, "/") {
+			resource = "object"
+		}
+	}
+	query := u.Query()
+	for _, sub := range gatewaySubresources {
+		if query.Has(sub) {
+			return resource + "?" + sub
+		}
+	}
+	return resource
+}
+
+// zfsSizeUnits are the suffixes of human-readable ZFS sizes (powers of 1024).
+var zfsSizeUnits = map[byte]float64{'B': 1, 'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30, 'T': 1 << 40, 'P': 1 << 50, 'E': 1 << 60}
+
+// ParseZFSSize converts a size as printed by zfs list (e.g. "96K", "1.50T",
+// "0B") to bytes. It reports false for "none", "-" and unparsable values.
+func ParseZFSSize(s string) (int64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "none" || s == "-" {
+		return 0, false
+	}
+	mult := 1.0
+	if m, ok := zfsSizeUnits[strings.ToUpper(s[len(s)-1:])[0]]; ok {
+		mult = m
+		s = s[:len(s)-1]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return int64(v * mult), true
+}
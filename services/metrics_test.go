@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bytes"
+	"net/url"
+	"testing"
+)
+
+func TestCounterVecWrite(t *testing.T) {
+	c := NewCounterVec("test_total", "Test\ncounter.", "route", "code")
+	c.Inc("/b", "200")
+	c.Add(2, "/a", "500")
+	c.Inc("/b", "200")
+	c.Inc(`say "hi"\`, "200")
+
+	var buf bytes.Buffer
+	m := NewMetricWriter(&buf)
+	c.Write(m)
+	if err := m.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_total Test\ncounter.
+# TYPE test_total counter
+test_total{route="/a",code="500"} 2
+test_total{route="/b",code="200"} 2
+test_total{route="say \"hi\"\\",code="200"} 1
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogramVecWrite(t *testing.T) {
+	h := NewHistogramVec("test_seconds", "Test histogram.", []float64{0.1, 1}, "op")
+	h.Observe(0.05, "get")
+	h.Observe(0.1, "get")
+	h.Observe(0.5, "get")
+	h.Observe(3, "get")
+
+	var buf bytes.Buffer
+	h.Write(NewMetricWriter(&buf))
+	want := `# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{op="get",le="0.1"} 2
+test_seconds_bucket{op="get",le="1"} 3
+test_seconds_bucket{op="get",le="+Inf"} 4
+test_seconds_sum{op="get"} 3.65
+test_seconds_count{op="get"} 4
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGatewayOperation(t *testing.T) {
+	tests := []struct {
+		method, url, want string
+	}{
+		{"PATCH", "http://gw/create-user", "create-user"},
+		{"PATCH", "http://gw/change-bucket-owner/?bucket=a&owner=b", "change-bucket-owner"},
+		{"GET", "http://gw/", "service"},
+		{"GET", "http://gw/acme-backups", "bucket"},
+		{"PUT", "http://gw/acme-backups?policy", "bucket?policy"},
+		{"GET", "http://gw/acme-backups?list-type=2&prefix=x", "bucket?list-type"},
+		{"DELETE", "http://gw/acme-backups/a/b.txt", "object"},
+		{"DELETE", "http://gw/acme-backups/big.iso?uploadId=123", "object?uploadId"},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := gatewayOperation(tt.method, u); got != tt.want {
+			t.Errorf("gatewayOperation(%s %s) = %q, want %q", tt.method, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestParseZFSSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+		ok   bool
+	}{
+		{"0B", 0, true},
+		{"96K", 96 << 10, true},
+		{"1.50T", 3 << 39, true},
+		{"12345", 12345, true},
+		{"none", 0, false},
+		{"-", 0, false},
+		{"", 0, false},
+		{"lots", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseZFSSize(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseZFSSize(%q) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// MultipartUpload is an incomplete (not yet completed or aborted) multipart upload.
+type MultipartUpload struct {
+	Bucket    string    `json:"bucket" xml:"-"`
+	Key       string    `json:"key" xml:"Key"`
+	UploadID  string    `json:"uploadId" xml:"UploadId"`
+	Initiated time.Time `json:"initiated" xml:"Initiated"`
+	Initiator string    `json:"initiator,omitempty" xml:"Initiator>ID"`
+}
+
+// listMultipartUploadsResult is the S3 ListMultipartUploads response document.
+type listMultipartUploadsResult struct {
+	Uploads            []MultipartUpload `xml:"Upload"`
+	IsTruncated        bool              `xml:"IsTruncated"`
+	NextKeyMarker      string            `xml:"NextKeyMarker"`
+	NextUploadIDMarker string            `xml:"NextUploadIdMarker"`
+}
+
+// ListMultipartUploads lists all incomplete multipart uploads of a bucket,
+// following pagination markers until the listing is complete.
+func (s *VersityGWService) ListMultipartUploads(ctx context.Context, bucket string) ([]MultipartUpload, error) {
+	uploads := []MultipartUpload{}
+	keyMarker, uploadIDMarker := "", ""
+
+	for {
+		query := "uploads"
+		if keyMarker != "" {
+			query += "&key-marker=" + url.QueryEscape(keyMarker)
+		}
+		if uploadIDMarker != "" {
+			query += "&upload-id-marker=" + url.QueryEscape(uploadIDMarker)
+		}
+		query = strings.ReplaceAll(query, "+", "%20")
+
+		url := fmt.Sprintf("%s/%s?%s", s.cfg.EndpointURL, bucket, query)
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		body, err := s.signAndSend(httpReq, []byte{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list multipart uploads: %w", err)
+		}
+
+		var result listMultipartUploadsResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("failed to parse multipart uploads: %w", err)
+		}
+		for _, upload := range result.Uploads {
+			upload.Bucket = bucket
+			uploads = append(uploads, upload)
+		}
+
+		if !result.IsTruncated || (result.NextKeyMarker == "" && result.NextUploadIDMarker == "") {
+			break
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+
+	return uploads, nil
+}
+
+// AbortMultipartUpload aborts an incomplete multipart upload and frees its parts.
+func (s *VersityGWService) AbortMultipartUpload(ctx context.Context, bucket, key, uploadID string) error {
+	url := fmt.Sprintf("%s?uploadId=%s", objectURL(s.cfg.EndpointURL, bucket, key), url.QueryEscape(uploadID))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if _, err := s.signAndSend(httpReq, []byte{}); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+
+	return nil
+}
+
+// MultipartCleanupResult reports the outcome of a stale multipart upload cleanup.
+type MultipartCleanupResult struct {
+	DryRun  bool                   `json:"dryRun"`
+	Uploads []MultipartCleanupItem `json:"uploads"`
+	Aborted int                    `json:"aborted"`
+	Failed  int                    `json:"failed"`
+}
+
+// MultipartCleanupItem is one stale upload found by the cleanup, with its abort outcome.
+type MultipartCleanupItem struct {
+	MultipartUpload
+	Aborted bool   `json:"aborted"`
+	Error   string `json:"error,omitempty"`
+}
+
+// FindStaleMultipartUploads lists incomplete multipart uploads initiated more than
+// olderThan ago. An empty bucket name searches every bucket on the gateway.
+func FindStaleMultipartUploads(ctx context.Context, cfg *config.Config, bucket string, olderThan time.Duration) ([]MultipartUpload, error) {
+	vgwService := NewVersityGWService(cfg)
+
+	buckets := []string{bucket}
+	if bucket == "" {
+		infos, err := vgwService.ListBuckets(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list buckets: %w", err)
+		}
+		buckets = buckets[:0]
+		for _, info := range infos {
+			buckets = append(buckets, info.Name)
+		}
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+	stale := []MultipartUpload{}
+	for _, name := range buckets {
+		uploads, err := vgwService.ListMultipartUploads(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("bucket %s: %w", name, err)
+		}
+		stale = append(stale, StaleUploads(uploads, cutoff)...)
+	}
+	return stale, nil
+}
+
+// StaleUploads returns the uploads initiated before cutoff.
+func StaleUploads(uploads []MultipartUpload, cutoff time.Time) []MultipartUpload {
+	stale := []MultipartUpload{}
+	for _, upload := range uploads {
+		if upload.Initiated.Before(cutoff) {
+			stale = append(stale, upload)
+		}
+	}
+	return stale
+}
+
+// CleanupMultipartUploads aborts incomplete multipart uploads older than olderThan
+// in one bucket (or all buckets when bucket is empty). With dryRun set, the stale
+// uploads are only reported. Individual abort failures are recorded per upload
+// rather than stopping the cleanup.
+func CleanupMultipartUploads(ctx context.Context, cfg *config.Config, bucket string, olderThan time.Duration, dryRun bool) (*MultipartCleanupResult, error) {
+	stale, err := FindStaleMultipartUploads(ctx, cfg, bucket, olderThan)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MultipartCleanupResult{DryRun: dryRun, Uploads: make([]MultipartCleanupItem, 0, len(stale))}
+	vgwService := NewVersityGWService(cfg)
+	for _, upload := range stale {
+		item := MultipartCleanupItem{MultipartUpload: upload}
+		if !dryRun {
+			if err := vgwService.AbortMultipartUpload(ctx, upload.Bucket, upload.Key, upload.UploadID); err != nil {
+				item.Error = err.Error()
+				result.Failed++
+			} else {
+				item.Aborted = true
+				result.Aborted++
+			}
+		}
+		result.Uploads = append(result.Uploads, item)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestParseListMultipartUploads(t *testing.T) {
+	body := `<ListMultipartUploadsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Bucket>data</Bucket>
+  <IsTruncated>true</IsTruncated>
+  <NextKeyMarker>video.mp4</NextKeyMarker>
+  <NextUploadIdMarker>abc</NextUploadIdMarker>
+  <Upload>
+    <Key>video.mp4</Key>
+    <UploadId>abc</UploadId>
+    <Initiator><ID>alice</ID></Initiator>
+    <Initiated>2024-05-01T10:00:00.000Z</Initiated>
+  </Upload>
+</ListMultipartUploadsResult>`
+
+	var result listMultipartUploadsResult
+	if err := xml.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(result.Uploads) != 1 || !result.IsTruncated || result.NextUploadIDMarker != "abc" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	upload := result.Uploads[0]
+	if upload.Key != "video.mp4" || upload.UploadID != "abc" || upload.Initiator != "alice" {
+		t.Fatalf("unexpected upload: %+v", upload)
+	}
+	if !upload.Initiated.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("initiated = %v", upload.Initiated)
+	}
+}
+
+func TestStaleUploads(t *testing.T) {
+	now := time.Now()
+	uploads := []MultipartUpload{
+		{Key: "old", Initiated: now.Add(-48 * time.Hour)},
+		{Key: "new", Initiated: now.Add(-time.Hour)},
+	}
+	stale := StaleUploads(uploads, now.Add(-24*time.Hour))
+	if len(stale) != 1 || stale[0].Key != "old" {
+		t.Fatalf("StaleUploads() = %+v", stale)
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import "testing"
+
+func TestEscapeObjectKey(t *testing.T) {
+	cases := map[string]string{
+		"photos/2024/a.jpg": "photos/2024/a.jpg",
+		"my file (1).txt":   "my%20file%20%281%29.txt",
+		"a+b=c&d":           "a%2Bb%3Dc%26d",
+		"ünicode/~tilde-_.": "%C3%BCnicode/~tilde-_.",
+	}
+	for key, want := range cases {
+		if got := escapeObjectKey(key); got != want {
+			t.Errorf("escapeObjectKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestObjectPrefixHelpers(t *testing.T) {
+	if got := ObjectBaseName("logs/2024/app.log"); got != "app.log" {
+		t.Errorf("ObjectBaseName(object) = %q", got)
+	}
+	if got := ObjectBaseName("logs/2024/"); got != "2024/" {
+		t.Errorf("ObjectBaseName(prefix) = %q", got)
+	}
+	if got := ParentPrefix("logs/2024/"); got != "logs/" {
+		t.Errorf("ParentPrefix(nested) = %q", got)
+	}
+	if got := ParentPrefix("logs/"); got != "" {
+		t.Errorf("ParentPrefix(top) = %q", got)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1536:            "1.5 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+		3 << 40:         "3.0 TiB",
+	}
+	for n, want := range cases {
+		if got := FormatBytes(n); got != want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
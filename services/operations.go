@@ -1,28 +1,33 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 
+	"github.com/monobilisim/vgw-manager/config"
 	"github.com/monobilisim/vgw-manager/models"
 )
 
 // ListMergedBuckets returns a merged list of ZFS and API buckets, sorted by name.
-// ZFS buckets are enriched with real owner info from the VersityGW API (via ACL).
-// Buckets that exist only in the API are added as placeholders.
-// Returns an error when both listings fail or a bucket policy cannot be read.
-func ListMergedBuckets() ([]models.Bucket, error) {
-	bucketService := NewBucketService()
-	vgwService := NewVersityGWService()
-
-	buckets, zfsErr := bucketService.ListBuckets()
+// ZFS buckets are enriched with real owner info (via ACL), visibility and tags
+// from the VersityGW API; see enrichBuckets. Buckets that exist only in the API
+// are added as placeholders. With quotaAlerts, QuotaAlert is set from the state
+// recorded by CheckQuotaAlerts. Returns an error when both listings fail or ctx
+// is cancelled; a bucket that cannot be enriched carries the reason in Error.
+func ListMergedBuckets(ctx context.Context, cfg *config.Config) ([]models.Bucket, error) {
+	bucketService := NewBucketService(cfg)
+	vgwService := NewVersityGWService(cfg)
+
+	buckets, zfsErr := bucketService.ListBuckets(ctx)
 	if zfsErr != nil {
 		buckets = []models.Bucket{}
 	}
 
-	apiBuckets, apiErr := vgwService.ListBuckets()
+	apiBuckets, apiErr := vgwService.ListBuckets(ctx)
 	if apiErr == nil {
 		// Create map for fast lookup of ZFS buckets
 		zfsMap := make(map[string]*models.Bucket)
@@ -30,61 +35,33 @@ func ListMergedBuckets() ([]models.Bucket, error) {
 			zfsMap[buckets[i].Name] = &buckets[i]
 		}
 
-		// Create map for API info
-		apiMap := make(map[string]BucketInfo)
-		for _, b := range apiBuckets {
-			apiMap[b.Name] = b
-		}
-
-		// Update existing ZFS buckets with owner info
-		for i := range buckets {
-			if info, ok := apiMap[buckets[i].Name]; ok {
-				if info.Owner != "" {
-					buckets[i].Owner = info.Owner
+		for _, apiBucket := range apiBuckets {
+			if zfsBucket, exists := zfsMap[apiBucket.Name]; exists {
+				if apiBucket.Owner != "" {
+					zfsBucket.Owner = apiBucket.Owner
 				}
+				continue
 			}
 
-			// Fetch TRUE owner from ACL
-			trueOwner, err := vgwService.GetBucketOwner(buckets[i].Name)
-			if err == nil && trueOwner != "" {
-				buckets[i].Owner = trueOwner
-			}
-
-			buckets[i].Public, err = bucketIsPublic(vgwService, buckets[i].Name)
-			if err != nil {
-				return nil, fmt.Errorf("checking bucket %q policy: %w", buckets[i].Name, err)
-			}
+			// Add buckets that exist in API but NOT in ZFS
+			buckets = append(buckets, models.Bucket{
+				Name:       apiBucket.Name,
+				Mountpoint: "-",
+				Quota:      "-",
+				Used:       "-",
+				Available:  "-",
+				Owner:      apiBucket.Owner,
+			})
 		}
 
-		// Add buckets that exist in API but NOT in ZFS
-		for _, apiBucket := range apiBuckets {
-			if _, exists := zfsMap[apiBucket.Name]; !exists {
-				newBucket := models.Bucket{
-					Name:       apiBucket.Name,
-					Mountpoint: "-",
-					Quota:      "-",
-					Used:       "-",
-					Available:  "-",
-					Owner:      apiBucket.Owner,
-				}
-
-				trueOwner, err := vgwService.GetBucketOwner(apiBucket.Name)
-				if err == nil && trueOwner != "" {
-					newBucket.Owner = trueOwner
-				}
-
-				newBucket.Public, err = bucketIsPublic(vgwService, apiBucket.Name)
-				if err != nil {
-					return nil, fmt.Errorf("checking bucket %q policy: %w", apiBucket.Name, err)
-				}
-
-				buckets = append(buckets, newBucket)
-			}
+		if err := enrichBuckets(ctx, vgwService, buckets); err != nil {
+			return nil, err
 		}
 	} else if zfsErr != nil {
 		// If both failed, then we have a real error
 		return nil, fmt.Errorf("listing buckets: ZFS(%v) API(%v)", zfsErr, apiErr)
 	}
+	applyQuotaAlerts(cfg, buckets)
 
 	sort.Slice(buckets, func(i, j int) bool {
 		return buckets[i].Name < buckets[j].Name
@@ -103,17 +80,29 @@ type bucketPolicyStatement struct {
 
 // bucketIsPublic reports whether a bucket policy grants access to everyone.
 // A missing policy or a policy limited to named principals is private.
-func bucketIsPublic(vgwService *VersityGWService, name string) (bool, error) {
-	policy, err := vgwService.GetBucketPolicy(name)
+func bucketIsPublic(ctx context.Context, vgwService *VersityGWService, name string) (bool, error) {
+	policy, err := vgwService.GetBucketPolicy(ctx, name)
 	if err == nil {
 		return policyGrantsPublicAccess(policy)
 	}
-	if strings.Contains(err.Error(), "API error (status 404)") {
+	if isNotFoundError(err) {
 		return false, nil
 	}
 	return false, err
 }
 
+// isNotFoundError reports whether err is a 404 response from the VersityGW API.
+func isNotFoundError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "API error (status 404)")
+}
+
+// isNotImplementedError reports whether err is a VersityGW response rejecting
+// an unsupported request (501 or an S3 NotImplemented error).
+func isNotImplementedError(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "API error (status 501)") ||
+		strings.Contains(err.Error(), "<Code>NotImplemented</Code>"))
+}
+
 func policyGrantsPublicAccess(policy string) (bool, error) {
 	var document bucketPolicy
 	if err := json.Unmarshal([]byte(policy), &document); err != nil {
@@ -159,37 +148,135 @@ func policyGrantsPublicAccess(policy string) (bool, error) {
 
 // DeleteBucketWithFallback tries ZFS deletion first, then falls back to API deletion.
 // Returns the method used ("zfs" or "api") and any error.
-func DeleteBucketWithFallback(name string) (string, error) {
-	bucketService := NewBucketService()
-	err := bucketService.DeleteBucket(name)
+func DeleteBucketWithFallback(ctx context.Context, cfg *config.Config, name string) (string, error) {
+	bucketService := NewBucketService(cfg)
+	err := bucketService.DeleteBucket(ctx, name)
 	if err == nil {
 		return "zfs", nil
 	}
 
 	// ZFS failed, try API
-	vgwService := NewVersityGWService()
-	if apiErr := vgwService.DeleteBucket(name); apiErr != nil {
+	vgwService := NewVersityGWService(cfg)
+	if apiErr := vgwService.DeleteBucket(ctx, name); apiErr != nil {
 		return "", fmt.Errorf("ZFS: %v; API: %v", err, apiErr)
 	}
 	return "api", nil
 }
 
+// DeleteUser deletes a user. The user and its service accounts are first
+// removed from their groups, then the service accounts are revoked, so that
+// none of them stays behind as a gateway user or policy principal. A service
+// account itself is revoked as by RevokeServiceAccount.
+func DeleteUser(ctx context.Context, cfg *config.Config, access string) error {
+	accounts, err := ListServiceAccounts(cfg, "")
+	if err != nil {
+		return err
+	}
+	isServiceAccount := false
+	children := []string{}
+	for _, account := range accounts {
+		switch access {
+		case account.Access:
+			isServiceAccount = true
+		case account.Parent:
+			children = append(children, account.Access)
+		}
+	}
+
+	for _, member := range append(children, access) {
+		if err := leaveGroups(ctx, cfg, member); err != nil {
+			return err
+		}
+	}
+	if isServiceAccount {
+		return RevokeServiceAccount(ctx, cfg, access)
+	}
+	for _, child := range children {
+		if err := RevokeServiceAccount(ctx, cfg, child); err != nil {
+			return fmt.Errorf("failed to revoke service account %s: %w", child, err)
+		}
+	}
+
+	return NewVersityGWService(cfg).DeleteUser(ctx, access)
+}
+
+// ErrServiceAccountGrant is wrapped by the ChangeBucketOwner error when the
+// owner was changed but the bucket's service account grant was not moved.
+// SyncServiceAccountGrants repairs the grant.
+var ErrServiceAccountGrant = errors.New("owner changed, but granting the bucket to the owner's service accounts failed")
+
+// ChangeBucketOwner changes the owner of a bucket and then moves the bucket's
+// service account grant to the new owner's service accounts. A failure of the
+// second step wraps ErrServiceAccountGrant.
+func ChangeBucketOwner(ctx context.Context, cfg *config.Config, bucket, owner string) error {
+	vgwService := NewVersityGWService(cfg)
+	if err := vgwService.ChangeBucketOwner(ctx, bucket, owner); err != nil {
+		return err
+	}
+	if err := syncBucketServiceAccountGrant(ctx, vgwService, bucket, owner); err != nil {
+		return fmt.Errorf("%w: %w", ErrServiceAccountGrant, err)
+	}
+	return nil
+}
+
 // MakeBucketPublic resolves the bucket owner (if empty), generates a public
-// read policy, and applies it via the VersityGW API.
-func MakeBucketPublic(name, owner string) error {
-	vgwService := NewVersityGWService()
+// read policy, and applies it via the VersityGW API. Grants managed by
+// vgw-manager are carried over into the new policy.
+func MakeBucketPublic(ctx context.Context, cfg *config.Config, name, owner string) error {
+	vgwService := NewVersityGWService(cfg)
 
 	if owner == "" {
 		var err error
-		owner, err = vgwService.GetBucketOwner(name)
+		owner, err = vgwService.GetBucketOwner(ctx, name)
 		if err != nil || owner == "" {
 			return fmt.Errorf("failed to resolve owner for policy generation")
 		}
 	}
 
 	policy := GeneratePublicPolicy(name, owner)
-	if err := vgwService.SetBucketPolicy(name, policy); err != nil {
+
+	// Keep grants managed by vgw-manager (service accounts) on the bucket.
+	current, err := vgwService.currentBucketPolicy(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to make bucket public: %w", err)
+	}
+	managed, err := managedStatements(current)
+	if err != nil {
+		return fmt.Errorf("failed to make bucket public: %w", err)
+	}
+	if len(managed) > 0 {
+		if policy, err = mergePolicyStatements(policy, nil, managed); err != nil {
+			return fmt.Errorf("failed to make bucket public: %w", err)
+		}
+	}
+
+	if err := vgwService.SetBucketPolicy(ctx, name, policy); err != nil {
 		return fmt.Errorf("failed to make bucket public: %w", err)
 	}
 	return nil
 }
+
+// FilterBucketsByTags returns the buckets whose tags contain every pair in filter.
+// An empty filter returns buckets unchanged.
+func FilterBucketsByTags(buckets []models.Bucket, filter map[string]string) []models.Bucket {
+	if len(filter) == 0 {
+		return buckets
+	}
+
+	filtered := make([]models.Bucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		if MatchTags(bucket.Tags, filter) {
+			filtered = append(filtered, bucket)
+		}
+	}
+	return filtered
+}
+
+// SetBucketTags replaces the tags of a bucket. An empty tag set removes all tags.
+func SetBucketTags(ctx context.Context, cfg *config.Config, name string, tags map[string]string) error {
+	vgwService := NewVersityGWService(cfg)
+	if len(tags) == 0 {
+		return vgwService.DeleteBucketTagging(ctx, name)
+	}
+	return vgwService.PutBucketTagging(ctx, name, tags)
+}
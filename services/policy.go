@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// managedSidPrefix marks bucket policy statements generated by vgw-manager to
+// grant buckets to other users (service accounts). They are rewritten on
+// every sync; all other statements of a policy are kept as they are.
+const managedSidPrefix = "Vgwm"
+
+// bucketAccessActions are the actions granted to users sharing a bucket with
+// its owner. Bucket-level administration (policy, ACL, deletion) is not included.
+var bucketAccessActions = []string{
+	"s3:ListMultipartUploadParts",
+	"s3:PutObject",
+	"s3:AbortMultipartUpload",
+	"s3:DeleteObject",
+	"s3:GetBucketLocation",
+	"s3:GetObject",
+	"s3:ListBucket",
+	"s3:ListBucketMultipartUploads",
+}
+
+// policyStatement is a bucket policy statement as generated by vgw-manager.
+type policyStatement struct {
+	Sid       string              `json:"Sid"`
+	Effect    string              `json:"Effect"`
+	Principal map[string][]string `json:"Principal"`
+	Action    []string            `json:"Action"`
+	Resource  []string            `json:"Resource"`
+}
+
+// policyDocument is a bucket policy with statements kept verbatim.
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []json.RawMessage `json:"Statement"`
+}
+
+// bucketGrantStatement grants principals object read/write access to a bucket.
+func bucketGrantStatement(sid, bucket string, principals []string) policyStatement {
+	return policyStatement{
+		Sid:       sid,
+		Effect:    "Allow",
+		Principal: map[string][]string{"AWS": principals},
+		Action:    bucketAccessActions,
+		Resource: []string{
+			"arn:aws:s3:::" + bucket,
+			"arn:aws:s3:::" + bucket + "/*",
+		},
+	}
+}
+
+func isManagedSid(sid string) bool {
+	return strings.HasPrefix(sid, managedSidPrefix)
+}
+
+func statementSid(statement json.RawMessage) string {
+	var s struct {
+		Sid string `json:"Sid"`
+	}
+	_ = json.Unmarshal(statement, &s)
+	return s.Sid
+}
+
+// mergePolicyStatements removes the statements of policy whose Sid matches drop
+// and appends add. An empty policy string starts a new document. The result is
+// "" when no statements remain.
+func mergePolicyStatements(policy string, drop func(sid string) bool, add []policyStatement) (string, error) {
+	document := policyDocument{Version: "2012-10-17"}
+	if strings.TrimSpace(policy) != "" {
+		if err := json.Unmarshal([]byte(policy), &document); err != nil {
+			return "", fmt.Errorf("parsing bucket policy: %w", err)
+		}
+	}
+
+	statements := make([]json.RawMessage, 0, len(document.Statement)+len(add))
+	for _, statement := range document.Statement {
+		if drop == nil || !drop(statementSid(statement)) {
+			statements = append(statements, statement)
+		}
+	}
+	for _, statement := range add {
+		raw, err := json.Marshal(statement)
+		if err != nil {
+			return "", fmt.Errorf("encoding policy statement: %w", err)
+		}
+		statements = append(statements, raw)
+	}
+	if len(statements) == 0 {
+		return "", nil
+	}
+
+	document.Statement = statements
+	data, err := json.MarshalIndent(document, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf("encoding bucket policy: %w", err)
+	}
+	return string(data), nil
+}
+
+// managedStatements returns the vgw-manager generated statements of a policy.
+func managedStatements(policy string) ([]policyStatement, error) {
+	if strings.TrimSpace(policy) == "" {
+		return nil, nil
+	}
+	var document policyDocument
+	if err := json.Unmarshal([]byte(policy), &document); err != nil {
+		return nil, fmt.Errorf("parsing bucket policy: %w", err)
+	}
+
+	managed := []policyStatement{}
+	for _, raw := range document.Statement {
+		if !isManagedSid(statementSid(raw)) {
+			continue
+		}
+		var statement policyStatement
+		if err := json.Unmarshal(raw, &statement); err != nil {
+			return nil, fmt.Errorf("parsing statement %s: %w", statementSid(raw), err)
+		}
+		managed = append(managed, statement)
+	}
+	return managed, nil
+}
+
+// currentBucketPolicy returns a bucket's policy, or "" if it has none.
+func (s *VersityGWService) currentBucketPolicy(ctx context.Context, bucket string) (string, error) {
+	policy, err := s.GetBucketPolicy(ctx, bucket)
+	if isNotFoundError(err) {
+		return "", nil
+	}
+	return policy, err
+}
+
+// rewriteBucketPolicy replaces the statements matching drop with add, deleting
+// the policy when nothing remains.
+func (s *VersityGWService) rewriteBucketPolicy(ctx context.Context, bucket string, drop func(sid string) bool, add []policyStatement) error {
+	current, err := s.currentBucketPolicy(ctx, bucket)
+	if err != nil {
+		return err
+	}
+	policy, err := mergePolicyStatements(current, drop, add)
+	if err != nil {
+		return err
+	}
+
+	if policy == "" {
+		if current == "" {
+			return nil
+		}
+		return s.DeleteBucketPolicy(ctx, bucket)
+	}
+	return s.SetBucketPolicy(ctx, bucket, policy)
+}
+
+// MakeBucketPrivate removes public access from a bucket. Grants managed by
+// vgw-manager (service accounts) stay in place; without any, the policy is deleted.
+func MakeBucketPrivate(ctx context.Context, cfg *config.Config, name string) error {
+	vgwService := NewVersityGWService(cfg)
+	drop := func(sid string) bool { return !isManagedSid(sid) }
+	if err := vgwService.rewriteBucketPolicy(ctx, name, drop, nil); err != nil {
+		return fmt.Errorf("failed to make bucket private: %w", err)
+	}
+	return nil
+}
+
+// IsBucketPublic reports whether a bucket's policy grants access to everyone.
+func IsBucketPublic(ctx context.Context, cfg *config.Config, name string) (bool, error) {
+	return bucketIsPublic(ctx, NewVersityGWService(cfg), name)
+}
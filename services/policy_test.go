@@ -0,0 +1,52 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergePolicyStatements(t *testing.T) {
+	public := GeneratePublicPolicy("data", "alice")
+	grant := bucketGrantStatement(serviceAccountsSid, "data", []string{"alice-ci", "alice-backup"})
+
+	merged, err := mergePolicyStatements(public, nil, []policyStatement{grant})
+	if err != nil {
+		t.Fatalf("mergePolicyStatements() error = %v", err)
+	}
+	for _, want := range []string{`"PublicRead"`, `"UserWriteDelete"`, `"VgwmServiceAccounts"`, `"alice-backup"`, `"arn:aws:s3:::data/*"`} {
+		if !strings.Contains(merged, want) {
+			t.Errorf("merged policy missing %s:\n%s", want, merged)
+		}
+	}
+	if ok, err := policyGrantsPublicAccess(merged); err != nil || !ok {
+		t.Errorf("merged policy public = %v, %v; want true", ok, err)
+	}
+
+	managed, err := managedStatements(merged)
+	if err != nil || len(managed) != 1 || len(managed[0].Principal["AWS"]) != 2 {
+		t.Fatalf("managedStatements() = %+v, %v", managed, err)
+	}
+
+	// Making the bucket private keeps only the managed grant.
+	private, err := mergePolicyStatements(merged, func(sid string) bool { return !isManagedSid(sid) }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(private, "PublicRead") || !strings.Contains(private, serviceAccountsSid) {
+		t.Errorf("private policy:\n%s", private)
+	}
+	if ok, _ := policyGrantsPublicAccess(private); ok {
+		t.Error("private policy still grants public access")
+	}
+
+	// Dropping the last statement yields no policy at all.
+	empty, err := mergePolicyStatements(private, isManagedSid, nil)
+	if err != nil || empty != "" {
+		t.Errorf("mergePolicyStatements() = %q, %v; want empty", empty, err)
+	}
+
+	fresh, err := mergePolicyStatements("", nil, []policyStatement{grant})
+	if err != nil || !strings.Contains(fresh, `"Version": "2012-10-17"`) {
+		t.Errorf("new policy = %q, %v", fresh, err)
+	}
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// Presigned URL lifetimes. SigV4 rejects presigned URLs valid for more than 7 days.
+const (
+	DefaultPresignExpiry = time.Hour
+	MaxPresignExpiry     = 7 * 24 * time.Hour
+)
+
+// Signers of a presigned URL.
+const (
+	PresignAsAdmin = "admin"
+	PresignAsOwner = "owner"
+)
+
+// PresignRequest describes a presigned object URL to generate.
+type PresignRequest struct {
+	Bucket string        `json:"bucket"`
+	Key    string        `json:"key"`
+	Method string        `json:"method"` // GET or PUT
+	Expiry time.Duration `json:"-"`
+	// SignAs is PresignAsAdmin (default) or PresignAsOwner, which signs with the
+	// bucket owner's credentials so the URL keeps working under owner-only policies.
+	SignAs string `json:"signAs"`
+	// Owner is the bucket owner signing with PresignAsOwner; it is looked up
+	// when empty.
+	Owner string `json:"-"`
+}
+
+// PresignedURL is a generated presigned object URL.
+type PresignedURL struct {
+	URL       string    `json:"url"`
+	Method    string    `json:"method"`
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	SignedBy  string    `json:"signedBy"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Validate normalizes the method, signer and expiry and checks their ranges.
+func (r *PresignRequest) Validate() error {
+	if r.Bucket == "" || r.Key == "" {
+		return fmt.Errorf("bucket and key are required")
+	}
+
+	r.Method = strings.ToUpper(r.Method)
+	if r.Method == "" {
+		r.Method = http.MethodGet
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		return fmt.Errorf("method must be GET or PUT")
+	}
+
+	if r.SignAs == "" {
+		r.SignAs = PresignAsAdmin
+	}
+	if r.SignAs != PresignAsAdmin && r.SignAs != PresignAsOwner {
+		return fmt.Errorf("signAs must be %q or %q", PresignAsAdmin, PresignAsOwner)
+	}
+
+	if r.Expiry == 0 {
+		r.Expiry = DefaultPresignExpiry
+	}
+	if r.Expiry < time.Second || r.Expiry > MaxPresignExpiry {
+		return fmt.Errorf("expiry must be between 1s and %s", MaxPresignExpiry)
+	}
+	return nil
+}
+
+// PresignObjectURL generates a time-limited presigned GET or PUT URL for an object.
+func PresignObjectURL(ctx context.Context, cfg *config.Config, req PresignRequest) (*PresignedURL, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	creds := aws.Credentials{
+		AccessKeyID:     cfg.AdminAccess,
+		SecretAccessKey: cfg.AdminSecret,
+	}
+	if req.SignAs == PresignAsOwner {
+		owner := req.Owner
+		if owner == "" {
+			var err error
+			if owner, err = ResolveBucketOwner(ctx, cfg, req.Bucket); err != nil {
+				return nil, err
+			}
+		}
+		user, err := NewUserService(cfg).GetUser(owner)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up credentials of bucket owner %s: %w", owner, err)
+		}
+		creds = aws.Credentials{AccessKeyID: user.Access, SecretAccessKey: user.Secret}
+	}
+
+	return presignWithCredentials(cfg, req, creds, time.Now())
+}
+
+// ResolveBucketOwner returns the access key of the bucket's owner, failing
+// if the bucket has none.
+func ResolveBucketOwner(ctx context.Context, cfg *config.Config, bucket string) (string, error) {
+	owner, err := NewVersityGWService(cfg).GetBucketOwner(ctx, bucket)
+	if err != nil || owner == "" {
+		return "", fmt.Errorf("failed to resolve owner of bucket %s: %v", bucket, err)
+	}
+	return owner, nil
+}
+
+// presignWithCredentials signs the URL for the public endpoint: SigV4 covers
+// the Host, so the URL cannot be rewritten to it afterwards.
+func presignWithCredentials(cfg *config.Config, req PresignRequest, creds aws.Credentials, now time.Time) (*PresignedURL, error) {
+	endpoint := cfg.PublicEndpointURL
+	if endpoint == "" {
+		endpoint = cfg.EndpointURL
+	}
+	httpReq, err := http.NewRequest(req.Method, objectURL(endpoint, req.Bucket, req.Key), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.URL.RawQuery = "X-Amz-Expires=" + strconv.FormatInt(int64(req.Expiry/time.Second), 10)
+
+	signer := v4.NewSigner()
+	signed, _, err := signer.PresignHTTP(context.Background(), creds, httpReq, "UNSIGNED-PAYLOAD", "s3", cfg.Region, now,
+		func(o *v4.SignerOptions) {
+			o.DisableURIPathEscaping = true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign request: %w", err)
+	}
+
+	return &PresignedURL{
+		URL:       signed,
+		Method:    req.Method,
+		Bucket:    req.Bucket,
+		Key:       req.Key,
+		SignedBy:  creds.AccessKeyID,
+		ExpiresAt: now.Add(req.Expiry).Truncate(time.Second),
+	}, nil
+}
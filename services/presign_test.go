@@ -0,0 +1,81 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+func TestPresignRequestValidate(t *testing.T) {
+	req := PresignRequest{Bucket: "b", Key: "k", Method: "put"}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if req.Method != "PUT" || req.SignAs != PresignAsAdmin || req.Expiry != DefaultPresignExpiry {
+		t.Fatalf("defaults not applied: %+v", req)
+	}
+
+	bad := []PresignRequest{
+		{Bucket: "b", Key: "k", Method: "DELETE"},
+		{Bucket: "b", Key: "k", Expiry: 8 * 24 * time.Hour},
+		{Bucket: "b", Key: "k", SignAs: "someone"},
+		{Bucket: "b"},
+	}
+	for _, r := range bad {
+		if err := r.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want error", r)
+		}
+	}
+}
+
+func TestPresignWithCredentials(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	req := PresignRequest{Bucket: "docs", Key: "reports/q1 final.pdf", Method: "GET", Expiry: 15 * time.Minute}
+	if err := req.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{EndpointURL: "http://localhost:7070", PublicEndpointURL: "https://s3.example.com", Region: "local"}
+	got, err := presignWithCredentials(cfg, req, aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, now)
+	if err != nil {
+		t.Fatalf("presignWithCredentials() error = %v", err)
+	}
+
+	u, err := url.Parse(got.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The URL is signed for the public endpoint, not the internal one.
+	if u.Scheme != "https" || u.Host != "s3.example.com" {
+		t.Errorf("URL = %s, want it on the public endpoint", got.URL)
+	}
+	if !strings.HasSuffix(u.EscapedPath(), "/docs/reports/q1%20final.pdf") {
+		t.Errorf("path = %q", u.EscapedPath())
+	}
+	q := u.Query()
+	if q.Get("X-Amz-Expires") != "900" || q.Get("X-Amz-Signature") == "" || !strings.HasPrefix(q.Get("X-Amz-Credential"), "AKID/") {
+		t.Errorf("query = %v", q)
+	}
+	if !got.ExpiresAt.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("ExpiresAt = %v", got.ExpiresAt)
+	}
+}
+
+func TestPresignWithoutPublicEndpoint(t *testing.T) {
+	req := PresignRequest{Bucket: "docs", Key: "a.txt"}
+	if err := req.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := presignWithCredentials(&config.Config{EndpointURL: "http://localhost:7070", Region: "local"}, req, aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got.URL, "http://localhost:7070/docs/a.txt?") {
+		t.Errorf("URL = %s, want it on endpointURL", got.URL)
+	}
+}
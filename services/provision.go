@@ -1,10 +1,13 @@
 package services
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 
+	"github.com/monobilisim/vgw-manager/config"
 	"github.com/monobilisim/vgw-manager/models"
 )
 
@@ -21,6 +24,7 @@ type ProvisionRequest struct {
 	Bucket string
 	Quota  string
 	Owner  string
+	Tags   map[string]string
 }
 
 // ProvisionSummary holds the result of a provisioning operation.
@@ -33,15 +37,16 @@ type ProvisionSummary struct {
 	GroupID   int `json:"groupID"`
 	ProjectID int `json:"projectID"`
 
-	Bucket string `json:"bucket"`
-	Quota  string `json:"quota"`
-	Owner  string `json:"owner"`
+	Bucket string            `json:"bucket"`
+	Quota  string            `json:"quota"`
+	Owner  string            `json:"owner"`
+	Tags   map[string]string `json:"tags,omitempty"`
 
 	SecretGenerated bool `json:"secretGenerated"`
 }
 
-// Provision creates a user, creates a bucket, and sets the bucket owner.
-func Provision(req ProvisionRequest) (ProvisionSummary, error) {
+// Provision creates a user, creates a bucket, sets the bucket owner, and applies any bucket tags.
+func Provision(ctx context.Context, cfg *config.Config, req ProvisionRequest) (ProvisionSummary, error) {
 	summary := ProvisionSummary{}
 
 	if req.Access == "" {
@@ -66,8 +71,8 @@ func Provision(req ProvisionRequest) (ProvisionSummary, error) {
 		secretGenerated = true
 	}
 
-	vgwService := NewVersityGWService()
-	bucketService := NewBucketService()
+	vgwService := NewVersityGWService(cfg)
+	bucketService := NewBucketService(cfg)
 
 	userReq := models.UserCreateRequest{
 		Access:    req.Access,
@@ -78,7 +83,7 @@ func Provision(req ProvisionRequest) (ProvisionSummary, error) {
 		ProjectID: req.ProjectID,
 	}
 
-	if err := vgwService.CreateUser(userReq); err != nil {
+	if err := vgwService.CreateUser(ctx, userReq); err != nil {
 		return summary, fmt.Errorf("failed to create user: %w", err)
 	}
 
@@ -88,14 +93,22 @@ func Provision(req ProvisionRequest) (ProvisionSummary, error) {
 		Owner: req.Owner,
 	}
 
-	if err := bucketService.CreateBucket(bucketReq); err != nil {
+	if err := bucketService.CreateBucket(ctx, bucketReq); err != nil {
 		return summary, fmt.Errorf("failed to create bucket: %w", err)
 	}
 
-	if err := vgwService.ChangeBucketOwner(req.Bucket, req.Owner); err != nil {
+	if err := ChangeBucketOwner(ctx, cfg, req.Bucket, req.Owner); errors.Is(err, ErrServiceAccountGrant) {
+		return summary, err
+	} else if err != nil {
 		return summary, fmt.Errorf("failed to set bucket owner: %w", err)
 	}
 
+	if len(req.Tags) > 0 {
+		if err := vgwService.PutBucketTagging(ctx, req.Bucket, req.Tags); err != nil {
+			return summary, fmt.Errorf("failed to set bucket tags: %w", err)
+		}
+	}
+
 	summary = ProvisionSummary{
 		Access:          req.Access,
 		Secret:          req.Secret,
@@ -106,6 +119,7 @@ func Provision(req ProvisionRequest) (ProvisionSummary, error) {
 		Bucket:          req.Bucket,
 		Quota:           req.Quota,
 		Owner:           req.Owner,
+		Tags:            req.Tags,
 		SecretGenerated: secretGenerated,
 	}
 
@@ -0,0 +1,348 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+// quotaAlertsFile stores the alert state of every bucket in the data directory.
+const quotaAlertsFile = "quota-alerts.json"
+
+// alertTimeout bounds the delivery of one alert to one webhook or SMTP server.
+const alertTimeout = 30 * time.Second
+
+// quotaAlertsState is the on-disk format of quotaAlertsFile.
+type quotaAlertsState struct {
+	Buckets map[string]quotaAlertState `json:"buckets"`
+}
+
+// quotaAlertState is the threshold in effect for a bucket and since when.
+type quotaAlertState struct {
+	Threshold int       `json:"threshold"`
+	Since     time.Time `json:"since"`
+}
+
+func loadQuotaAlerts(cfg *config.Config) (quotaAlertsState, error) {
+	state := quotaAlertsState{}
+	if err := readStateFile(cfg.DataDir, quotaAlertsFile, &state); err != nil {
+		return state, err
+	}
+	if state.Buckets == nil {
+		state.Buckets = map[string]quotaAlertState{}
+	}
+	return state, nil
+}
+
+// applyQuotaAlerts sets the QuotaAlert field of buckets from the recorded
+// alert state. Without quotaAlerts nothing is set; an unreadable state file
+// is logged and ignored, as it must not break bucket listings.
+func applyQuotaAlerts(cfg *config.Config, buckets []models.Bucket) {
+	if cfg.QuotaAlerts == nil {
+		return
+	}
+	state, err := loadQuotaAlerts(cfg)
+	if err != nil {
+		slog.Warn("failed to read quota alert state", "error", err)
+		return
+	}
+	for i := range buckets {
+		buckets[i].QuotaAlert = state.Buckets[buckets[i].Name].Threshold
+	}
+}
+
+// FormatQuotaAlert renders a bucket's quota alert for tables, e.g. "90%", or
+// "-" when no alert is active.
+func FormatQuotaAlert(b models.Bucket) string {
+	if b.QuotaAlert == 0 {
+		return "-"
+	}
+	return strconv.Itoa(b.QuotaAlert) + "%"
+}
+
+// quotaUsage returns a bucket's usage in percent of its quota. It reports
+// false for buckets without a quota and for unknown ("-") sizes.
+func quotaUsage(b models.Bucket) (float64, bool) {
+	quota, ok := ParseZFSSize(b.Quota)
+	if !ok || quota == 0 {
+		return 0, false
+	}
+	used, ok := ParseZFSSize(b.Used)
+	if !ok {
+		return 0, false
+	}
+	return float64(used) * 100 / float64(quota), true
+}
+
+// quotaAlertLevel returns the threshold in effect at usage percent, given the
+// one in effect before (current). A threshold takes effect when usage reaches
+// it and stays in effect until usage falls more than hysteresis points below
+// it. Thresholds must be sorted.
+func quotaAlertLevel(thresholds []int, hysteresis, current int, percent float64) int {
+	level := 0
+	for _, t := range thresholds {
+		if percent >= float64(t) || (t <= current && percent >= float64(t-hysteresis)) {
+			level = t
+		}
+	}
+	return level
+}
+
+// CheckQuotaAlerts compares the usage of the buckets of a site with their
+// quota and calls notify for every bucket that rose to a higher threshold or
+// fell back below all thresholds. The alert state in the data directory is
+// only advanced for alerts that were delivered, so failed ones are retried
+// at the next check; falling to a lower, still active threshold is recorded
+// without a notification. The QuotaAlert field of buckets is updated to the
+// recorded state.
+//
+// Buckets whose sizes are unknown ("-") keep their state; buckets that are
+// gone or have no quota lose it.
+func CheckQuotaAlerts(cfg *config.Config, buckets []models.Bucket, now time.Time, notify func(models.QuotaAlert) error) error {
+	if cfg.QuotaAlerts == nil {
+		return nil
+	}
+	alerts := cfg.QuotaAlerts.WithDefaults()
+	previous, err := loadQuotaAlerts(cfg)
+	if err != nil {
+		return err
+	}
+
+	next := map[string]quotaAlertState{}
+	var errs []error
+	for i, b := range buckets {
+		current := previous.Buckets[b.Name]
+		if b.Quota == "-" || b.Used == "-" {
+			if current.Threshold > 0 {
+				next[b.Name] = current
+			}
+			buckets[i].QuotaAlert = current.Threshold
+			continue
+		}
+		percent, ok := quotaUsage(b)
+		if !ok {
+			buckets[i].QuotaAlert = 0
+			continue
+		}
+
+		level := quotaAlertLevel(alerts.Thresholds, *alerts.Hysteresis, current.Threshold, percent)
+		state := current
+		if level != current.Threshold {
+			state = quotaAlertState{Threshold: level, Since: now.UTC()}
+		}
+		if level > current.Threshold || (level == 0 && current.Threshold > 0) {
+			alert := newQuotaAlert(cfg.Site, b, level, current.Threshold, percent, now)
+			if err := notify(alert); err != nil {
+				errs = append(errs, fmt.Errorf("bucket %s: %w", b.Name, err))
+				state = current
+			}
+		}
+		if state.Threshold > 0 {
+			next[b.Name] = state
+		}
+		buckets[i].QuotaAlert = state.Threshold
+	}
+
+	if !quotaAlertsEqual(previous.Buckets, next) {
+		stateMu.Lock()
+		err := writeStateFile(cfg.DataDir, quotaAlertsFile, quotaAlertsState{Buckets: next})
+		stateMu.Unlock()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func quotaAlertsEqual(a, b map[string]quotaAlertState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, s := range a {
+		if other, ok := b[name]; !ok || !other.Since.Equal(s.Since) || other.Threshold != s.Threshold {
+			return false
+		}
+	}
+	return true
+}
+
+func newQuotaAlert(site string, b models.Bucket, level, previous int, percent float64, now time.Time) models.QuotaAlert {
+	alert := models.QuotaAlert{
+		Time:         now.UTC(),
+		Site:         site,
+		Bucket:       b.Name,
+		Threshold:    level,
+		Previous:     previous,
+		Resolved:     level == 0,
+		UsagePercent: float64(int(percent*10)) / 10,
+		Used:         b.Used,
+		Quota:        b.Quota,
+	}
+	if b.Owner != "-" {
+		alert.Owner = b.Owner
+	}
+
+	subject := "bucket " + b.Name
+	if site != "" && site != config.DefaultSiteName {
+		subject += " on site " + site
+	}
+	if alert.Owner != "" {
+		subject += " (owner " + alert.Owner + ")"
+	}
+	usage := fmt.Sprintf("%s of %s used (%.1f%%)", b.Used, b.Quota, alert.UsagePercent)
+	if alert.Resolved {
+		alert.Message = fmt.Sprintf("Quota alert resolved: %s is below %d%% of its quota again: %s.", subject, previous, usage)
+	} else {
+		alert.Message = fmt.Sprintf("Quota alert: %s reached %d%% of its quota: %s.", subject, level, usage)
+	}
+	return alert
+}
+
+// NotifyQuotaAlert sends an alert to every webhook and the email recipients
+// of the configuration. It fails only if no target received the alert;
+// failures of single targets are logged.
+func NotifyQuotaAlert(ctx context.Context, alerts *config.QuotaAlertsConfig, alert models.QuotaAlert) error {
+	cfg := alerts.WithDefaults()
+	var errs []error
+	delivered := 0
+	for _, w := range cfg.Webhooks {
+		if err := sendAlertWebhook(ctx, w, alert); err != nil {
+			slog.Warn("failed to send quota alert webhook", "bucket", alert.Bucket, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		delivered++
+	}
+	if cfg.Email != nil {
+		if err := sendAlertEmail(ctx, *cfg.Email, alert); err != nil {
+			slog.Warn("failed to send quota alert email", "bucket", alert.Bucket, "error", err)
+			errs = append(errs, err)
+		} else {
+			delivered++
+		}
+	}
+	if delivered == 0 {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// sendAlertWebhook posts the alert as JSON, or as a {"text": ...} message to
+// Slack-compatible webhooks.
+func sendAlertWebhook(ctx context.Context, w config.AlertWebhook, alert models.QuotaAlert) error {
+	var payload any = alert
+	if w.Format == config.WebhookSlack {
+		payload = map[string]string{"text": alert.Message}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, alertTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The URL may carry a secret (e.g. Slack webhooks); name the host only.
+		return fmt.Errorf("webhook %s: request failed", req.URL.Host)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s: unexpected status %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
+
+// sendAlertEmail sends the alert to every recipient in one message. The
+// connection is upgraded with STARTTLS when the server offers it.
+func sendAlertEmail(ctx context.Context, e config.AlertEmail, alert models.QuotaAlert) error {
+	from, err := mail.ParseAddress(e.From)
+	if err != nil {
+		return err
+	}
+	to := make([]string, 0, len(e.To))
+	for _, addr := range e.To {
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return err
+		}
+		to = append(to, parsed.Address)
+	}
+
+	subject := fmt.Sprintf("[vgw-manager] Bucket %s at %d%% of its quota", alert.Bucket, alert.Threshold)
+	if alert.Resolved {
+		subject = fmt.Sprintf("[vgw-manager] Bucket %s quota alert resolved", alert.Bucket)
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from.String())
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", alert.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(alert.Message + "\r\n")
+
+	ctx, cancel := context.WithTimeout(ctx, alertTimeout)
+	defer cancel()
+	addr := net.JoinHostPort(e.SMTPHost, strconv.Itoa(e.SMTPPort))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("smtp %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, e.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp %s: %w", addr, err)
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: e.SMTPHost}); err != nil {
+			return fmt.Errorf("smtp %s: %w", addr, err)
+		}
+	}
+	if e.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.Username, e.Password, e.SMTPHost)); err != nil {
+			return fmt.Errorf("smtp %s: %w", addr, err)
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp %s: %w", addr, err)
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("smtp %s: recipient %s: %w", addr, rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp %s: %w", addr, err)
+	}
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		return fmt.Errorf("smtp %s: %w", addr, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp %s: %w", addr, err)
+	}
+	return c.Quit()
+}
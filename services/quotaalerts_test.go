@@ -0,0 +1,256 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+func TestQuotaAlertLevel(t *testing.T) {
+	thresholds := []int{80, 90, 100}
+	tests := []struct {
+		name    string
+		current int
+		percent float64
+		want    int
+	}{
+		{"below all", 0, 50, 0},
+		{"reaches first", 0, 80, 80},
+		{"jumps to highest crossed", 0, 95, 90},
+		{"over quota", 90, 120, 100},
+		{"stays within hysteresis", 80, 76, 80},
+		{"re-arms below hysteresis", 80, 74.9, 0},
+		{"falls to lower threshold", 100, 92, 90},
+		{"falls within hysteresis of lower threshold", 100, 78, 80},
+		{"lower threshold does not hold without having fired", 0, 78, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quotaAlertLevel(thresholds, 5, tt.current, tt.percent); got != tt.want {
+				t.Errorf("quotaAlertLevel(current %d, %.1f%%) = %d, want %d", tt.current, tt.percent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckQuotaAlerts(t *testing.T) {
+	cfg := &config.Config{
+		Site:        config.DefaultSiteName,
+		DataDir:     t.TempDir(),
+		QuotaAlerts: &config.QuotaAlertsConfig{Thresholds: []int{80, 90, 100}},
+	}
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	bucket := func(used string) []models.Bucket {
+		return []models.Bucket{
+			{Name: "acme", Quota: "100G", Used: used, Owner: "alice"},
+			{Name: "unlimited", Quota: "none", Used: "5T"},
+		}
+	}
+	var sent []models.QuotaAlert
+	record := func(a models.QuotaAlert) error {
+		sent = append(sent, a)
+		return nil
+	}
+
+	steps := []struct {
+		used      string
+		fail      bool
+		wantSent  []int // thresholds notified, -1 for resolved
+		wantAlert int
+	}{
+		{"50G", false, nil, 0},
+		{"85G", false, []int{80}, 80},
+		{"88G", false, nil, 80},
+		{"95G", true, nil, 80}, // delivery fails, retried at the next check
+		{"95G", false, []int{90}, 90},
+		{"87G", false, nil, 90}, // within the hysteresis of 90
+		{"84G", false, nil, 80}, // below 90 by more than 5 points, no notification
+		{"91G", false, []int{90}, 90},
+		{"-", false, nil, 90}, // unknown usage keeps the state
+		{"10G", false, []int{-1}, 0},
+	}
+	for i, step := range steps {
+		sent = nil
+		notify := record
+		if step.fail {
+			notify = func(models.QuotaAlert) error { return errors.New("webhook down") }
+		}
+		buckets := bucket(step.used)
+		err := CheckQuotaAlerts(cfg, buckets, now.Add(time.Duration(i)*time.Minute), notify)
+		if step.fail != (err != nil) {
+			t.Fatalf("step %d (%s): CheckQuotaAlerts() error = %v", i, step.used, err)
+		}
+		var got []int
+		for _, a := range sent {
+			if a.Resolved {
+				got = append(got, -1)
+				continue
+			}
+			got = append(got, a.Threshold)
+		}
+		if len(got) != len(step.wantSent) || (len(got) > 0 && got[0] != step.wantSent[0]) {
+			t.Errorf("step %d (%s): notified %v, want %v", i, step.used, got, step.wantSent)
+		}
+		if buckets[0].QuotaAlert != step.wantAlert || buckets[1].QuotaAlert != 0 {
+			t.Errorf("step %d (%s): QuotaAlert = %d/%d, want %d/0", i, step.used, buckets[0].QuotaAlert, buckets[1].QuotaAlert, step.wantAlert)
+		}
+
+		// Listings see the recorded state.
+		listed := bucket(step.used)
+		applyQuotaAlerts(cfg, listed)
+		if listed[0].QuotaAlert != step.wantAlert {
+			t.Errorf("step %d (%s): listed QuotaAlert = %d, want %d", i, step.used, listed[0].QuotaAlert, step.wantAlert)
+		}
+	}
+
+	sent = nil
+	CheckQuotaAlerts(cfg, bucket("99G"), now, record)
+	if len(sent) != 1 || sent[0].Owner != "alice" || sent[0].UsagePercent != 99 ||
+		!strings.Contains(sent[0].Message, "bucket acme (owner alice) reached 90% of its quota: 99G of 100G used (99.0%)") {
+		t.Errorf("alert = %+v", sent)
+	}
+
+	// A deleted bucket loses its state.
+	if err := CheckQuotaAlerts(cfg, nil, now, record); err != nil {
+		t.Fatal(err)
+	}
+	state, err := loadQuotaAlerts(cfg)
+	if err != nil || len(state.Buckets) != 0 {
+		t.Errorf("state after deletion = %+v, %v", state, err)
+	}
+}
+
+func TestNotifyQuotaAlert(t *testing.T) {
+	received := make(chan string, 2)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("webhook body: %v", err)
+		}
+		if text, ok := body["text"].(string); ok {
+			received <- "slack:" + text
+		} else {
+			received <- r.URL.Path + ":" + body["bucket"].(string) + ":" + strconv.Itoa(int(body["threshold"].(float64)))
+		}
+	}))
+	defer webhook.Close()
+
+	smtpAddr, mails := startSMTPStandIn(t)
+	host, port, _ := net.SplitHostPort(smtpAddr)
+	portNum, _ := strconv.Atoi(port)
+
+	alerts := &config.QuotaAlertsConfig{
+		Webhooks: []config.AlertWebhook{
+			{URL: webhook.URL + "/generic"},
+			{URL: webhook.URL + "/slack", Format: config.WebhookSlack},
+		},
+		Email: &config.AlertEmail{SMTPHost: host, SMTPPort: portNum, From: "vgw <vgw@example.com>", To: []string{"ops@example.com", "Storage <storage@example.com>"}},
+	}
+	alert := newQuotaAlert("default", models.Bucket{Name: "acme", Quota: "100G", Used: "92G", Owner: "-"}, 90, 80, 92, time.Now())
+	if err := NotifyQuotaAlert(context.Background(), alerts, alert); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]bool{<-received: true, <-received: true}
+	if !got["/generic:acme:90"] || !got["slack:Quota alert: bucket acme reached 90% of its quota: 92G of 100G used (92.0%)."] {
+		t.Errorf("webhooks received %v", got)
+	}
+	m := <-mails
+	if m.from != "vgw@example.com" || strings.Join(m.to, ",") != "ops@example.com,storage@example.com" ||
+		!strings.Contains(m.data, "Subject: [vgw-manager] Bucket acme at 90% of its quota\r\n") ||
+		!strings.Contains(m.data, "Quota alert: bucket acme reached 90%") {
+		t.Errorf("mail = %+v", m)
+	}
+
+	// Delivery fails only if no target received the alert.
+	webhook.Close()
+	alerts.Email = nil
+	if err := NotifyQuotaAlert(context.Background(), alerts, alert); err == nil {
+		t.Error("NotifyQuotaAlert() succeeded without a reachable target")
+	}
+}
+
+type standInMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTPStandIn serves just enough SMTP for net/smtp to deliver one
+// message per connection, without STARTTLS or authentication.
+func startSMTPStandIn(t *testing.T) (string, <-chan standInMail) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	mails := make(chan standInMail, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTPStandIn(conn, mails)
+		}
+	}()
+	return ln.Addr().String(), mails
+}
+
+func serveSMTPStandIn(conn net.Conn, mails chan<- standInMail) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	reply("220 stand-in ESMTP")
+	var m standInMail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 stand-in")
+		case "MAIL":
+			m.from = strings.Trim(strings.TrimPrefix(cmd, "MAIL FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			m.to = append(m.to, strings.Trim(strings.TrimPrefix(cmd, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			m.data = data.String()
+			mails <- m
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
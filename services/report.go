@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// Report defaults used when ReportOptions leaves a field unset.
+const (
+	DefaultReportTopPrefixes = 10
+	DefaultReportPrefixDepth = 1
+)
+
+// ReportOptions controls how a bucket usage report is aggregated.
+type ReportOptions struct {
+	// Prefix limits the report to keys under this prefix.
+	Prefix string
+	// PrefixDepth is the number of "/"-separated key segments that make up a
+	// reported prefix (1 = top-level folders).
+	PrefixDepth int
+	// TopPrefixes is the number of largest prefixes to include.
+	TopPrefixes int
+}
+
+// PrefixUsage is the object count and size of one key prefix.
+type PrefixUsage struct {
+	Prefix  string `json:"prefix"`
+	Objects int64  `json:"objects"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// AgeBucket counts objects whose last modification falls in an age range.
+type AgeBucket struct {
+	Label   string `json:"label"`
+	MinDays int    `json:"minDays"`
+	MaxDays int    `json:"maxDays,omitempty"` // 0 = no upper bound
+	Objects int64  `json:"objects"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// BucketReport is an object-level usage breakdown of a bucket.
+type BucketReport struct {
+	Bucket          string        `json:"bucket"`
+	Prefix          string        `json:"prefix,omitempty"`
+	Objects         int64         `json:"objects"`
+	Bytes           int64         `json:"bytes"`
+	LargestPrefixes []PrefixUsage `json:"largestPrefixes"`
+	TotalPrefixes   int           `json:"totalPrefixes"`
+	AgeHistogram    []AgeBucket   `json:"ageHistogram"`
+	OldestObject    *time.Time    `json:"oldestObject,omitempty"`
+	NewestObject    *time.Time    `json:"newestObject,omitempty"`
+	GeneratedAt     time.Time     `json:"generatedAt"`
+}
+
+// Share returns bytes as a percentage of the report's total size.
+func (r *BucketReport) Share(bytes int64) float64 {
+	if r.Bytes == 0 {
+		return 0
+	}
+	return float64(bytes) * 100 / float64(r.Bytes)
+}
+
+// reportAgeRanges are the age histogram ranges as [min, max) days; max 0 is open-ended.
+var reportAgeRanges = []struct {
+	label    string
+	min, max int
+}{
+	{"< 1 day", 0, 1},
+	{"1-7 days", 1, 7},
+	{"7-30 days", 7, 30},
+	{"30-90 days", 30, 90},
+	{"90-365 days", 90, 365},
+	{"> 1 year", 365, 0},
+}
+
+// RootPrefixLabel is how objects that are not under any prefix are reported.
+const RootPrefixLabel = "(root)"
+
+// GenerateBucketReport walks every object of a bucket via ListObjectsV2 and
+// aggregates counts, sizes, the largest prefixes and an age histogram.
+// Listing through the gateway (rather than the ZFS mountpoint) keeps the
+// numbers identical to what S3 clients see.
+func GenerateBucketReport(ctx context.Context, cfg *config.Config, bucket string, opts ReportOptions) (*BucketReport, error) {
+	vgwService := NewVersityGWService(cfg)
+	builder := newReportBuilder(bucket, opts, time.Now())
+
+	token := ""
+	for {
+		listing, err := vgwService.ListObjects(ctx, bucket, opts.Prefix, "", token, 1000)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range listing.Objects {
+			builder.add(obj)
+		}
+		if !listing.IsTruncated || listing.NextContinuationToken == "" {
+			break
+		}
+		token = listing.NextContinuationToken
+	}
+
+	return builder.build(), nil
+}
+
+// reportBuilder accumulates objects into a BucketReport.
+type reportBuilder struct {
+	report   BucketReport
+	opts     ReportOptions
+	now      time.Time
+	prefixes map[string]*PrefixUsage
+}
+
+func newReportBuilder(bucket string, opts ReportOptions, now time.Time) *reportBuilder {
+	if opts.PrefixDepth <= 0 {
+		opts.PrefixDepth = DefaultReportPrefixDepth
+	}
+	if opts.TopPrefixes <= 0 {
+		opts.TopPrefixes = DefaultReportTopPrefixes
+	}
+
+	b := &reportBuilder{
+		report: BucketReport{
+			Bucket:      bucket,
+			Prefix:      opts.Prefix,
+			GeneratedAt: now,
+		},
+		opts:     opts,
+		now:      now,
+		prefixes: make(map[string]*PrefixUsage),
+	}
+	for _, r := range reportAgeRanges {
+		b.report.AgeHistogram = append(b.report.AgeHistogram, AgeBucket{Label: r.label, MinDays: r.min, MaxDays: r.max})
+	}
+	return b
+}
+
+func (b *reportBuilder) add(obj ObjectInfo) {
+	b.report.Objects++
+	b.report.Bytes += obj.Size
+
+	prefix := keyPrefix(strings.TrimPrefix(obj.Key, b.opts.Prefix), b.opts.PrefixDepth)
+	if prefix != RootPrefixLabel {
+		prefix = b.opts.Prefix + prefix
+	}
+	usage, ok := b.prefixes[prefix]
+	if !ok {
+		usage = &PrefixUsage{Prefix: prefix}
+		b.prefixes[prefix] = usage
+	}
+	usage.Objects++
+	usage.Bytes += obj.Size
+
+	if !obj.LastModified.IsZero() {
+		modified := obj.LastModified
+		if b.report.OldestObject == nil || modified.Before(*b.report.OldestObject) {
+			b.report.OldestObject = &modified
+		}
+		if b.report.NewestObject == nil || modified.After(*b.report.NewestObject) {
+			b.report.NewestObject = &modified
+		}
+	}
+
+	days := int(b.now.Sub(obj.LastModified).Hours() / 24)
+	if days < 0 {
+		days = 0 // clock skew between gateway and manager
+	}
+	for i := range b.report.AgeHistogram {
+		h := &b.report.AgeHistogram[i]
+		if days >= h.MinDays && (h.MaxDays == 0 || days < h.MaxDays) {
+			h.Objects++
+			h.Bytes += obj.Size
+			break
+		}
+	}
+}
+
+func (b *reportBuilder) build() *BucketReport {
+	all := make([]PrefixUsage, 0, len(b.prefixes))
+	for _, usage := range b.prefixes {
+		all = append(all, *usage)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Bytes != all[j].Bytes {
+			return all[i].Bytes > all[j].Bytes
+		}
+		return all[i].Prefix < all[j].Prefix
+	})
+
+	b.report.TotalPrefixes = len(all)
+	if len(all) > b.opts.TopPrefixes {
+		all = all[:b.opts.TopPrefixes]
+	}
+	b.report.LargestPrefixes = all
+	return &b.report
+}
+
+// keyPrefix returns the first depth "/"-separated segments of key (with a
+// trailing slash), or RootPrefixLabel for keys without a folder at that level.
+func keyPrefix(key string, depth int) string {
+	end := 0
+	for i := 0; i < depth; i++ {
+		idx := strings.Index(key[end:], "/")
+		if idx < 0 {
+			break
+		}
+		end += idx + 1
+	}
+	if end == 0 {
+		return RootPrefixLabel
+	}
+	return key[:end]
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReportBuilder(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	b := newReportBuilder("data", ReportOptions{TopPrefixes: 2}, now)
+	b.add(ObjectInfo{Key: "logs/a.log", Size: 100, LastModified: now.Add(-2 * time.Hour)})
+	b.add(ObjectInfo{Key: "logs/2024/b.log", Size: 300, LastModified: now.AddDate(0, 0, -10)})
+	b.add(ObjectInfo{Key: "img/c.png", Size: 50, LastModified: now.AddDate(-2, 0, 0)})
+	b.add(ObjectInfo{Key: "readme.txt", Size: 10, LastModified: now.AddDate(0, 0, -3)})
+	report := b.build()
+
+	if report.Objects != 4 || report.Bytes != 460 {
+		t.Fatalf("totals = %d objects / %d bytes, want 4 / 460", report.Objects, report.Bytes)
+	}
+	if report.TotalPrefixes != 3 || len(report.LargestPrefixes) != 2 {
+		t.Fatalf("prefixes = %d total, %d listed; want 3, 2", report.TotalPrefixes, len(report.LargestPrefixes))
+	}
+	if top := report.LargestPrefixes[0]; top.Prefix != "logs/" || top.Objects != 2 || top.Bytes != 400 {
+		t.Fatalf("largest prefix = %+v", top)
+	}
+
+	wantHistogram := []int64{1, 1, 1, 0, 0, 1}
+	for i, want := range wantHistogram {
+		if got := report.AgeHistogram[i].Objects; got != want {
+			t.Errorf("histogram[%s] = %d, want %d", report.AgeHistogram[i].Label, got, want)
+		}
+	}
+	if !report.OldestObject.Equal(now.AddDate(-2, 0, 0)) {
+		t.Errorf("oldest object = %v", report.OldestObject)
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	cases := []struct {
+		key   string
+		depth int
+		want  string
+	}{
+		{"a/b/c.txt", 1, "a/"},
+		{"a/b/c.txt", 2, "a/b/"},
+		{"a/b/c.txt", 5, "a/b/"},
+		{"c.txt", 1, RootPrefixLabel},
+	}
+	for _, c := range cases {
+		if got := keyPrefix(c.key, c.depth); got != c.want {
+			t.Errorf("keyPrefix(%q, %d) = %q, want %q", c.key, c.depth, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// Backoff between attempts: exponential from retryBaseDelay, capped at
+// retryMaxDelay, with full jitter.
+var (
+	retryBaseDelay = 200 * time.Millisecond
+	retryMaxDelay  = 5 * time.Second
+)
+
+// do signs and sends a request, retrying idempotent methods on connection
+// errors and 5xx responses up to the configured MaxAttempts times. The body is rebuilt
+// from payload and the request re-signed on every attempt. Cancelling the
+// request context stops both the request and the retries. The caller must
+// close the body of the returned response. The call is recorded in the
+// gateway metrics.
+func (s *VersityGWService) do(client *http.Client, httpReq *http.Request, payload []byte) (*http.Response, error) {
+	start := time.Now()
+	resp, err := s.doWithRetries(client, httpReq, payload)
+	recordGatewayCall(s.cfg.Site, httpReq, resp, err, time.Since(start))
+	return resp, err
+}
+
+func (s *VersityGWService) doWithRetries(client *http.Client, httpReq *http.Request, payload []byte) (*http.Response, error) {
+	if s.transportErr != nil {
+		return nil, s.transportErr
+	}
+
+	ctx := httpReq.Context()
+	attempts := s.cfg.MaxAttempts
+	if attempts < 1 || !isIdempotent(httpReq.Method) {
+		attempts = 1
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := httpReq.Clone(ctx)
+		if len(payload) > 0 {
+			attemptReq.Body = io.NopCloser(bytes.NewReader(payload))
+		}
+		if err := s.signRequest(attemptReq, payload); err != nil {
+			return nil, err
+		}
+
+		resp, err := client.Do(attemptReq)
+		if ctx.Err() != nil {
+			if err == nil {
+				resp.Body.Close()
+			}
+			return nil, fmt.Errorf("failed to send request: %w", ctx.Err())
+		}
+		retry := err != nil || resp.StatusCode >= http.StatusInternalServerError
+		if !retry || attempt+1 >= attempts {
+			if err != nil {
+				return nil, fmt.Errorf("failed to send request: %w", err)
+			}
+			return resp, nil
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to send request: %w", ctx.Err())
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+}
+
+// isIdempotent reports whether a request with the given method can safely be
+// sent again. The admin API uses PATCH, which is never retried.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// retryDelay returns a random delay in (0, min(retryBaseDelay*2^attempt, retryMaxDelay)].
+func retryDelay(attempt int) time.Duration {
+	backoff := retryMaxDelay
+	if attempt < 16 && retryBaseDelay<<attempt < retryMaxDelay {
+		backoff = retryBaseDelay << attempt
+	}
+	return time.Duration(rand.Int64N(int64(backoff))) + 1
+}
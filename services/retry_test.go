@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := 0; attempt < 40; attempt++ {
+		limit := retryMaxDelay
+		if attempt < 5 {
+			limit = retryBaseDelay << attempt
+		}
+		for i := 0; i < 100; i++ {
+			if d := retryDelay(attempt); d <= 0 || d > limit {
+				t.Fatalf("retryDelay(%d) = %v, want (0, %v]", attempt, d, limit)
+			}
+		}
+	}
+}
+
+func TestDoRetries(t *testing.T) {
+	baseDelay := retryBaseDelay
+	retryBaseDelay = time.Millisecond
+	defer func() { retryBaseDelay = baseDelay }()
+	cfg := &config.Config{RequestTimeout: time.Second, MaxAttempts: 3}
+
+	tests := []struct {
+		name     string
+		method   string
+		failures int32
+		wantCode int
+		wantHits int32
+	}{
+		{"GET recovers", http.MethodGet, 2, http.StatusOK, 3},
+		{"GET gives up", http.MethodGet, 5, http.StatusServiceUnavailable, 3},
+		{"PUT recovers with body", http.MethodPut, 1, http.StatusOK, 2},
+		{"PATCH is not retried", http.MethodPatch, 1, http.StatusServiceUnavailable, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := hits.Add(1)
+				if r.Method == http.MethodPut {
+					body := make([]byte, 16)
+					if k, _ := r.Body.Read(body); string(body[:k]) != "payload" {
+						t.Errorf("attempt %d body = %q, want %q", n, body[:k], "payload")
+					}
+				}
+				if n <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+
+			req, _ := http.NewRequestWithContext(context.Background(), tt.method, server.URL, strings.NewReader("payload"))
+			resp, err := NewVersityGWService(cfg).do(server.Client(), req, []byte("payload"))
+			if err != nil {
+				t.Fatalf("do() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantCode || hits.Load() != tt.wantHits {
+				t.Errorf("do() = %d after %d requests, want %d after %d", resp.StatusCode, hits.Load(), tt.wantCode, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestDoStopsOnCancel(t *testing.T) {
+	baseDelay := retryBaseDelay
+	retryBaseDelay = time.Hour
+	defer func() { retryBaseDelay = baseDelay }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	cfg := &config.Config{RequestTimeout: time.Second, MaxAttempts: 3}
+	if _, err := NewVersityGWService(cfg).do(server.Client(), req, nil); err == nil || ctx.Err() == nil {
+		t.Fatalf("do() error = %v, want context deadline", err)
+	}
+}
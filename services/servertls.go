@@ -0,0 +1,97 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certReloader serves a certificate and key from files, loading them again
+// when either file's modification time changes. If the new files do not load
+// (e.g. the certificate was replaced before the key), the previous
+// certificate stays in use until the files change again.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu     sync.Mutex
+	cert   *tls.Certificate
+	loaded [2]time.Time // modification times of the loaded cert and key
+	tried  [2]time.Time // modification times of the last attempt
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload loads the certificate if the files changed since the last load.
+func (r *certReloader) reload() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return err
+	}
+	modTimes := [2]time.Time{certInfo.ModTime(), keyInfo.ModTime()}
+	if r.cert != nil && (modTimes == r.loaded || modTimes == r.tried) {
+		return nil
+	}
+	r.tried = modTimes
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load API TLS certificate: %w", err)
+	}
+	if r.cert != nil {
+		slog.Info("API TLS certificate reloaded", "certFile", r.certFile)
+	}
+	r.cert, r.loaded = &cert, modTimes
+	return nil
+}
+
+// getCertificate implements tls.Config.GetCertificate.
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.reload(); err != nil {
+		slog.Warn("API TLS certificate reload failed, keeping the previous one", "error", err)
+	}
+	return r.cert, nil
+}
+
+// NewServerTLSConfig builds the TLS configuration of the API server. The
+// certificate is reloaded when its files change. With clientCAFile, clients
+// must present a certificate signed by one of its CAs.
+func NewServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	reloader, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API TLS client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in API TLS client CA file %s", clientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
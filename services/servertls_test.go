@@ -0,0 +1,194 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a certificate and key, signed by parent (self-signed if nil).
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCert(t *testing.T, name string, isCA bool, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (c *testCert) keyPEM(t *testing.T) []byte {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	cert, err := tls.X509KeyPair(c.pem, c.keyPEM(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// writeTestCert writes the certificate and key, moving their modification
+// time forward so a reload notices the change.
+func writeTestCert(t *testing.T, c *testCert, certFile, keyFile string, modTime time.Time) {
+	t.Helper()
+	for file, data := range map[string][]byte{certFile: c.pem, keyFile: c.keyPEM(t)} {
+		if err := os.WriteFile(file, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// handshake connects to a TLS listener and returns the server certificate.
+func handshake(t *testing.T, serverConfig *tls.Config, clientConfig *tls.Config) (*x509.Certificate, error) {
+	t.Helper()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// TLS 1.3 reports a rejected client certificate on the first read.
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+func TestNewServerTLSConfigReloadsCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	ca := newTestCert(t, "ca", true, nil)
+	first := newTestCert(t, "localhost", false, ca)
+	second := newTestCert(t, "localhost", false, ca)
+	start := time.Now().Add(-time.Hour)
+	writeTestCert(t, first, certFile, keyFile, start)
+
+	serverConfig, err := NewServerTLSConfig(certFile, keyFile, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+
+	got, err := handshake(t, serverConfig, clientConfig)
+	if err != nil || !got.Equal(first.cert) {
+		t.Fatalf("handshake() = %v, %v, want the first certificate", got, err)
+	}
+
+	writeTestCert(t, second, certFile, keyFile, start.Add(time.Minute))
+	got, err = handshake(t, serverConfig, clientConfig)
+	if err != nil || !got.Equal(second.cert) {
+		t.Fatalf("handshake() after renewal = %v, %v, want the second certificate", got, err)
+	}
+
+	// A certificate without its key does not replace the working one.
+	if err := os.WriteFile(certFile, first.pem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err = handshake(t, serverConfig, clientConfig)
+	if err != nil || !got.Equal(second.cert) {
+		t.Errorf("handshake() with mismatched key = %v, %v, want the second certificate", got, err)
+	}
+}
+
+func TestNewServerTLSConfigClientCA(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	caFile := filepath.Join(dir, "client-ca.pem")
+	ca := newTestCert(t, "ca", true, nil)
+	writeTestCert(t, newTestCert(t, "localhost", false, ca), certFile, keyFile, time.Now())
+	clientCA := newTestCert(t, "client-ca", true, nil)
+	if err := os.WriteFile(caFile, clientCA.pem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	serverConfig, err := NewServerTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	tests := []struct {
+		name   string
+		certs  []tls.Certificate
+		wantOK bool
+	}{
+		{"no client certificate", nil, false},
+		{"certificate from another CA", []tls.Certificate{newTestCert(t, "portal", false, ca).tlsCertificate(t)}, false},
+		{"certificate from the client CA", []tls.Certificate{newTestCert(t, "portal", false, clientCA).tlsCertificate(t)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handshake(t, serverConfig, &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: tt.certs})
+			if (err == nil) != tt.wantOK {
+				t.Errorf("handshake() error = %v, want success %v", err, tt.wantOK)
+			}
+		})
+	}
+
+	if _, err := NewServerTLSConfig(certFile, keyFile, certFile+".missing"); err == nil {
+		t.Error("NewServerTLSConfig() accepted a missing client CA file")
+	}
+}
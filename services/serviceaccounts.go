@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+// serviceAccountsFile stores the parent/child relationships in the data directory.
+const serviceAccountsFile = "service-accounts.json"
+
+// serviceAccountsSid is the bucket policy statement granting a parent's
+// buckets to its service accounts.
+const serviceAccountsSid = managedSidPrefix + "ServiceAccounts"
+
+// serviceAccountsState is the on-disk format of serviceAccountsFile.
+type serviceAccountsState struct {
+	ServiceAccounts map[string]models.ServiceAccount `json:"serviceAccounts"`
+}
+
+// ServiceAccountRequest describes a service account to create.
+type ServiceAccountRequest struct {
+	Parent      string `json:"parent"`
+	Access      string `json:"access"` // generated from the parent if empty
+	Secret      string `json:"secret"` // generated if empty
+	Description string `json:"description"`
+}
+
+// ServiceAccountCredentials is a service account together with its secret,
+// returned on creation and rotation.
+type ServiceAccountCredentials struct {
+	models.ServiceAccount
+	Secret string `json:"secret"`
+	// Buckets are the parent's buckets the service account was granted.
+	Buckets []string `json:"buckets"`
+}
+
+func loadServiceAccounts(cfg *config.Config) (serviceAccountsState, error) {
+	state := serviceAccountsState{}
+	if err := readStateFile(cfg.DataDir, serviceAccountsFile, &state); err != nil {
+		return state, err
+	}
+	if state.ServiceAccounts == nil {
+		state.ServiceAccounts = map[string]models.ServiceAccount{}
+	}
+	return state, nil
+}
+
+// ListServiceAccounts returns the service accounts of parent (all service
+// accounts when parent is empty), sorted by access key.
+func ListServiceAccounts(cfg *config.Config, parent string) ([]models.ServiceAccount, error) {
+	state, err := loadServiceAccounts(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return serviceAccountsOf(state, parent), nil
+}
+
+func serviceAccountsOf(state serviceAccountsState, parent string) []models.ServiceAccount {
+	accounts := []models.ServiceAccount{}
+	for _, account := range state.ServiceAccounts {
+		if parent == "" || account.Parent == parent {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Access < accounts[j].Access
+	})
+	return accounts
+}
+
+// GetServiceAccount returns the service account with the given access key.
+func GetServiceAccount(cfg *config.Config, access string) (*models.ServiceAccount, error) {
+	state, err := loadServiceAccounts(cfg)
+	if err != nil {
+		return nil, err
+	}
+	account, ok := state.ServiceAccounts[access]
+	if !ok {
+		return nil, fmt.Errorf("service account not found: %s", access)
+	}
+	return &account, nil
+}
+
+// CreateServiceAccount creates a gateway user under a parent user (same role
+// restrictions as a plain "user", same UID/GID/project as the parent), records
+// the relationship and grants it the parent's buckets. If the grant fails, the
+// user is removed again.
+func CreateServiceAccount(ctx context.Context, cfg *config.Config, req ServiceAccountRequest) (*ServiceAccountCredentials, error) {
+	if req.Parent == "" {
+		return nil, fmt.Errorf("parent access key is required")
+	}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := loadServiceAccounts(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := state.ServiceAccounts[req.Parent]; ok {
+		return nil, fmt.Errorf("%s is itself a service account; service accounts cannot be nested", req.Parent)
+	}
+
+	parent, err := NewUserService(cfg).GetUser(req.Parent)
+	if err != nil {
+		return nil, fmt.Errorf("parent user: %w", err)
+	}
+
+	if req.Access == "" {
+		suffix, err := randomSuffix()
+		if err != nil {
+			return nil, err
+		}
+		req.Access = req.Parent + "-" + suffix
+	}
+	if req.Access == req.Parent {
+		return nil, fmt.Errorf("service account access key must differ from the parent's")
+	}
+	if _, err := NewUserService(cfg).GetUser(req.Access); err == nil {
+		return nil, fmt.Errorf("user already exists: %s", req.Access)
+	}
+	if req.Secret == "" {
+		req.Secret = GenerateSecretKey()
+	}
+
+	vgwService := NewVersityGWService(cfg)
+	userReq := models.UserCreateRequest{
+		Access:    req.Access,
+		Secret:    req.Secret,
+		Role:      "user",
+		UserID:    parent.UserID,
+		GroupID:   parent.GroupID,
+		ProjectID: parent.ProjectID,
+	}
+	if err := vgwService.CreateUser(ctx, userReq); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	account := models.ServiceAccount{
+		Access:      req.Access,
+		Parent:      req.Parent,
+		Description: req.Description,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+	state.ServiceAccounts[account.Access] = account
+
+	buckets, err := syncServiceAccountGrants(ctx, vgwService, state, req.Parent)
+	if err == nil {
+		err = writeStateFile(cfg.DataDir, serviceAccountsFile, state)
+	}
+	if err != nil {
+		// Roll back even if ctx was cancelled.
+		cleanupCtx := context.WithoutCancel(ctx)
+		delete(state.ServiceAccounts, account.Access)
+		_, _ = syncServiceAccountGrants(cleanupCtx, vgwService, state, req.Parent)
+		_ = vgwService.DeleteUser(cleanupCtx, account.Access)
+		return nil, fmt.Errorf("failed to grant parent buckets (service account removed): %w", err)
+	}
+
+	return &ServiceAccountCredentials{ServiceAccount: account, Secret: req.Secret, Buckets: buckets}, nil
+}
+
+// RevokeServiceAccount deletes a service account's gateway user, forgets it
+// and removes it from the parent's bucket policies.
+func RevokeServiceAccount(ctx context.Context, cfg *config.Config, access string) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := loadServiceAccounts(cfg)
+	if err != nil {
+		return err
+	}
+	account, ok := state.ServiceAccounts[access]
+	if !ok {
+		return fmt.Errorf("service account not found: %s", access)
+	}
+
+	vgwService := NewVersityGWService(cfg)
+	if err := vgwService.DeleteUser(ctx, access); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	delete(state.ServiceAccounts, access)
+	if err := writeStateFile(cfg.DataDir, serviceAccountsFile, state); err != nil {
+		return err
+	}
+	if _, err := syncServiceAccountGrants(ctx, vgwService, state, account.Parent); err != nil {
+		return fmt.Errorf("user deleted, but updating bucket policies failed: %w", err)
+	}
+	return nil
+}
+
+// RotateServiceAccount replaces a service account's secret with a generated one.
+func RotateServiceAccount(ctx context.Context, cfg *config.Config, access string) (*ServiceAccountCredentials, error) {
+	account, err := GetServiceAccount(cfg, access)
+	if err != nil {
+		return nil, err
+	}
+	user, err := NewUserService(cfg).GetUser(access)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := GenerateSecretKey()
+	updateReq := models.UserUpdateRequest{
+		Access:    user.Access,
+		Secret:    secret,
+		Role:      user.Role,
+		UserID:    user.UserID,
+		GroupID:   user.GroupID,
+		ProjectID: user.ProjectID,
+	}
+	if err := NewVersityGWService(cfg).UpdateUser(ctx, updateReq); err != nil {
+		return nil, fmt.Errorf("failed to rotate secret: %w", err)
+	}
+
+	return &ServiceAccountCredentials{ServiceAccount: *account, Secret: secret}, nil
+}
+
+// SyncServiceAccountGrants rewrites the service account grant on every bucket
+// owned by parent. ChangeBucketOwner keeps the grant of the bucket it changes
+// up to date; run this to repair grants after buckets changed owner outside
+// vgw-manager. Returns the buckets that were updated.
+func SyncServiceAccountGrants(ctx context.Context, cfg *config.Config, parent string) ([]string, error) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := loadServiceAccounts(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return syncServiceAccountGrants(ctx, NewVersityGWService(cfg), state, parent)
+}
+
+func syncServiceAccountGrants(ctx context.Context, vgwService *VersityGWService, state serviceAccountsState, parent string) ([]string, error) {
+	principals := []string{}
+	for _, account := range serviceAccountsOf(state, parent) {
+		principals = append(principals, account.Access)
+	}
+
+	buckets, err := bucketsOwnedBy(ctx, vgwService, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	drop := func(sid string) bool { return sid == serviceAccountsSid }
+	for _, bucket := range buckets {
+		var add []policyStatement
+		if len(principals) > 0 {
+			add = []policyStatement{bucketGrantStatement(serviceAccountsSid, bucket, principals)}
+		}
+		if err := vgwService.rewriteBucketPolicy(ctx, bucket, drop, add); err != nil {
+			return nil, fmt.Errorf("bucket %s: %w", bucket, err)
+		}
+	}
+	return buckets, nil
+}
+
+// syncBucketServiceAccountGrant rewrites the service account grant of a
+// bucket that now belongs to owner: the owner's service accounts are granted
+// the bucket, and those of a previous owner lose it.
+func syncBucketServiceAccountGrant(ctx context.Context, vgwService *VersityGWService, bucket, owner string) error {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	state, err := loadServiceAccounts(vgwService.cfg)
+	if err != nil {
+		return err
+	}
+	principals := []string{}
+	for _, account := range serviceAccountsOf(state, owner) {
+		principals = append(principals, account.Access)
+	}
+
+	drop := func(sid string) bool { return sid == serviceAccountsSid }
+	var add []policyStatement
+	if len(principals) > 0 {
+		add = []policyStatement{bucketGrantStatement(serviceAccountsSid, bucket, principals)}
+	}
+	return vgwService.rewriteBucketPolicy(ctx, bucket, drop, add)
+}
+
+// bucketsOwnedBy returns the names of the buckets owned by owner (per ACL).
+func bucketsOwnedBy(ctx context.Context, vgwService *VersityGWService, owner string) ([]string, error) {
+	infos, err := vgwService.ListBuckets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list buckets: %w", err)
+	}
+
+	buckets := []string{}
+	for _, info := range infos {
+		bucketOwner := info.Owner
+		if trueOwner, err := vgwService.GetBucketOwner(ctx, info.Name); err == nil && trueOwner != "" {
+			bucketOwner = trueOwner
+		}
+		if bucketOwner == owner {
+			buckets = append(buckets, info.Name)
+		}
+	}
+	return buckets, nil
+}
+
+// randomSuffix returns 8 random hex characters for generated access keys.
+func randomSuffix() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate access key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
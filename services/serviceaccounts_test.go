@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/models"
+)
+
+func TestServiceAccountsOf(t *testing.T) {
+	state := serviceAccountsState{ServiceAccounts: map[string]models.ServiceAccount{
+		"alice-web": {Access: "alice-web", Parent: "alice"},
+		"alice-ci":  {Access: "alice-ci", Parent: "alice"},
+		"bob-ci":    {Access: "bob-ci", Parent: "bob"},
+	}}
+
+	got := serviceAccountsOf(state, "alice")
+	if len(got) != 2 || got[0].Access != "alice-ci" || got[1].Access != "alice-web" {
+		t.Errorf("serviceAccountsOf(alice) = %+v", got)
+	}
+	if all := serviceAccountsOf(state, ""); len(all) != 3 {
+		t.Errorf("serviceAccountsOf(\"\") returned %d accounts, want 3", len(all))
+	}
+}
+
+// fakeGateway keeps the bucket owners and policies and the deleted users of
+// a stand-in VersityGW.
+type fakeGateway struct {
+	mu       sync.Mutex
+	owners   map[string]string
+	policies map[string]string
+	deleted  []string
+}
+
+func newFakeGateway(t *testing.T, owners map[string]string) (*fakeGateway, *config.Config) {
+	t.Helper()
+	g := &fakeGateway{owners: owners, policies: map[string]string{}}
+	srv := httptest.NewServer(http.HandlerFunc(g.serveHTTP))
+	t.Cleanup(srv.Close)
+	return g, &config.Config{EndpointURL: srv.URL, Region: "local", AdminAccess: "admin", AdminSecret: "secret",
+		MaxAttempts: 1, DataDir: t.TempDir()}
+}
+
+func (g *fakeGateway) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	q := r.URL.Query()
+	bucket := strings.Trim(r.URL.Path, "/")
+	switch {
+	case r.URL.Path == "/delete-user":
+		g.deleted = append(g.deleted, q.Get("access"))
+	case strings.HasPrefix(r.URL.Path, "/change-bucket-owner"):
+		g.owners[q.Get("bucket")] = q.Get("owner")
+	case bucket == "":
+		var b strings.Builder
+		b.WriteString("<ListAllMyBucketsResult><Buckets>")
+		for name := range g.owners {
+			b.WriteString("<Bucket><Name>" + name + "</Name></Bucket>")
+		}
+		b.WriteString("</Buckets></ListAllMyBucketsResult>")
+		w.Write([]byte(b.String()))
+	case q.Has("acl"):
+		w.Write([]byte("<AccessControlPolicy><Owner><ID>" + g.owners[bucket] + "</ID></Owner></AccessControlPolicy>"))
+	case q.Has("policy") && r.Method == http.MethodGet:
+		policy, ok := g.policies[bucket]
+		if !ok {
+			http.Error(w, "NoSuchBucketPolicy", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(policy))
+	case q.Has("policy") && r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		g.policies[bucket] = string(body)
+	case q.Has("policy") && r.Method == http.MethodDelete:
+		delete(g.policies, bucket)
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.String(), http.StatusBadRequest)
+	}
+}
+
+// principals returns the principals of the statement sid in bucket's policy.
+func (g *fakeGateway) principals(t *testing.T, bucket, sid string) []string {
+	t.Helper()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	statements, err := managedStatements(g.policies[bucket])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range statements {
+		if statement.Sid == sid {
+			principals := append([]string(nil), statement.Principal["AWS"]...)
+			sort.Strings(principals)
+			return principals
+		}
+	}
+	return nil
+}
+
+func saveServiceAccounts(t *testing.T, cfg *config.Config, accounts ...models.ServiceAccount) {
+	t.Helper()
+	state := serviceAccountsState{ServiceAccounts: map[string]models.ServiceAccount{}}
+	for _, account := range accounts {
+		state.ServiceAccounts[account.Access] = account
+	}
+	if err := writeStateFile(cfg.DataDir, serviceAccountsFile, state); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChangeBucketOwnerMovesServiceAccountGrant(t *testing.T) {
+	gw, cfg := newFakeGateway(t, map[string]string{"data": "bob"})
+	saveServiceAccounts(t, cfg,
+		models.ServiceAccount{Access: "alice-ci", Parent: "alice"},
+		models.ServiceAccount{Access: "bob-ci", Parent: "bob"})
+
+	if err := ChangeBucketOwner(context.Background(), cfg, "data", "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if got := gw.principals(t, "data", serviceAccountsSid); strings.Join(got, ",") != "alice-ci" {
+		t.Errorf("grant after owner change = %v, want [alice-ci]", got)
+	}
+
+	if err := ChangeBucketOwner(context.Background(), cfg, "data", "carol"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := gw.policies["data"]; ok {
+		t.Errorf("policy after change to an owner without service accounts = %s, want none", gw.policies["data"])
+	}
+}
+
+func TestChangeBucketOwnerReportsGrantFailure(t *testing.T) {
+	gw, cfg := newFakeGateway(t, map[string]string{"data": "bob"})
+	if err := os.WriteFile(filepath.Join(cfg.DataDir, serviceAccountsFile), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ChangeBucketOwner(context.Background(), cfg, "data", "alice")
+	if !errors.Is(err, ErrServiceAccountGrant) {
+		t.Fatalf("ChangeBucketOwner() error = %v, want ErrServiceAccountGrant", err)
+	}
+	if gw.owners["data"] != "alice" {
+		t.Errorf("owner = %q, want alice", gw.owners["data"])
+	}
+}
+
+func TestDeleteUserRevokesServiceAccounts(t *testing.T) {
+	gw, cfg := newFakeGateway(t, map[string]string{"data": "alice", "other": "bob"})
+	saveServiceAccounts(t, cfg,
+		models.ServiceAccount{Access: "alice-ci", Parent: "alice"},
+		models.ServiceAccount{Access: "alice-web", Parent: "alice"},
+		models.ServiceAccount{Access: "bob-ci", Parent: "bob"})
+	if _, err := SyncServiceAccountGrants(context.Background(), cfg, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DeleteUser(context.Background(), cfg, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(gw.deleted)
+	if got := strings.Join(gw.deleted, ","); got != "alice,alice-ci,alice-web" {
+		t.Errorf("deleted users = %s, want alice and its service accounts", got)
+	}
+	if got := gw.principals(t, "data", serviceAccountsSid); got != nil {
+		t.Errorf("grant left on the parent's bucket: %v", got)
+	}
+	accounts, err := ListServiceAccounts(cfg, "")
+	if err != nil || len(accounts) != 1 || accounts[0].Access != "bob-ci" {
+		t.Errorf("service accounts after deletion = %+v, %v", accounts, err)
+	}
+}
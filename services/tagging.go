@@ -0,0 +1,152 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// tagging is the S3 PutBucketTagging/GetBucketTagging document.
+type tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	TagSet  struct {
+		Tags []tag `xml:"Tag"`
+	} `xml:"TagSet"`
+}
+
+type tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// GetBucketTagging retrieves the tag set of a bucket.
+// A bucket without tags returns an empty map.
+func (s *VersityGWService) GetBucketTagging(ctx context.Context, bucket string) (map[string]string, error) {
+	url := fmt.Sprintf("%s/%s?tagging", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	body, err := s.signAndSend(httpReq, []byte{})
+	if err != nil {
+		if isNotFoundError(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	var doc tagging
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse bucket tagging: %w", err)
+	}
+
+	tags := make(map[string]string, len(doc.TagSet.Tags))
+	for _, t := range doc.TagSet.Tags {
+		tags[t.Key] = t.Value
+	}
+	return tags, nil
+}
+
+// PutBucketTagging replaces the tag set of a bucket.
+func (s *VersityGWService) PutBucketTagging(ctx context.Context, bucket string, tags map[string]string) error {
+	defer bucketMetas.invalidate(s.cfg.EndpointURL, bucket)
+
+	var doc tagging
+	for _, key := range sortedTagKeys(tags) {
+		doc.TagSet.Tags = append(doc.TagSet.Tags, tag{Key: key, Value: tags[key]})
+	}
+
+	payload, err := xml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bucket tagging: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s?tagging", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	setContentMD5(httpReq, payload)
+
+	if _, err := s.signAndSend(httpReq, payload); err != nil {
+		return fmt.Errorf("failed to set bucket tagging: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBucketTagging removes all tags from a bucket.
+func (s *VersityGWService) DeleteBucketTagging(ctx context.Context, bucket string) error {
+	defer bucketMetas.invalidate(s.cfg.EndpointURL, bucket)
+
+	url := fmt.Sprintf("%s/%s?tagging", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if _, err := s.signAndSend(httpReq, []byte{}); err != nil {
+		return fmt.Errorf("failed to delete bucket tagging: %w", err)
+	}
+
+	return nil
+}
+
+// setContentMD5 sets the Content-MD5 header S3 requires on configuration uploads.
+func setContentMD5(httpReq *http.Request, payload []byte) {
+	sum := md5.Sum(payload)
+	httpReq.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+// ParseTags parses a comma-separated list of key=value pairs (e.g. "cost-center=42,customer=acme").
+func ParseTags(s string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q (expected key=value)", pair)
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+	return tags, nil
+}
+
+// FormatTags renders tags as a sorted, comma-separated list of key=value pairs.
+func FormatTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for _, key := range sortedTagKeys(tags) {
+		pairs = append(pairs, key+"="+tags[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// MatchTags reports whether tags contains every key/value pair in filter.
+func MatchTags(tags, filter map[string]string) bool {
+	for key, value := range filter {
+		if got, ok := tags[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedTagKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,50 @@
+package services
+
+import "testing"
+
+func TestParseTags(t *testing.T) {
+	tags, err := ParseTags(" cost-center=42, customer=acme ,empty=")
+	if err != nil {
+		t.Fatalf("ParseTags() error = %v", err)
+	}
+	want := map[string]string{"cost-center": "42", "customer": "acme", "empty": ""}
+	if len(tags) != len(want) {
+		t.Fatalf("ParseTags() = %v, want %v", tags, want)
+	}
+	for key, value := range want {
+		if tags[key] != value {
+			t.Fatalf("ParseTags()[%q] = %q, want %q", key, tags[key], value)
+		}
+	}
+
+	if _, err := ParseTags("no-separator"); err == nil {
+		t.Fatal("expected error for tag without '='")
+	}
+	if _, err := ParseTags("=value"); err == nil {
+		t.Fatal("expected error for tag without key")
+	}
+}
+
+func TestFormatTags(t *testing.T) {
+	got := FormatTags(map[string]string{"b": "2", "a": "1"})
+	if got != "a=1,b=2" {
+		t.Fatalf("FormatTags() = %q, want %q", got, "a=1,b=2")
+	}
+}
+
+func TestMatchTags(t *testing.T) {
+	tags := map[string]string{"cost-center": "42", "customer": "acme"}
+
+	if !MatchTags(tags, nil) {
+		t.Fatal("empty filter should match")
+	}
+	if !MatchTags(tags, map[string]string{"customer": "acme"}) {
+		t.Fatal("expected subset filter to match")
+	}
+	if MatchTags(tags, map[string]string{"customer": "other"}) {
+		t.Fatal("expected mismatched value not to match")
+	}
+	if MatchTags(nil, map[string]string{"customer": "acme"}) {
+		t.Fatal("expected untagged bucket not to match")
+	}
+}
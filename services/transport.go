@@ -0,0 +1,101 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+// gatewayTLS is the TLS part of the configuration a transport was built for.
+type gatewayTLS struct {
+	caFile   string
+	certFile string
+	keyFile  string
+	insecure bool
+}
+
+// Transports are shared by all VersityGWService instances with the same TLS
+// settings so connections are reused across services and sites, until
+// ResetGatewayTransports.
+var (
+	transportMu sync.Mutex
+	transports  = map[gatewayTLS]*http.Transport{}
+)
+
+// ResetGatewayTransports drops the cached transports, so that the next
+// gateway request reads the TLS CA, certificate and key files again. Requests
+// in flight keep their transport; its idle connections are closed.
+func ResetGatewayTransports() {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	for key, transport := range transports {
+		transport.CloseIdleConnections()
+		delete(transports, key)
+	}
+}
+
+// gatewayTransport returns the HTTP transport for the VersityGW endpoint,
+// configured with the TLS settings from cfg.
+func gatewayTransport(cfg *config.Config) (*http.Transport, error) {
+	key := gatewayTLS{
+		caFile:   cfg.TLSCAFile,
+		certFile: cfg.TLSCertFile,
+		keyFile:  cfg.TLSKeyFile,
+		insecure: cfg.TLSInsecureSkipVerify,
+	}
+
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	if transport, ok := transports[key]; ok {
+		return transport, nil
+	}
+
+	tlsConfig, err := newGatewayTLSConfig(key)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transports[key] = transport
+	return transport, nil
+}
+
+// newGatewayTLSConfig builds the client TLS configuration: the CA bundle is
+// trusted in addition to the system roots, and the client certificate is
+// presented for mutual TLS.
+func newGatewayTLSConfig(settings gatewayTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: settings.insecure,
+	}
+
+	if settings.caFile != "" {
+		pem, err := os.ReadFile(settings.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in TLS CA file %s", settings.caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if settings.certFile != "" || settings.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(settings.certFile, settings.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
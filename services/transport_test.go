@@ -0,0 +1,105 @@
+package services
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/monobilisim/vgw-manager/config"
+)
+
+func TestNewGatewayTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	garbage := filepath.Join(dir, "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		settings   gatewayTLS
+		wantErr    bool
+		wantVerify bool
+	}{
+		{"system roots only", gatewayTLS{}, false, false},
+		{"private CA", gatewayTLS{caFile: caFile}, false, true},
+		{"insecure skip verify", gatewayTLS{insecure: true}, false, true},
+		{"CA without certificates", gatewayTLS{caFile: garbage}, true, false},
+		{"missing CA file", gatewayTLS{caFile: filepath.Join(dir, "missing.pem")}, true, false},
+		{"invalid client certificate", gatewayTLS{certFile: garbage, keyFile: garbage}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := newGatewayTLSConfig(tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newGatewayTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.TLSClientConfig = tlsConfig
+			defer transport.CloseIdleConnections()
+			resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err == nil) != tt.wantVerify {
+				t.Errorf("GET with %+v: error = %v, want success %v", tt.settings, err, tt.wantVerify)
+			}
+		})
+	}
+}
+
+func TestResetGatewayTransportsRereadsTLSFiles(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	t.Cleanup(ResetGatewayTransports)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{TLSCAFile: caFile}
+	get := func() error {
+		transport, err := gatewayTransport(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		server.CloseClientConnections()
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	if err := get(); err != nil {
+		t.Fatalf("GET with the server's CA: %v", err)
+	}
+
+	// The CA is rotated in place; the cached transport keeps the old one
+	// until the transports are reset.
+	if err := os.WriteFile(caFile, newTestCert(t, "other-ca", true, nil).pem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(); err != nil {
+		t.Fatalf("GET with the cached transport: %v", err)
+	}
+	ResetGatewayTransports()
+	if err := get(); err == nil {
+		t.Error("GET after ResetGatewayTransports succeeded, want the rotated CA to be used")
+	}
+}
@@ -11,16 +11,18 @@ import (
 )
 
 // UserService handles user-related operations
-type UserService struct{}
+type UserService struct {
+	cfg *config.Config
+}
 
 // NewUserService creates a new UserService instance
-func NewUserService() *UserService {
-	return &UserService{}
+func NewUserService(cfg *config.Config) *UserService {
+	return &UserService{cfg: cfg}
 }
 
 // ListUsers reads and returns all users from users.json
 func (s *UserService) ListUsers() ([]models.User, error) {
-	data, err := os.ReadFile(config.UsersJSONPath)
+	data, err := os.ReadFile(s.cfg.UsersJSONPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read users.json: %w", err)
 	}
@@ -44,7 +46,7 @@ func (s *UserService) ListUsers() ([]models.User, error) {
 
 // GetUser returns a specific user by access key
 func (s *UserService) GetUser(access string) (*models.User, error) {
-	data, err := os.ReadFile(config.UsersJSONPath)
+	data, err := os.ReadFile(s.cfg.UsersJSONPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read users.json: %w", err)
 	}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/xml"
@@ -18,14 +19,21 @@ import (
 
 // VersityGWService handles VersityGW admin API operations
 type VersityGWService struct {
+	cfg    *config.Config
 	client *http.Client
+	// transportErr is returned by every request when the TLS settings
+	// could not be loaded.
+	transportErr error
 }
 
 // NewVersityGWService creates a new VersityGWService instance
-func NewVersityGWService() *VersityGWService {
-	return &VersityGWService{
-		client: &http.Client{Timeout: 30 * time.Second},
+func NewVersityGWService(cfg *config.Config) *VersityGWService {
+	client := &http.Client{Timeout: cfg.RequestTimeout}
+	transport, err := gatewayTransport(cfg)
+	if err == nil {
+		client.Transport = transport
 	}
+	return &VersityGWService{cfg: cfg, client: client, transportErr: err}
 }
 
 // Account represents a VersityGW account (matching the versitygw auth.Account structure)
@@ -39,7 +47,7 @@ type Account struct {
 }
 
 // CreateUser creates a new user via VersityGW admin API
-func (s *VersityGWService) CreateUser(req models.UserCreateRequest) error {
+func (s *VersityGWService) CreateUser(ctx context.Context, req models.UserCreateRequest) error {
 	acc := Account{
 		Access:    req.Access,
 		Secret:    req.Secret,
@@ -54,8 +62,8 @@ func (s *VersityGWService) CreateUser(req models.UserCreateRequest) error {
 		return fmt.Errorf("failed to marshal user data: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/create-user", config.EndpointURL)
-	httpReq, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(accxml))
+	url := fmt.Sprintf("%s/create-user", s.cfg.EndpointURL)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewBuffer(accxml))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -68,7 +76,7 @@ func (s *VersityGWService) CreateUser(req models.UserCreateRequest) error {
 }
 
 // UpdateUser updates an existing user via VersityGW admin API
-func (s *VersityGWService) UpdateUser(req models.UserUpdateRequest) error {
+func (s *VersityGWService) UpdateUser(ctx context.Context, req models.UserUpdateRequest) error {
 	acc := Account{
 		Access:    req.Access,
 		Secret:    req.Secret,
@@ -83,8 +91,8 @@ func (s *VersityGWService) UpdateUser(req models.UserUpdateRequest) error {
 		return fmt.Errorf("failed to marshal user data: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/update-user?access=%s", config.EndpointURL, req.Access)
-	httpReq, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(accxml))
+	url := fmt.Sprintf("%s/update-user?access=%s", s.cfg.EndpointURL, req.Access)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewBuffer(accxml))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -97,9 +105,9 @@ func (s *VersityGWService) UpdateUser(req models.UserUpdateRequest) error {
 }
 
 // DeleteUser deletes a user via VersityGW admin API
-func (s *VersityGWService) DeleteUser(access string) error {
-	url := fmt.Sprintf("%s/delete-user?access=%s", config.EndpointURL, access)
-	httpReq, err := http.NewRequest(http.MethodPatch, url, nil)
+func (s *VersityGWService) DeleteUser(ctx context.Context, access string) error {
+	url := fmt.Sprintf("%s/delete-user?access=%s", s.cfg.EndpointURL, access)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -112,25 +120,12 @@ func (s *VersityGWService) DeleteUser(access string) error {
 	return nil
 }
 
-// signAndSend signs the request with AWS V4 signature, sends it, and returns the response body
+// signAndSend signs the request with AWS V4 signature, sends it (retrying
+// idempotent requests, see do), and returns the response body
 func (s *VersityGWService) signAndSend(httpReq *http.Request, payload []byte) ([]byte, error) {
-	signer := v4.NewSigner()
-	hashedPayload := sha256.Sum256(payload)
-	hexPayload := hex.EncodeToString(hashedPayload[:])
-
-	httpReq.Header.Set("X-Amz-Content-Sha256", hexPayload)
-
-	err := signer.SignHTTP(httpReq.Context(), aws.Credentials{
-		AccessKeyID:     config.AdminAccess,
-		SecretAccessKey: config.AdminSecret,
-	}, httpReq, hexPayload, "s3", config.Region, time.Now())
-	if err != nil {
-		return nil, fmt.Errorf("failed to sign request: %w", err)
-	}
-
-	resp, err := s.client.Do(httpReq)
+	resp, err := s.do(s.client, httpReq, payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -146,52 +141,50 @@ func (s *VersityGWService) signAndSend(httpReq *http.Request, payload []byte) ([
 	return body, nil
 }
 
-// ChangeBucketOwner changes the owner of a bucket
-func (s *VersityGWService) ChangeBucketOwner(bucket, owner string) error {
-	url := fmt.Sprintf("%s/change-bucket-owner/?bucket=%s&owner=%s", config.EndpointURL, bucket, owner)
-	httpReq, err := http.NewRequest(http.MethodPatch, url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
+// signRequest signs the request in place with the admin credentials (AWS V4, S3 service).
+// Paths are signed as sent: object keys must already be S3 URI-encoded (see escapeObjectKey).
+func (s *VersityGWService) signRequest(httpReq *http.Request, payload []byte) error {
 	signer := v4.NewSigner()
-	hashedPayload := sha256.Sum256([]byte{})
+	hashedPayload := sha256.Sum256(payload)
 	hexPayload := hex.EncodeToString(hashedPayload[:])
 
 	httpReq.Header.Set("X-Amz-Content-Sha256", hexPayload)
 
-	err = signer.SignHTTP(httpReq.Context(), aws.Credentials{
-		AccessKeyID:     config.AdminAccess,
-		SecretAccessKey: config.AdminSecret,
-	}, httpReq, hexPayload, "s3", config.Region, time.Now())
+	err := signer.SignHTTP(httpReq.Context(), aws.Credentials{
+		AccessKeyID:     s.cfg.AdminAccess,
+		SecretAccessKey: s.cfg.AdminSecret,
+	}, httpReq, hexPayload, "s3", s.cfg.Region, time.Now(), func(o *v4.SignerOptions) {
+		o.DisableURIPathEscaping = true
+	})
 	if err != nil {
 		return fmt.Errorf("failed to sign request: %w", err)
 	}
+	return nil
+}
 
-	resp, err := s.client.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+// ChangeBucketOwner changes the owner of a bucket. Use the package-level
+// ChangeBucketOwner to also move the bucket's service account grant.
+func (s *VersityGWService) ChangeBucketOwner(ctx context.Context, bucket, owner string) error {
+	defer bucketMetas.invalidate(s.cfg.EndpointURL, bucket)
 
-	body, err := io.ReadAll(resp.Body)
+	url := fmt.Sprintf("%s/change-bucket-owner/?bucket=%s&owner=%s", s.cfg.EndpointURL, bucket, owner)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, nil)
 	if err != nil {
-		return err
-	}
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return nil
+	_, err = s.signAndSend(httpReq, []byte{})
+	return err
 }
 
 // SetBucketPolicy sets the S3 bucket policy
-func (s *VersityGWService) SetBucketPolicy(bucket string, policy string) error {
+func (s *VersityGWService) SetBucketPolicy(ctx context.Context, bucket string, policy string) error {
+	defer bucketMetas.invalidate(s.cfg.EndpointURL, bucket)
+
 	// S3 PutBucketPolicy: PUT /<bucket>?policy
 	// Body: policy JSON
-	url := fmt.Sprintf("%s/%s?policy", config.EndpointURL, bucket)
-	httpReq, err := http.NewRequest(http.MethodPut, url, bytes.NewBufferString(policy))
+	url := fmt.Sprintf("%s/%s?policy", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewBufferString(policy))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -241,9 +234,11 @@ func GeneratePublicPolicy(bucket, owner string) string {
 }
 
 // DeleteBucketPolicy deletes the policy of a bucket (making it private)
-func (s *VersityGWService) DeleteBucketPolicy(bucket string) error {
-	url := fmt.Sprintf("%s/%s?policy", config.EndpointURL, bucket)
-	httpReq, err := http.NewRequest(http.MethodDelete, url, nil)
+func (s *VersityGWService) DeleteBucketPolicy(ctx context.Context, bucket string) error {
+	defer bucketMetas.invalidate(s.cfg.EndpointURL, bucket)
+
+	url := fmt.Sprintf("%s/%s?policy", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -256,9 +251,11 @@ func (s *VersityGWService) DeleteBucketPolicy(bucket string) error {
 }
 
 // DeleteBucket deletes a bucket (must be empty)
-func (s *VersityGWService) DeleteBucket(bucket string) error {
-	url := fmt.Sprintf("%s/%s", config.EndpointURL, bucket)
-	httpReq, err := http.NewRequest(http.MethodDelete, url, nil)
+func (s *VersityGWService) DeleteBucket(ctx context.Context, bucket string) error {
+	defer bucketMetas.invalidate(s.cfg.EndpointURL, bucket)
+
+	url := fmt.Sprintf("%s/%s", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -271,9 +268,9 @@ func (s *VersityGWService) DeleteBucket(bucket string) error {
 }
 
 // GetBucketPolicy retrieves the policy of a bucket
-func (s *VersityGWService) GetBucketPolicy(bucket string) (string, error) {
-	url := fmt.Sprintf("%s/%s?policy", config.EndpointURL, bucket)
-	httpReq, err := http.NewRequest(http.MethodGet, url, nil)
+func (s *VersityGWService) GetBucketPolicy(ctx context.Context, bucket string) (string, error) {
+	url := fmt.Sprintf("%s/%s?policy", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -294,9 +291,9 @@ type BucketInfo struct {
 }
 
 // ListBuckets lists all buckets via VersityGW admin API
-func (s *VersityGWService) ListBuckets() ([]BucketInfo, error) {
-	url := fmt.Sprintf("%s/", config.EndpointURL)
-	httpReq, err := http.NewRequest(http.MethodGet, url, nil)
+func (s *VersityGWService) ListBuckets(ctx context.Context) ([]BucketInfo, error) {
+	url := fmt.Sprintf("%s/", s.cfg.EndpointURL)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -322,9 +319,9 @@ func (s *VersityGWService) ListBuckets() ([]BucketInfo, error) {
 }
 
 // GetBucketOwner retrieves the true bucket owner by checking the ACL
-func (s *VersityGWService) GetBucketOwner(bucket string) (string, error) {
-	url := fmt.Sprintf("%s/%s?acl", config.EndpointURL, bucket)
-	httpReq, err := http.NewRequest(http.MethodGet, url, nil)
+func (s *VersityGWService) GetBucketOwner(ctx context.Context, bucket string) (string, error) {
+	url := fmt.Sprintf("%s/%s?acl", s.cfg.EndpointURL, bucket)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
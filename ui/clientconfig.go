@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// openClientConfigPicker shows the client config formats for the user under the cursor.
+func (m Model) openClientConfigPicker() (tea.Model, tea.Cmd) {
+	if m.currentView == UsersListView {
+		idx := m.page*m.pageSize + m.cursor
+		if idx < 0 || idx >= len(m.users) {
+			return m, nil
+		}
+		m.selectedUserIndex = idx
+	}
+	if m.selectedUserIndex < 0 || m.selectedUserIndex >= len(m.users) {
+		return m, nil
+	}
+
+	m.returnView = m.currentView
+	m.currentView = ClientConfigView
+	m.cursor = 0
+	return m, nil
+}
+
+// closeClientConfigPicker returns to the users list or user detail view.
+func (m Model) closeClientConfigPicker() (tea.Model, tea.Cmd) {
+	m.currentView = m.returnView
+	if m.currentView == UsersListView {
+		m.page = m.selectedUserIndex / m.pageSize
+		m.cursor = m.selectedUserIndex % m.pageSize
+	}
+	return m, nil
+}
+
+// copySelectedClientConfig renders the selected format and copies it to the clipboard.
+func (m Model) copySelectedClientConfig() (tea.Model, tea.Cmd) {
+	if m.cursor < 0 || m.cursor >= len(services.ClientConfigFormats) {
+		return m, nil
+	}
+	format := services.ClientConfigFormats[m.cursor]
+	user := m.users[m.selectedUserIndex]
+
+	clientConfig, err := services.RenderClientConfig(m.cfg, user, format)
+	if err != nil {
+		m.errorMessage = err.Error()
+		return m, nil
+	}
+	if err := clipboard.WriteAll(clientConfig.Content); err != nil {
+		m.errorMessage = fmt.Sprintf("Copy failed: failed to copy to clipboard: %v", err)
+		return m, nil
+	}
+	m.successMessage = fmt.Sprintf("✓ %s config for %s copied to clipboard!", format, user.Access)
+	return m, nil
+}
+
+func (m Model) renderClientConfigPicker() string {
+	var s strings.Builder
+
+	user := m.users[m.selectedUserIndex]
+	s.WriteString(titleStyle.Render("Export Client Config: "+user.Access) + "\n\n")
+	s.WriteString(dimStyle.Render(fmt.Sprintf("  Endpoint: %s   Region: %s", m.cfg.PublicEndpointURL, m.cfg.Region)) + "\n\n")
+
+	for i, format := range services.ClientConfigFormats {
+		line := fmt.Sprintf("  %-8s %s", format, services.ClientConfigDescriptions[format])
+		if m.cursor == i {
+			s.WriteString(selectedTableRowStyle.Render("> "+strings.TrimPrefix(line, "  ")) + "\n")
+		} else {
+			s.WriteString(line + "\n")
+		}
+	}
+
+	help := helpStyle.Render("↑/↓: Navigate • Enter: Copy to Clipboard • esc: Back")
+	s.WriteString("\n" + help)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render(m.errorMessage))
+	} else if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
@@ -0,0 +1,243 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// openCorsView loads the CORS rules of the selected bucket and shows them
+func (m Model) openCorsView() (tea.Model, tea.Cmd) {
+	if m.selectedBucketIndex < 0 || m.selectedBucketIndex >= len(m.buckets) {
+		m.errorMessage = "Invalid bucket selection"
+		return m, nil
+	}
+
+	bucket := m.buckets[m.selectedBucketIndex].Name
+	rules, err := m.versitygwService.GetBucketCors(context.Background(), bucket)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to load CORS rules: %v", err)
+		return m, nil
+	}
+
+	m.corsBucket = bucket
+	m.corsRules = rules
+	m.currentView = CorsView
+	m.cursor = 0
+	m.page = 0
+	return m, nil
+}
+
+// reloadCorsRules refreshes the rule list after a change, keeping the cursor in range
+func (m *Model) reloadCorsRules() {
+	rules, err := m.versitygwService.GetBucketCors(context.Background(), m.corsBucket)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to reload CORS rules: %v", err)
+		return
+	}
+	m.corsRules = rules
+	m.page = 0
+	if m.cursor >= len(rules) {
+		m.cursor = max(0, len(rules)-1)
+	}
+}
+
+// renderCorsView renders the CORS rules of a bucket
+func (m Model) renderCorsView() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("CORS Rules: "+m.corsBucket) + "\n\n")
+
+	start := m.page * m.pageSize
+	end := start + m.pageSize
+	if end > len(m.corsRules) {
+		end = len(m.corsRules)
+	}
+
+	header := fmt.Sprintf("  %-4s %-40s %-20s %-20s %-8s", "#", "Origins", "Methods", "Headers", "Max Age")
+	s.WriteString(dimStyle.Render(header) + "\n")
+	s.WriteString(dimStyle.Render(strings.Repeat("-", 98)) + "\n")
+
+	if len(m.corsRules) == 0 {
+		s.WriteString(dimStyle.Render("  No CORS rules. Press 'a' to allow GET from an origin.") + "\n")
+	}
+
+	for i := start; i < end; i++ {
+		rule := m.corsRules[i]
+		cursor := " "
+		if m.cursor == (i - start) {
+			cursor = ">"
+		}
+
+		line := fmt.Sprintf("%s %-4d %-40s %-20s %-20s %-8d",
+			cursor,
+			i+1,
+			truncate(strings.Join(rule.AllowedOrigins, ","), 40),
+			truncate(strings.Join(rule.AllowedMethods, ","), 20),
+			truncate(strings.Join(rule.AllowedHeaders, ","), 20),
+			rule.MaxAgeSeconds,
+		)
+
+		if m.cursor == (i - start) {
+			s.WriteString(selectedTableRowStyle.Render(line) + "\n")
+		} else {
+			s.WriteString(line + "\n")
+		}
+	}
+
+	help := helpStyle.Render("↑/↓: Navigate • a: Allow GET from Origin • d: Delete • esc: Back")
+	s.WriteString("\n" + help)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render(m.errorMessage))
+	} else if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
+
+// initCorsForm initializes the "allow GET from origin" form
+func (m *Model) initCorsForm() {
+	inputs := make([]textinput.Model, 1)
+
+	// Origins
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "https://app.example.com (comma-separated, * for any)"
+	inputs[0].Focus()
+	inputs[0].CharLimit = 1024
+	inputs[0].Width = 60
+
+	m.corsFormInputs = inputs
+	m.focusIndex = 0
+}
+
+// renderCorsForm renders the "allow GET from origin" form
+func (m Model) renderCorsForm() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Allow GET from Origin: "+m.corsBucket) + "\n\n")
+
+	labels := []string{"Origins:"}
+
+	for i, input := range m.corsFormInputs {
+		label := inputLabelStyle.Render(labels[i])
+		s.WriteString(label + "\n")
+
+		if i == m.focusIndex {
+			s.WriteString(focusedInputStyle.Render(input.View()) + "\n\n")
+		} else {
+			s.WriteString(inputStyle.Render(input.View()) + "\n\n")
+		}
+	}
+
+	addBtn := "[ Add Rule ]"
+	cancelBtn := "[ Cancel ]"
+
+	if m.focusIndex == len(m.corsFormInputs) {
+		s.WriteString(focusedButtonStyle.Render(addBtn) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	} else if m.focusIndex == len(m.corsFormInputs)+1 {
+		s.WriteString(buttonStyle.Render(addBtn) + "  ")
+		s.WriteString(focusedButtonStyle.Render(cancelBtn) + "\n")
+	} else {
+		s.WriteString(buttonStyle.Render(addBtn) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	}
+
+	help := helpStyle.Render("tab: Next field • shift+tab: Previous • enter: Submit/Select • esc: Cancel")
+	s.WriteString("\n" + help)
+
+	info := dimStyle.Render("Adds a rule allowing GET/HEAD with any header from these origins, exposing ETag.")
+	s.WriteString("\n" + info)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render("Error: "+m.errorMessage))
+	}
+	if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
+
+// updateCorsForm handles key events for the "allow GET from origin" form
+func (m Model) updateCorsForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		m.currentView = CorsView
+		return m, nil
+
+	case "tab", "down":
+		m.focusIndex++
+		if m.focusIndex > len(m.corsFormInputs)+1 {
+			m.focusIndex = 0
+		}
+		m.updateCorsFormFocus()
+		return m, nil
+
+	case "shift+tab", "up":
+		m.focusIndex--
+		if m.focusIndex < 0 {
+			m.focusIndex = len(m.corsFormInputs) + 1
+		}
+		m.updateCorsFormFocus()
+		return m, nil
+
+	case "enter":
+		if m.focusIndex == len(m.corsFormInputs)+1 {
+			m.currentView = CorsView
+			return m, nil
+		}
+		return m.handleCorsAllowGet()
+	}
+
+	if m.focusIndex < len(m.corsFormInputs) {
+		m.corsFormInputs[m.focusIndex], cmd = m.corsFormInputs[m.focusIndex].Update(msg)
+	}
+
+	return m, cmd
+}
+
+// updateCorsFormFocus updates focus state for CORS form inputs
+func (m *Model) updateCorsFormFocus() {
+	for i := range m.corsFormInputs {
+		if i == m.focusIndex {
+			m.corsFormInputs[i].Focus()
+		} else {
+			m.corsFormInputs[i].Blur()
+		}
+	}
+}
+
+// handleCorsAllowGet adds the origins from the CORS form to the "allow GET from origin" rule
+func (m Model) handleCorsAllowGet() (tea.Model, tea.Cmd) {
+	origins := services.ParseOrigins(m.corsFormInputs[0].Value())
+	if len(origins) == 0 {
+		m.errorMessage = "At least one origin is required"
+		return m, nil
+	}
+
+	audit := m.audit("cors.allow_get", m.corsBucket, map[string]any{"origin": strings.Join(origins, ",")})
+	_, err := services.AllowCorsGet(context.Background(), m.cfg, m.corsBucket, origins)
+	audit.Finish(err)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to add CORS rule: %v", err)
+		return m, nil
+	}
+
+	m.currentView = CorsView
+	m.reloadCorsRules()
+	m.successMessage = fmt.Sprintf("Bucket '%s' now allows GET from %s", m.corsBucket, strings.Join(origins, ", "))
+
+	return m, nil
+}
@@ -0,0 +1,177 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// forceDeleteProgressMsg carries a progress update of a running force delete
+type forceDeleteProgressMsg services.EmptyProgress
+
+// forceDeleteDoneMsg is sent when a force delete finishes
+type forceDeleteDoneMsg struct {
+	via    string
+	result *services.EmptyResult
+	err    error
+}
+
+// openForceDelete shows the force delete confirmation for the bucket under the cursor
+func (m Model) openForceDelete() (tea.Model, tea.Cmd) {
+	idx := m.selectedBucketIndex
+	if m.currentView == BucketsListView {
+		idx = m.page*m.pageSize + m.cursor
+	}
+	if idx < 0 || idx >= len(m.buckets) {
+		return m, nil
+	}
+
+	input := textinput.New()
+	input.Placeholder = "bucket name"
+	input.Focus()
+	input.CharLimit = 63
+	input.Width = 40
+
+	m.selectedBucketIndex = idx
+	m.forceDeleteBucket = m.buckets[idx].Name
+	m.forceDeleteInput = input
+	m.forceDeleteRunning = false
+	m.forceDeleteProgress = services.EmptyProgress{}
+	m.returnView = m.currentView
+	m.currentView = ForceDeleteView
+	return m, nil
+}
+
+// updateForceDelete handles key events while confirming or running a force delete
+func (m Model) updateForceDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.forceDeleteRunning {
+		// Deletion cannot be interrupted safely halfway; only allow quitting.
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		m.errorMessage = ""
+		m.currentView = m.returnView
+		m.successMessage = "Force delete cancelled."
+		return m, nil
+
+	case "enter":
+		if strings.TrimSpace(m.forceDeleteInput.Value()) != m.forceDeleteBucket {
+			m.errorMessage = "Name does not match; type the bucket name exactly to confirm"
+			return m, nil
+		}
+		m.errorMessage = ""
+		m.forceDeleteRunning = true
+		m.forceDeleteCh = make(chan tea.Msg, 64)
+		return m, tea.Batch(startForceDelete(m.cfg, m.forceDeleteBucket, m.forceDeleteCh), waitForForceDelete(m.forceDeleteCh))
+	}
+
+	var cmd tea.Cmd
+	m.forceDeleteInput, cmd = m.forceDeleteInput.Update(msg)
+	return m, cmd
+}
+
+// startForceDelete runs the force delete in the background, reporting on ch
+func startForceDelete(cfg *config.Config, bucket string, ch chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		go func() {
+			audit := services.StartAudit(cfg, services.LocalActor("tui"), "bucket.force_delete", bucket, nil)
+			via, result, err := services.ForceDeleteBucket(context.Background(), cfg, bucket, services.EmptyOptions{
+				Progress: func(p services.EmptyProgress) {
+					// Drop updates the UI has not caught up with; the next one supersedes them.
+					select {
+					case ch <- forceDeleteProgressMsg(p):
+					default:
+					}
+				},
+			})
+			audit.Finish(err)
+			ch <- forceDeleteDoneMsg{via: via, result: result, err: err}
+		}()
+		return nil
+	}
+}
+
+// waitForForceDelete waits for the next message from a running force delete
+func waitForForceDelete(ch chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// handleForceDeleteDone reloads the bucket list after a force delete
+func (m Model) handleForceDeleteDone(msg forceDeleteDoneMsg) (tea.Model, tea.Cmd) {
+	m.forceDeleteRunning = false
+	m.forceDeleteCh = nil
+
+	if msg.err != nil {
+		m.errorMessage = fmt.Sprintf("Force delete failed: %v", msg.err)
+		if msg.result != nil && len(msg.result.Errors) > 0 {
+			m.errorMessage += " (first error: " + msg.result.Errors[0] + ")"
+		}
+		m.currentView = m.returnView
+		return m, nil
+	}
+
+	successMessage := fmt.Sprintf("Bucket '%s' emptied (%d objects, %d uploads) and deleted (via %s).",
+		m.forceDeleteBucket, msg.result.Deleted, msg.result.UploadsAborted, msg.via)
+
+	// Reload buckets
+	m.currentView = MainMenuView
+	m.cursor = 1
+	newM, cmd := m.handleEnter()
+	if model, ok := newM.(Model); ok {
+		m = model
+		m.successMessage = successMessage
+	}
+	return m, cmd
+}
+
+// renderForceDelete renders the force delete confirmation and progress
+func (m Model) renderForceDelete() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Force Delete Bucket: "+m.forceDeleteBucket) + "\n\n")
+
+	if m.forceDeleteRunning {
+		p := m.forceDeleteProgress
+		phase := p.Phase
+		if phase == "" {
+			phase = "starting"
+		}
+		s.WriteString(tableHeaderStyle.Render("Status") + "\n")
+		s.WriteString(tableCellStyle.Render(phase) + "\n\n")
+		s.WriteString(tableHeaderStyle.Render("Progress") + "\n")
+		s.WriteString(tableCellStyle.Render(fmt.Sprintf("%d uploads aborted • %d objects deleted • %d failed",
+			p.UploadsAborted, p.Deleted, p.Failed)) + "\n")
+		s.WriteString("\n" + helpStyle.Render("Deleting… please wait (ctrl+c quits)"))
+		return s.String()
+	}
+
+	warning := errorStyle.Render(fmt.Sprintf("⚠ This permanently deletes ALL objects, versions and multipart uploads in '%s', then the bucket itself.", m.forceDeleteBucket))
+	s.WriteString(warning + "\n\n")
+
+	s.WriteString(inputLabelStyle.Render("Type the bucket name to confirm:") + "\n")
+	s.WriteString(focusedInputStyle.Render(m.forceDeleteInput.View()) + "\n")
+
+	help := helpStyle.Render("enter: Delete • esc: Cancel")
+	s.WriteString("\n" + help)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render("Error: "+m.errorMessage))
+	}
+
+	return s.String()
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -166,14 +167,20 @@ func (m Model) handleCreateUser() (tea.Model, tea.Cmd) {
 			GroupID:   req.GroupID,
 			ProjectID: req.ProjectID,
 		}
-		if err := m.versitygwService.UpdateUser(updateReq); err != nil {
+		audit := m.audit("user.update", req.Access, map[string]any{"role": req.Role, "secret": req.Secret, "uid": req.UserID, "gid": req.GroupID, "project-id": req.ProjectID})
+		err := m.versitygwService.UpdateUser(context.Background(), updateReq)
+		audit.Finish(err)
+		if err != nil {
 			m.errorMessage = fmt.Sprintf("Failed to update user: %v", err)
 			return m, nil
 		}
 		m.successMessage = fmt.Sprintf("User '%s' updated successfully!", req.Access)
 	} else {
 		// Create user
-		if err := m.versitygwService.CreateUser(req); err != nil {
+		audit := m.audit("user.create", req.Access, map[string]any{"role": req.Role, "secret": req.Secret, "uid": req.UserID, "gid": req.GroupID, "project-id": req.ProjectID})
+		err := m.versitygwService.CreateUser(context.Background(), req)
+		audit.Finish(err)
+		if err != nil {
 			m.errorMessage = fmt.Sprintf("Failed to create user: %v", err)
 			return m, nil
 		}
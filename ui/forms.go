@@ -1,8 +1,10 @@
 package ui
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -78,7 +80,7 @@ func (m *Model) initUpdateUserForm(user models.User) {
 
 // initBucketForm initializes the bucket creation form
 func (m *Model) initBucketForm() {
-	inputs := make([]textinput.Model, 3)
+	inputs := make([]textinput.Model, 4)
 
 	// Bucket Name
 	inputs[0] = textinput.New()
@@ -100,6 +102,12 @@ func (m *Model) initBucketForm() {
 	inputs[2].CharLimit = 64
 	inputs[2].Width = 40
 
+	// Tags
+	inputs[3] = textinput.New()
+	inputs[3].Placeholder = "Tags (key=value,key2=value2)"
+	inputs[3].CharLimit = 512
+	inputs[3].Width = 60
+
 	m.bucketFormInputs = inputs
 	m.focusIndex = 0
 }
@@ -127,7 +135,7 @@ func (m *Model) initChangeOwnerForm() {
 
 // initProvisionForm initializes the combined provision form
 func (m *Model) initProvisionForm() {
-	inputs := make([]textinput.Model, 9)
+	inputs := make([]textinput.Model, 10)
 
 	// Access Key
 	inputs[0] = textinput.New()
@@ -190,6 +198,12 @@ func (m *Model) initProvisionForm() {
 	inputs[8].CharLimit = 64
 	inputs[8].Width = 40
 
+	// Tags
+	inputs[9] = textinput.New()
+	inputs[9].Placeholder = "Tags (key=value,key2=value2)"
+	inputs[9].CharLimit = 512
+	inputs[9].Width = 60
+
 	m.provisionFormInputs = inputs
 	m.focusIndex = 0
 	m.provisionScroll = 0
@@ -299,7 +313,7 @@ func (m Model) renderCreateBucketForm() string {
 	s.WriteString(titleStyle.Render("Create New Bucket") + "\n\n")
 
 	// Form fields
-	labels := []string{"Bucket Name:", "Quota:", "Owner:"}
+	labels := []string{"Bucket Name:", "Quota:", "Owner:", "Tags:"}
 
 	for i, input := range m.bucketFormInputs {
 		label := inputLabelStyle.Render(labels[i])
@@ -400,6 +414,12 @@ func (m Model) handleCreateBucket() (tea.Model, tea.Cmd) {
 		Owner: m.bucketFormInputs[2].Value(),
 	}
 
+	tags, err := services.ParseTags(m.bucketFormInputs[3].Value())
+	if err != nil {
+		m.errorMessage = err.Error()
+		return m, nil
+	}
+
 	// Validate
 	if req.Name == "" {
 		m.errorMessage = "Bucket name is required"
@@ -411,21 +431,39 @@ func (m Model) handleCreateBucket() (tea.Model, tea.Cmd) {
 	}
 
 	// Create bucket
-	err := m.bucketService.CreateBucket(req)
+	audit := m.audit("bucket.create", req.Name, map[string]any{"quota": req.Quota, "owner": req.Owner, "tags": services.FormatTags(tags)})
+	err = m.bucketService.CreateBucket(context.Background(), req)
 	if err != nil {
+		audit.Finish(err)
 		m.errorMessage = fmt.Sprintf("Failed to create bucket: %v", err)
 		return m, nil
 	}
 
 	// If owner is specified, change bucket owner
 	if req.Owner != "" {
-		err = m.versitygwService.ChangeBucketOwner(req.Name, req.Owner)
+		err = services.ChangeBucketOwner(context.Background(), m.cfg, req.Name, req.Owner)
+		if errors.Is(err, services.ErrServiceAccountGrant) {
+			audit.Finish(err)
+			m.errorMessage = fmt.Sprintf("Bucket created, but %v", err)
+			return m, nil
+		}
 		if err != nil {
+			audit.Finish(fmt.Errorf("bucket created but failed to set owner: %w", err))
 			m.errorMessage = fmt.Sprintf("Bucket created but failed to set owner: %v", err)
 			return m, nil
 		}
 	}
 
+	// If tags are specified, apply them
+	if len(tags) > 0 {
+		if err := m.versitygwService.PutBucketTagging(context.Background(), req.Name, tags); err != nil {
+			audit.Finish(fmt.Errorf("bucket created but failed to set tags: %w", err))
+			m.errorMessage = fmt.Sprintf("Bucket created but failed to set tags: %v", err)
+			return m, nil
+		}
+	}
+	audit.Finish(nil)
+
 	m.successMessage = fmt.Sprintf("Bucket '%s' created successfully!", req.Name)
 	m.currentView = m.returnView
 	m.cursor = 0
@@ -447,7 +485,10 @@ func (m Model) handleChangeOwner() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	if err := m.versitygwService.ChangeBucketOwner(bucket, owner); err != nil {
+	audit := m.audit("bucket.change_owner", bucket, map[string]any{"owner": owner})
+	err := services.ChangeBucketOwner(context.Background(), m.cfg, bucket, owner)
+	audit.Finish(err)
+	if err != nil && !errors.Is(err, services.ErrServiceAccountGrant) {
 		m.errorMessage = fmt.Sprintf("Failed to change owner: %v", err)
 		return m, nil
 	}
@@ -460,7 +501,11 @@ func (m Model) handleChangeOwner() (tea.Model, tea.Cmd) {
 		}
 	}
 
-	m.successMessage = fmt.Sprintf("Owner for '%s' changed to '%s'", bucket, owner)
+	if err != nil {
+		m.errorMessage = err.Error()
+	} else {
+		m.successMessage = fmt.Sprintf("Owner for '%s' changed to '%s'", bucket, owner)
+	}
 	m.currentView = m.returnView
 	m.cursor = 0
 
@@ -478,6 +523,7 @@ func (m Model) handleProvision() (tea.Model, tea.Cmd) {
 	bucket := strings.TrimSpace(m.provisionFormInputs[6].Value())
 	quota := strings.TrimSpace(m.provisionFormInputs[7].Value())
 	owner := strings.TrimSpace(m.provisionFormInputs[8].Value())
+	tagsStr := strings.TrimSpace(m.provisionFormInputs[9].Value())
 
 	// Defaults and validation
 	if access == "" {
@@ -525,6 +571,11 @@ func (m Model) handleProvision() (tea.Model, tea.Cmd) {
 		m.errorMessage = "Project ID must be a number"
 		return m, nil
 	}
+	tags, err := services.ParseTags(tagsStr)
+	if err != nil {
+		m.errorMessage = err.Error()
+		return m, nil
+	}
 
 	userReq := models.UserCreateRequest{
 		Access:    access,
@@ -535,7 +586,9 @@ func (m Model) handleProvision() (tea.Model, tea.Cmd) {
 		ProjectID: pid,
 	}
 
-	if err := m.versitygwService.CreateUser(userReq); err != nil {
+	audit := m.audit("provision", access, map[string]any{"secret": secret, "role": role, "bucket": bucket, "quota": quota, "owner": owner, "tags": services.FormatTags(tags)})
+	if err := m.versitygwService.CreateUser(context.Background(), userReq); err != nil {
+		audit.Finish(fmt.Errorf("failed to create user: %w", err))
 		m.errorMessage = fmt.Sprintf("Failed to create user: %v", err)
 		return m, nil
 	}
@@ -546,16 +599,31 @@ func (m Model) handleProvision() (tea.Model, tea.Cmd) {
 		Owner: owner,
 	}
 
-	if err := m.bucketService.CreateBucket(bucketReq); err != nil {
+	if err := m.bucketService.CreateBucket(context.Background(), bucketReq); err != nil {
+		audit.Finish(fmt.Errorf("failed to create bucket: %w", err))
 		m.errorMessage = fmt.Sprintf("Failed to create bucket: %v", err)
 		return m, nil
 	}
 
-	if err := m.versitygwService.ChangeBucketOwner(bucket, owner); err != nil {
+	if err := services.ChangeBucketOwner(context.Background(), m.cfg, bucket, owner); errors.Is(err, services.ErrServiceAccountGrant) {
+		audit.Finish(err)
+		m.errorMessage = fmt.Sprintf("Bucket created, but %v", err)
+		return m, nil
+	} else if err != nil {
+		audit.Finish(fmt.Errorf("bucket created but failed to set owner: %w", err))
 		m.errorMessage = fmt.Sprintf("Bucket created but failed to set owner: %v", err)
 		return m, nil
 	}
 
+	if len(tags) > 0 {
+		if err := m.versitygwService.PutBucketTagging(context.Background(), bucket, tags); err != nil {
+			audit.Finish(fmt.Errorf("bucket created but failed to set tags: %w", err))
+			m.errorMessage = fmt.Sprintf("Bucket created but failed to set tags: %v", err)
+			return m, nil
+		}
+	}
+	audit.Finish(nil)
+
 	m.successMessage = fmt.Sprintf("Provisioned user '%s' and bucket '%s' (owner '%s')", access, bucket, owner)
 	m.currentView = m.returnView
 	m.cursor = 0
@@ -584,6 +652,7 @@ func (m Model) provisionLines() []string {
 		"Bucket Name:",
 		"Quota:",
 		"Owner:",
+		"Tags:",
 	}
 
 	for i, input := range m.provisionFormInputs {
@@ -612,7 +681,7 @@ func (m Model) provisionLines() []string {
 	}
 
 	add(helpStyle.Render("tab: Next • shift+tab: Prev • enter: Submit/Select • esc: Cancel • PgUp/PgDn scroll"))
-	add(dimStyle.Render("Creates user, then bucket, then sets bucket owner."))
+	add(dimStyle.Render("Creates user, then bucket, then sets bucket owner and tags."))
 
 	if m.errorMessage != "" {
 		add(errorStyle.Render("Error: " + m.errorMessage))
@@ -799,8 +868,9 @@ func (m Model) handleMakePublic() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	policy := services.GeneratePublicPolicy(bucketName, owner)
-	err := m.versitygwService.SetBucketPolicy(bucketName, policy)
+	audit := m.audit("bucket.make_public", bucketName, map[string]any{"owner": owner})
+	err := services.MakeBucketPublic(context.Background(), m.cfg, bucketName, owner)
+	audit.Finish(err)
 	if err != nil {
 		m.errorMessage = fmt.Sprintf("Failed to make public: %v", err)
 		return m, nil
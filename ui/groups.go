@@ -0,0 +1,509 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/models"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// Group form modes.
+const (
+	groupFormCreate = "create"
+	groupFormMember = "member"
+	groupFormGrant  = "grant"
+)
+
+// groupEntry is one row of the group detail view: a member or a bucket grant.
+type groupEntry struct {
+	member string
+	grant  models.GroupGrant
+}
+
+// openGroups loads the groups list.
+func (m Model) openGroups() (tea.Model, tea.Cmd) {
+	groups, err := services.ListGroups(m.cfg)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Error loading groups: %v", err)
+		return m, nil
+	}
+	m.groups = groups
+	m.currentView = GroupsListView
+	m.cursor = 0
+	m.page = 0
+	return m, nil
+}
+
+// reloadGroups refreshes the groups list and the selected group after a change.
+func (m *Model) reloadGroups() {
+	groups, err := services.ListGroups(m.cfg)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Error loading groups: %v", err)
+		return
+	}
+	m.groups = groups
+	for _, group := range groups {
+		if group.Name == m.selectedGroup.Name {
+			m.selectedGroup = group
+		}
+	}
+	if m.page*m.pageSize+m.cursor > m.getMaxCursorForView() {
+		m.page, m.cursor = 0, 0
+	}
+}
+
+// openSelectedGroup shows the members and bucket grants of the group under the cursor.
+func (m Model) openSelectedGroup() (tea.Model, tea.Cmd) {
+	idx := m.page*m.pageSize + m.cursor
+	if idx < 0 || idx >= len(m.groups) {
+		return m, nil
+	}
+	m.selectedGroup = m.groups[idx]
+	m.currentView = GroupDetailView
+	m.cursor = 0
+	m.page = 0
+	return m, nil
+}
+
+// groupEntries lists the selected group's members followed by its bucket grants.
+func (m Model) groupEntries() []groupEntry {
+	entries := make([]groupEntry, 0, len(m.selectedGroup.Members)+len(m.selectedGroup.Buckets))
+	for _, member := range m.selectedGroup.Members {
+		entries = append(entries, groupEntry{member: member})
+	}
+	for _, grant := range m.selectedGroup.Buckets {
+		entries = append(entries, groupEntry{grant: grant})
+	}
+	return entries
+}
+
+// confirmDeleteGroupItem asks to delete the group under the cursor (list view)
+// or to remove the member/grant under the cursor (detail view).
+func (m Model) confirmDeleteGroupItem() (tea.Model, tea.Cmd) {
+	idx := m.page*m.pageSize + m.cursor
+	m.returnView = m.currentView
+
+	if m.currentView == GroupsListView {
+		if idx < 0 || idx >= len(m.groups) {
+			return m, nil
+		}
+		m.pendingAction = "delete_group"
+		m.pendingTarget = m.groups[idx].Name
+		m.currentView = ConfirmView
+		return m, nil
+	}
+
+	entries := m.groupEntries()
+	if idx < 0 || idx >= len(entries) {
+		return m, nil
+	}
+	if entries[idx].member != "" {
+		m.pendingAction = "remove_group_member"
+		m.pendingTarget = entries[idx].member
+	} else {
+		m.pendingAction = "revoke_group_bucket"
+		m.pendingTarget = entries[idx].grant.Bucket
+	}
+	m.currentView = ConfirmView
+	return m, nil
+}
+
+// executeGroupAction performs a confirmed group action and reports the outcome.
+func (m *Model) executeGroupAction() {
+	var audit *services.Audit
+	var err error
+	var done string
+	switch m.pendingAction {
+	case "delete_group":
+		audit = m.audit("group.delete", m.pendingTarget, nil)
+		err = services.DeleteGroup(context.Background(), m.cfg, m.pendingTarget)
+		done = fmt.Sprintf("Group '%s' deleted", m.pendingTarget)
+	case "remove_group_member":
+		audit = m.audit("group.remove_member", m.selectedGroup.Name, map[string]any{"access": m.pendingTarget})
+		_, err = services.RemoveGroupMember(context.Background(), m.cfg, m.selectedGroup.Name, m.pendingTarget)
+		done = fmt.Sprintf("'%s' removed from group '%s'", m.pendingTarget, m.selectedGroup.Name)
+	case "revoke_group_bucket":
+		audit = m.audit("group.revoke_bucket", m.selectedGroup.Name, map[string]any{"bucket": m.pendingTarget})
+		_, err = services.RevokeGroupBucket(context.Background(), m.cfg, m.selectedGroup.Name, m.pendingTarget)
+		done = fmt.Sprintf("Group '%s' no longer has access to '%s'", m.selectedGroup.Name, m.pendingTarget)
+	}
+	audit.Finish(err)
+
+	if err != nil {
+		m.errorMessage = err.Error()
+	} else {
+		m.successMessage = done
+	}
+	m.currentView = m.returnView
+	m.reloadGroups()
+}
+
+// syncSelectedGroup re-applies the selected group's bucket policy statements.
+func (m Model) syncSelectedGroup() (tea.Model, tea.Cmd) {
+	audit := m.audit("group.sync", m.selectedGroup.Name, nil)
+	buckets, err := services.SyncGroup(context.Background(), m.cfg, m.selectedGroup.Name)
+	audit.Finish(err)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to sync group: %v", err)
+		return m, nil
+	}
+	m.successMessage = fmt.Sprintf("Group policies re-applied on %d bucket(s)", len(buckets))
+	return m, nil
+}
+
+func (m Model) renderGroupsList() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Groups") + "\n\n")
+
+	start := m.page * m.pageSize
+	end := start + m.pageSize
+	if end > len(m.groups) {
+		end = len(m.groups)
+	}
+
+	header := fmt.Sprintf("  %-25s %-8s %-8s %-40s", "Name", "Members", "Buckets", "Description")
+	s.WriteString(dimStyle.Render(header) + "\n")
+	s.WriteString(dimStyle.Render(strings.Repeat("-", 86)) + "\n")
+
+	if len(m.groups) == 0 {
+		s.WriteString(dimStyle.Render("  No groups. Press 'a' to create one.") + "\n")
+	}
+
+	for i := start; i < end; i++ {
+		group := m.groups[i]
+		cursor := " "
+		if m.cursor == (i - start) {
+			cursor = ">"
+		}
+
+		line := fmt.Sprintf("%s %-25s %-8d %-8d %-40s",
+			cursor,
+			truncate(group.Name, 25),
+			len(group.Members),
+			len(group.Buckets),
+			truncate(group.Description, 40),
+		)
+
+		if m.cursor == (i - start) {
+			s.WriteString(selectedTableRowStyle.Render(line) + "\n")
+		} else {
+			s.WriteString(line + "\n")
+		}
+	}
+
+	help := helpStyle.Render("↑/↓: Navigate • Enter: View • a: Add Group • d: Delete • esc: Back")
+	s.WriteString("\n" + help)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render(m.errorMessage))
+	} else if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
+
+func (m Model) renderGroupDetail() string {
+	var s strings.Builder
+
+	group := m.selectedGroup
+	s.WriteString(titleStyle.Render("Group: "+group.Name) + "\n")
+	if group.Description != "" {
+		s.WriteString(dimStyle.Render(group.Description) + "\n")
+	}
+	s.WriteString("\n")
+
+	entries := m.groupEntries()
+	start := m.page * m.pageSize
+	end := start + m.pageSize
+	if end > len(entries) {
+		end = len(entries)
+	}
+
+	header := fmt.Sprintf("  %-8s %-40s %-10s", "Type", "Name", "Access")
+	s.WriteString(dimStyle.Render(header) + "\n")
+	s.WriteString(dimStyle.Render(strings.Repeat("-", 62)) + "\n")
+
+	if len(entries) == 0 {
+		s.WriteString(dimStyle.Render("  No members or buckets. Press 'm' to add a member, 'b' to grant a bucket.") + "\n")
+	}
+
+	for i := start; i < end; i++ {
+		entry := entries[i]
+		cursor := " "
+		if m.cursor == (i - start) {
+			cursor = ">"
+		}
+
+		kind, name, access := "member", entry.member, ""
+		if entry.member == "" {
+			kind, name, access = "bucket", entry.grant.Bucket, entry.grant.Permission
+		}
+		line := fmt.Sprintf("%s %-8s %-40s %-10s", cursor, kind, truncate(name, 40), access)
+
+		if m.cursor == (i - start) {
+			s.WriteString(selectedTableRowStyle.Render(line) + "\n")
+		} else {
+			s.WriteString(line + "\n")
+		}
+	}
+
+	help := helpStyle.Render("↑/↓: Navigate • m: Add Member • b: Grant Bucket • d: Remove • s: Re-apply Policies • esc: Back")
+	s.WriteString("\n" + help)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render(m.errorMessage))
+	} else if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
+
+// initGroupForm initializes the group form for creating a group, adding a
+// member or granting a bucket.
+func (m *Model) initGroupForm(mode string) {
+	var inputs []textinput.Model
+
+	switch mode {
+	case groupFormCreate:
+		inputs = make([]textinput.Model, 2)
+		inputs[0] = textinput.New()
+		inputs[0].Placeholder = "dev-team"
+		inputs[0].CharLimit = 63
+		inputs[1] = textinput.New()
+		inputs[1].Placeholder = "Developers of the web shop"
+		inputs[1].CharLimit = 256
+	case groupFormMember:
+		inputs = make([]textinput.Model, 1)
+		inputs[0] = textinput.New()
+		inputs[0].Placeholder = "alice"
+		inputs[0].CharLimit = 128
+	case groupFormGrant:
+		inputs = make([]textinput.Model, 2)
+		inputs[0] = textinput.New()
+		inputs[0].Placeholder = "shared-data"
+		inputs[0].CharLimit = 63
+		inputs[1] = textinput.New()
+		inputs[1].Placeholder = "read or readwrite"
+		inputs[1].SetValue(services.PermissionReadWrite)
+		inputs[1].CharLimit = 16
+	}
+	for i := range inputs {
+		inputs[i].Width = 50
+	}
+	inputs[0].Focus()
+
+	m.groupFormMode = mode
+	m.groupFormInputs = inputs
+	m.groupFormReturn = m.currentView
+	m.focusIndex = 0
+	m.currentView = GroupFormView
+}
+
+// renderGroupForm renders the group form
+func (m Model) renderGroupForm() string {
+	var s strings.Builder
+
+	var title, submit string
+	var labels []string
+	switch m.groupFormMode {
+	case groupFormCreate:
+		title, submit, labels = "New Group", "[ Create ]", []string{"Name:", "Description:"}
+	case groupFormMember:
+		title, submit, labels = "Add Member to "+m.selectedGroup.Name, "[ Add ]", []string{"Access Key:"}
+	case groupFormGrant:
+		title, submit, labels = "Grant Bucket to "+m.selectedGroup.Name, "[ Grant ]", []string{"Bucket:", "Permission:"}
+	}
+
+	s.WriteString(titleStyle.Render(title) + "\n\n")
+
+	for i, input := range m.groupFormInputs {
+		label := inputLabelStyle.Render(labels[i])
+		s.WriteString(label + "\n")
+
+		if i == m.focusIndex {
+			s.WriteString(focusedInputStyle.Render(input.View()) + "\n\n")
+		} else {
+			s.WriteString(inputStyle.Render(input.View()) + "\n\n")
+		}
+	}
+
+	cancelBtn := "[ Cancel ]"
+
+	if m.focusIndex == len(m.groupFormInputs) {
+		s.WriteString(focusedButtonStyle.Render(submit) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	} else if m.focusIndex == len(m.groupFormInputs)+1 {
+		s.WriteString(buttonStyle.Render(submit) + "  ")
+		s.WriteString(focusedButtonStyle.Render(cancelBtn) + "\n")
+	} else {
+		s.WriteString(buttonStyle.Render(submit) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	}
+
+	help := helpStyle.Render("tab: Next field • shift+tab: Previous • enter: Submit/Select • esc: Cancel")
+	s.WriteString("\n" + help)
+
+	if m.groupFormMode != groupFormCreate {
+		info := dimStyle.Render("Bucket policies of the group's buckets are updated immediately.")
+		s.WriteString("\n" + info)
+	}
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render("Error: "+m.errorMessage))
+	}
+
+	return s.String()
+}
+
+// updateGroupForm handles key events for the group form
+func (m Model) updateGroupForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		m.currentView = m.groupFormReturn
+		return m, nil
+
+	case "tab", "down":
+		m.focusIndex++
+		if m.focusIndex > len(m.groupFormInputs)+1 {
+			m.focusIndex = 0
+		}
+		m.updateGroupFormFocus()
+		return m, nil
+
+	case "shift+tab", "up":
+		m.focusIndex--
+		if m.focusIndex < 0 {
+			m.focusIndex = len(m.groupFormInputs) + 1
+		}
+		m.updateGroupFormFocus()
+		return m, nil
+
+	case "enter":
+		if m.focusIndex == len(m.groupFormInputs)+1 {
+			m.currentView = m.groupFormReturn
+			return m, nil
+		}
+		return m.handleGroupForm()
+	}
+
+	if m.focusIndex < len(m.groupFormInputs) {
+		m.groupFormInputs[m.focusIndex], cmd = m.groupFormInputs[m.focusIndex].Update(msg)
+	}
+
+	return m, cmd
+}
+
+// updateGroupFormFocus updates focus state for group form inputs
+func (m *Model) updateGroupFormFocus() {
+	for i := range m.groupFormInputs {
+		if i == m.focusIndex {
+			m.groupFormInputs[i].Focus()
+		} else {
+			m.groupFormInputs[i].Blur()
+		}
+	}
+}
+
+// handleGroupForm submits the group form
+func (m Model) handleGroupForm() (tea.Model, tea.Cmd) {
+	first := strings.TrimSpace(m.groupFormInputs[0].Value())
+	if first == "" {
+		m.errorMessage = "All fields except the description are required"
+		return m, nil
+	}
+
+	var audit *services.Audit
+	var err error
+	var done string
+	switch m.groupFormMode {
+	case groupFormCreate:
+		var group *models.Group
+		audit = m.audit("group.create", first, map[string]any{"description": strings.TrimSpace(m.groupFormInputs[1].Value())})
+		group, err = services.CreateGroup(m.cfg, first, strings.TrimSpace(m.groupFormInputs[1].Value()))
+		if err == nil {
+			m.selectedGroup = *group
+		}
+		done = fmt.Sprintf("Group '%s' created", first)
+	case groupFormMember:
+		audit = m.audit("group.add_member", m.selectedGroup.Name, map[string]any{"access": first})
+		_, err = services.AddGroupMember(context.Background(), m.cfg, m.selectedGroup.Name, first)
+		done = fmt.Sprintf("'%s' added to group '%s'", first, m.selectedGroup.Name)
+	case groupFormGrant:
+		permission := strings.TrimSpace(m.groupFormInputs[1].Value())
+		audit = m.audit("group.grant_bucket", m.selectedGroup.Name, map[string]any{"bucket": first, "permission": permission})
+		_, err = services.GrantGroupBucket(context.Background(), m.cfg, m.selectedGroup.Name, first, permission)
+		done = fmt.Sprintf("Group '%s' granted %s access to '%s'", m.selectedGroup.Name, permission, first)
+	}
+	audit.Finish(err)
+	if err != nil {
+		m.errorMessage = err.Error()
+		return m, nil
+	}
+
+	m.currentView = m.groupFormReturn
+	m.reloadGroups()
+	m.successMessage = done
+	return m, nil
+}
+
+// openUserAccess shows every bucket the selected user can access.
+func (m Model) openUserAccess() (tea.Model, tea.Cmd) {
+	if m.selectedUserIndex < 0 || m.selectedUserIndex >= len(m.users) {
+		return m, nil
+	}
+	report, err := services.UserAccess(context.Background(), m.cfg, m.users[m.selectedUserIndex].Access)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to determine access: %v", err)
+		return m, nil
+	}
+	m.userAccessReport = report
+	m.currentView = UserAccessView
+	return m, nil
+}
+
+func (m Model) renderUserAccess() string {
+	var s strings.Builder
+
+	report := m.userAccessReport
+	s.WriteString(titleStyle.Render("Access of "+report.Access) + "\n\n")
+
+	if report.ServiceAccountOf != "" {
+		s.WriteString(tableHeaderStyle.Render("Service Account Of") + "\n")
+		s.WriteString(tableCellStyle.Render(report.ServiceAccountOf) + "\n\n")
+	}
+	groups := "None"
+	if len(report.Groups) > 0 {
+		groups = strings.Join(report.Groups, ", ")
+	}
+	s.WriteString(tableHeaderStyle.Render("Groups") + "\n")
+	s.WriteString(tableCellStyle.Render(groups) + "\n\n")
+
+	header := fmt.Sprintf("  %-30s %-10s %-50s", "Bucket", "Access", "Via")
+	s.WriteString(dimStyle.Render(header) + "\n")
+	s.WriteString(dimStyle.Render(strings.Repeat("-", 94)) + "\n")
+
+	if len(report.Buckets) == 0 {
+		s.WriteString(dimStyle.Render("  No accessible buckets.") + "\n")
+	}
+	for _, entry := range report.Buckets {
+		s.WriteString(fmt.Sprintf("  %-30s %-10s %-50s\n",
+			truncate(entry.Bucket, 30), entry.Access, truncate(strings.Join(entry.Via, ", "), 50)))
+	}
+
+	help := helpStyle.Render("esc: Back")
+	s.WriteString("\n" + help)
+
+	return s.String()
+}
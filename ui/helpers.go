@@ -4,19 +4,41 @@ import (
 	"fmt"
 
 	"github.com/atotto/clipboard"
+	"github.com/monobilisim/vgw-manager/services"
 )
 
+// audit begins the audit record of a mutating TUI operation.
+func (m Model) audit(operation, target string, params map[string]any) *services.Audit {
+	return services.StartAudit(m.cfg, services.LocalActor("tui"), operation, target, params)
+}
+
 // getMaxCursorForView returns the maximum cursor index for the current view
 func (m Model) getMaxCursorForView() int {
 	switch m.currentView {
 	case MainMenuView:
-		return 3 // 4 menu items (0-3)
+		return 4 // 5 menu items (0-4)
 	case OperationsView:
 		return 4 // 5 operations (0-4)
 	case UsersListView:
 		return len(m.users) - 1
 	case BucketsListView:
 		return len(m.buckets) - 1
+	case LifecycleView:
+		return len(m.lifecycleRules) - 1
+	case CorsView:
+		return len(m.corsRules) - 1
+	case ObjectBrowserView:
+		return len(m.objectEntries) - 1
+	case ServiceAccountsView:
+		return len(m.serviceAccounts) - 1
+	case GroupsListView:
+		return len(m.groups) - 1
+	case GroupDetailView:
+		return len(m.groupEntries()) - 1
+	case ClientConfigView:
+		return len(services.ClientConfigFormats) - 1
+	case SitesView:
+		return len(m.root.SiteNames()) - 1
 	default:
 		return 0
 	}
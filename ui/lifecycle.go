@@ -0,0 +1,331 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// openLifecycleView loads the lifecycle rules of the selected bucket and shows them
+func (m Model) openLifecycleView() (tea.Model, tea.Cmd) {
+	if m.selectedBucketIndex < 0 || m.selectedBucketIndex >= len(m.buckets) {
+		m.errorMessage = "Invalid bucket selection"
+		return m, nil
+	}
+
+	bucket := m.buckets[m.selectedBucketIndex].Name
+	rules, err := m.versitygwService.GetBucketLifecycle(context.Background(), bucket)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to load lifecycle rules: %v", err)
+		return m, nil
+	}
+
+	m.lifecycleBucket = bucket
+	m.lifecycleRules = rules
+	m.currentView = LifecycleView
+	m.cursor = 0
+	m.page = 0
+	return m, nil
+}
+
+// editSelectedLifecycleRule opens the rule form for the rule under the cursor
+func (m Model) editSelectedLifecycleRule() (tea.Model, tea.Cmd) {
+	idx := m.page*m.pageSize + m.cursor
+	if idx < 0 || idx >= len(m.lifecycleRules) {
+		return m, nil
+	}
+	if rule := m.lifecycleRules[idx]; rule.Unmanaged {
+		m.errorMessage = fmt.Sprintf("Rule %s uses settings that cannot be edited here; change it with an S3 client", rule.ID)
+		return m, nil
+	}
+	m.initLifecycleForm(m.lifecycleRules[idx])
+	m.currentView = LifecycleRuleFormView
+	return m, nil
+}
+
+// reloadLifecycleRules refreshes the rule list after a change, keeping the cursor in range
+func (m *Model) reloadLifecycleRules() {
+	rules, err := m.versitygwService.GetBucketLifecycle(context.Background(), m.lifecycleBucket)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to reload lifecycle rules: %v", err)
+		return
+	}
+	m.lifecycleRules = rules
+	m.page = 0
+	if m.cursor >= len(rules) {
+		m.cursor = max(0, len(rules)-1)
+	}
+}
+
+// renderLifecycleView renders the lifecycle rules of a bucket
+func (m Model) renderLifecycleView() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Lifecycle Rules: "+m.lifecycleBucket) + "\n\n")
+
+	start := m.page * m.pageSize
+	end := start + m.pageSize
+	if end > len(m.lifecycleRules) {
+		end = len(m.lifecycleRules)
+	}
+
+	header := fmt.Sprintf("  %-20s %-30s %-9s %-12s %-15s", "Rule ID", "Prefix", "Status", "Expire Days", "Abort MPU Days")
+	s.WriteString(dimStyle.Render(header) + "\n")
+	s.WriteString(dimStyle.Render(strings.Repeat("-", 92)) + "\n")
+
+	if len(m.lifecycleRules) == 0 {
+		s.WriteString(dimStyle.Render("  No lifecycle rules. Press 'a' to add one.") + "\n")
+	}
+
+	for i := start; i < end; i++ {
+		rule := m.lifecycleRules[i]
+		cursor := " "
+		if m.cursor == (i - start) {
+			cursor = ">"
+		}
+
+		prefix := rule.Prefix
+		if prefix == "" {
+			prefix = "(all objects)"
+		}
+		id := rule.ID
+		if rule.Unmanaged {
+			id = truncate(id, 18) + " *"
+		}
+
+		line := fmt.Sprintf("%s %-20s %-30s %-9s %-12s %-15s",
+			cursor,
+			truncate(id, 20),
+			truncate(prefix, 30),
+			rule.Status(),
+			formatDays(rule.ExpirationDays),
+			formatDays(rule.AbortIncompleteMultipartDays),
+		)
+
+		if m.cursor == (i - start) {
+			s.WriteString(selectedTableRowStyle.Render(line) + "\n")
+		} else {
+			s.WriteString(line + "\n")
+		}
+	}
+
+	for _, rule := range m.lifecycleRules {
+		if rule.Unmanaged {
+			s.WriteString("\n" + dimStyle.Render("  * uses settings that can only be changed with an S3 client; kept unchanged when other rules change") + "\n")
+			break
+		}
+	}
+
+	help := helpStyle.Render("↑/↓: Navigate • a: Add Rule • e/enter: Edit • d: Delete • esc: Back")
+	s.WriteString("\n" + help)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render(m.errorMessage))
+	} else if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
+
+// formatDays renders a day count, or "-" when unset
+func formatDays(days int) string {
+	if days <= 0 {
+		return "-"
+	}
+	return strconv.Itoa(days)
+}
+
+// initLifecycleForm initializes the lifecycle rule form, prefilled with rule
+func (m *Model) initLifecycleForm(rule services.LifecycleRule) {
+	inputs := make([]textinput.Model, 4)
+
+	// Rule ID
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "Rule ID (e.g. expire-logs)"
+	inputs[0].SetValue(rule.ID)
+	inputs[0].Focus()
+	inputs[0].CharLimit = 255
+	inputs[0].Width = 40
+
+	// Prefix
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = "Prefix (empty = all objects)"
+	inputs[1].SetValue(rule.Prefix)
+	inputs[1].CharLimit = 1024
+	inputs[1].Width = 40
+
+	// Expiration days
+	inputs[2] = textinput.New()
+	inputs[2].Placeholder = "Expire objects after N days (0 = never)"
+	inputs[2].SetValue(strconv.Itoa(rule.ExpirationDays))
+	inputs[2].CharLimit = 6
+	inputs[2].Width = 15
+
+	// Abort incomplete multipart days
+	inputs[3] = textinput.New()
+	inputs[3].Placeholder = "Abort incomplete uploads after N days (0 = never)"
+	inputs[3].SetValue(strconv.Itoa(rule.AbortIncompleteMultipartDays))
+	inputs[3].CharLimit = 6
+	inputs[3].Width = 15
+
+	m.lifecycleFormInputs = inputs
+	m.focusIndex = 0
+}
+
+// renderLifecycleForm renders the lifecycle rule form
+func (m Model) renderLifecycleForm() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Lifecycle Rule: "+m.lifecycleBucket) + "\n\n")
+
+	labels := []string{"Rule ID:", "Prefix:", "Expire After (days):", "Abort Incomplete Multipart After (days):"}
+
+	for i, input := range m.lifecycleFormInputs {
+		label := inputLabelStyle.Render(labels[i])
+		s.WriteString(label + "\n")
+
+		if i == m.focusIndex {
+			s.WriteString(focusedInputStyle.Render(input.View()) + "\n\n")
+		} else {
+			s.WriteString(inputStyle.Render(input.View()) + "\n\n")
+		}
+	}
+
+	saveBtn := "[ Save Rule ]"
+	cancelBtn := "[ Cancel ]"
+
+	if m.focusIndex == len(m.lifecycleFormInputs) {
+		s.WriteString(focusedButtonStyle.Render(saveBtn) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	} else if m.focusIndex == len(m.lifecycleFormInputs)+1 {
+		s.WriteString(buttonStyle.Render(saveBtn) + "  ")
+		s.WriteString(focusedButtonStyle.Render(cancelBtn) + "\n")
+	} else {
+		s.WriteString(buttonStyle.Render(saveBtn) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	}
+
+	help := helpStyle.Render("tab: Next field • shift+tab: Previous • enter: Submit/Select • esc: Cancel")
+	s.WriteString("\n" + help)
+
+	info := dimStyle.Render("Saving a rule with an existing ID replaces that rule.")
+	s.WriteString("\n" + info)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render("Error: "+m.errorMessage))
+	}
+	if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
+
+// updateLifecycleForm handles key events for the lifecycle rule form
+func (m Model) updateLifecycleForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		m.currentView = LifecycleView
+		return m, nil
+
+	case "tab", "down":
+		m.focusIndex++
+		if m.focusIndex > len(m.lifecycleFormInputs)+1 {
+			m.focusIndex = 0
+		}
+		m.updateLifecycleFormFocus()
+		return m, nil
+
+	case "shift+tab", "up":
+		m.focusIndex--
+		if m.focusIndex < 0 {
+			m.focusIndex = len(m.lifecycleFormInputs) + 1
+		}
+		m.updateLifecycleFormFocus()
+		return m, nil
+
+	case "enter":
+		if m.focusIndex == len(m.lifecycleFormInputs) {
+			return m.handleSaveLifecycleRule()
+		} else if m.focusIndex == len(m.lifecycleFormInputs)+1 {
+			m.currentView = LifecycleView
+			return m, nil
+		}
+	}
+
+	if m.focusIndex < len(m.lifecycleFormInputs) {
+		m.lifecycleFormInputs[m.focusIndex], cmd = m.lifecycleFormInputs[m.focusIndex].Update(msg)
+	}
+
+	return m, cmd
+}
+
+// updateLifecycleFormFocus updates focus state for lifecycle form inputs
+func (m *Model) updateLifecycleFormFocus() {
+	for i := range m.lifecycleFormInputs {
+		if i == m.focusIndex {
+			m.lifecycleFormInputs[i].Focus()
+		} else {
+			m.lifecycleFormInputs[i].Blur()
+		}
+	}
+}
+
+// handleSaveLifecycleRule adds or replaces the rule in the lifecycle form
+func (m Model) handleSaveLifecycleRule() (tea.Model, tea.Cmd) {
+	parseDays := func(val string) (int, error) {
+		val = strings.TrimSpace(val)
+		if val == "" {
+			return 0, nil
+		}
+		return strconv.Atoi(val)
+	}
+
+	expireDays, err := parseDays(m.lifecycleFormInputs[2].Value())
+	if err != nil {
+		m.errorMessage = "Expiration days must be a number"
+		return m, nil
+	}
+	abortDays, err := parseDays(m.lifecycleFormInputs[3].Value())
+	if err != nil {
+		m.errorMessage = "Abort multipart days must be a number"
+		return m, nil
+	}
+
+	rule := services.LifecycleRule{
+		ID:                           strings.TrimSpace(m.lifecycleFormInputs[0].Value()),
+		Prefix:                       m.lifecycleFormInputs[1].Value(),
+		Enabled:                      true,
+		ExpirationDays:               expireDays,
+		AbortIncompleteMultipartDays: abortDays,
+	}
+	if err := rule.Validate(); err != nil {
+		m.errorMessage = err.Error()
+		return m, nil
+	}
+
+	audit := m.audit("lifecycle.put_rule", m.lifecycleBucket, map[string]any{"rule-id": rule.ID, "prefix": rule.Prefix, "expire-days": rule.ExpirationDays, "abort-multipart-days": rule.AbortIncompleteMultipartDays})
+	err = services.PutLifecycleRule(context.Background(), m.cfg, m.lifecycleBucket, rule)
+	audit.Finish(err)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to save lifecycle rule: %v", err)
+		return m, nil
+	}
+
+	m.currentView = LifecycleView
+	m.reloadLifecycleRules()
+	m.successMessage = fmt.Sprintf("Lifecycle rule '%s' saved", rule.ID)
+
+	return m, nil
+}
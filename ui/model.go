@@ -1,12 +1,14 @@
 package ui
 
 import (
+	"context"
 	"fmt"
-	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/config"
 	"github.com/monobilisim/vgw-manager/models"
 	"github.com/monobilisim/vgw-manager/services"
 )
@@ -28,6 +30,24 @@ const (
 	UpdateUserView
 	MakeBucketPublicView
 	ConfirmView
+	BucketTagsView
+	LifecycleView
+	LifecycleRuleFormView
+	CorsView
+	CorsFormView
+	ObjectBrowserView
+	ObjectDetailView
+	ObjectDownloadView
+	BucketReportView
+	ForceDeleteView
+	ClientConfigView
+	ServiceAccountsView
+	ServiceAccountFormView
+	GroupsListView
+	GroupDetailView
+	GroupFormView
+	UserAccessView
+	SitesView
 )
 
 // Model represents the main application state
@@ -37,17 +57,48 @@ type Model struct {
 	width       int
 	height      int
 
+	// Configuration: root holds every site, cfg the one being managed
+	root *config.Config
+	cfg  *config.Config
+
 	// Services
 	userService      *services.UserService
 	bucketService    *services.BucketService
 	versitygwService *services.VersityGWService
 
 	// Data
-	users               []models.User
-	buckets             []models.Bucket
-	selectedUserIndex   int
-	selectedBucketIndex int
-	returnView          View
+	users                []models.User
+	buckets              []models.Bucket
+	selectedUserIndex    int
+	selectedBucketIndex  int
+	returnView           View
+	lifecycleBucket      string
+	lifecycleRules       []services.LifecycleRule
+	corsBucket           string
+	corsRules            []services.CORSRule
+	objectBucket         string
+	objectPrefix         string
+	objectEntries        []objectEntry
+	objectNextToken      string
+	selectedObject       services.ObjectInfo
+	bucketReport         *services.BucketReport
+	bucketUploads        []services.MultipartUpload
+	bucketUploadsErr     string
+	forceDeleteBucket    string
+	forceDeleteRunning   bool
+	forceDeleteProgress  services.EmptyProgress
+	forceDeleteCh        chan tea.Msg
+	downloadRunning      bool
+	downloadCancel       context.CancelFunc
+	serviceAccountParent string
+	serviceAccounts      []models.ServiceAccount
+	serviceAccountsErr   string
+	userParent           string
+	groups               []models.Group
+	selectedGroup        models.Group
+	groupFormMode        string
+	groupFormReturn      View
+	userAccessReport     *services.UserAccessReport
 
 	// Cache for session-persistent data
 
@@ -56,6 +107,13 @@ type Model struct {
 	bucketFormInputs      []textinput.Model
 	changeOwnerFormInputs []textinput.Model
 	provisionFormInputs   []textinput.Model
+	tagFormInputs         []textinput.Model
+	lifecycleFormInputs   []textinput.Model
+	corsFormInputs        []textinput.Model
+	objectFormInputs      []textinput.Model
+	forceDeleteInput      textinput.Model
+	serviceAccountInputs  []textinput.Model
+	groupFormInputs       []textinput.Model
 	focusIndex            int
 
 	// Scroll state
@@ -72,13 +130,15 @@ type Model struct {
 	pendingTarget string // The name/access key of the item
 }
 
-// NewModel creates a new application model
-func NewModel() Model {
+// NewModel creates a new application model managing cfg, one of the sites of root
+func NewModel(root, cfg *config.Config) Model {
 	return Model{
 		currentView:      MainMenuView,
-		userService:      services.NewUserService(),
-		bucketService:    services.NewBucketService(),
-		versitygwService: services.NewVersityGWService(),
+		root:             root,
+		cfg:              cfg,
+		userService:      services.NewUserService(cfg),
+		bucketService:    services.NewBucketService(cfg),
+		versitygwService: services.NewVersityGWService(cfg),
 		cursor:           0,
 		page:             0,
 		pageSize:         20,
@@ -102,6 +162,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case forceDeleteProgressMsg:
+		m.forceDeleteProgress = services.EmptyProgress(msg)
+		return m, waitForForceDelete(m.forceDeleteCh)
+
+	case forceDeleteDoneMsg:
+		return m.handleForceDeleteDone(msg)
+
+	case objectDownloadDoneMsg:
+		return m.handleObjectDownloadDone(msg)
+
 	case tea.KeyMsg:
 		// Handle form views differently
 		if m.currentView == CreateUserView {
@@ -122,6 +192,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.currentView == MakeBucketPublicView {
 			return m.updateMakePublicForm(msg)
 		}
+		if m.currentView == BucketTagsView {
+			return m.updateTagForm(msg)
+		}
+		if m.currentView == LifecycleRuleFormView {
+			return m.updateLifecycleForm(msg)
+		}
+		if m.currentView == CorsFormView {
+			return m.updateCorsForm(msg)
+		}
+		if m.currentView == ObjectDownloadView {
+			return m.updateObjectDownloadForm(msg)
+		}
+		if m.currentView == ForceDeleteView {
+			return m.updateForceDelete(msg)
+		}
+		if m.currentView == ServiceAccountFormView {
+			return m.updateServiceAccountForm(msg)
+		}
+		if m.currentView == GroupFormView {
+			return m.updateGroupForm(msg)
+		}
 
 		// Clear messages on any key press
 		m.errorMessage = ""
@@ -138,14 +229,44 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case "esc":
+			if m.currentView == ClientConfigView {
+				return m.closeClientConfigPicker()
+			}
 			if m.currentView == UserDetailView {
 				m.currentView = UsersListView
 				return m, nil
 			}
+			if m.currentView == ServiceAccountsView {
+				m.loadUserServiceAccounts()
+				m.currentView = UserDetailView
+				return m, nil
+			}
+			if m.currentView == UserAccessView {
+				m.currentView = UserDetailView
+				return m, nil
+			}
+			if m.currentView == GroupDetailView {
+				m.currentView = GroupsListView
+				m.cursor = 0
+				m.page = 0
+				return m, nil
+			}
 			if m.currentView == BucketDetailView {
+				// Sub-views of the detail view move the cursor; restore the list position
+				m.page = m.selectedBucketIndex / m.pageSize
+				m.cursor = m.selectedBucketIndex % m.pageSize
 				m.currentView = BucketsListView
 				return m, nil
 			}
+			if m.currentView == LifecycleView || m.currentView == CorsView || m.currentView == ObjectBrowserView ||
+				m.currentView == BucketReportView {
+				m.currentView = BucketDetailView
+				return m, nil
+			}
+			if m.currentView == ObjectDetailView {
+				m.currentView = ObjectBrowserView
+				return m, nil
+			}
 			if m.currentView == ProvisionView {
 				m.currentView = OperationsView
 				m.cursor = 0
@@ -192,6 +313,80 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			return m.handleEnter()
 
+		case "backspace":
+			// Go up one prefix level in the object browser
+			if m.currentView == ObjectBrowserView && m.objectPrefix != "" {
+				m.loadObjects(services.ParentPrefix(m.objectPrefix))
+			}
+
+		case "o":
+			// Browse objects from bucket detail view
+			if m.currentView == BucketDetailView {
+				return m.openObjectBrowser()
+			}
+
+		case "r":
+			// Usage report from bucket detail view
+			if m.currentView == BucketDetailView {
+				return m.openBucketReport()
+			}
+
+		case "g", "G":
+			// Copy a presigned GET URL of the selected object (G: signed as bucket owner)
+			if m.currentView == ObjectBrowserView || m.currentView == ObjectDetailView {
+				signAs := services.PresignAsAdmin
+				if msg.String() == "G" {
+					signAs = services.PresignAsOwner
+				}
+				return m.copyPresignedURL(signAs)
+			}
+
+		case "F":
+			// Force-empty and delete a bucket (requires typing its name)
+			if (m.currentView == BucketsListView && len(m.buckets) > 0) || m.currentView == BucketDetailView {
+				return m.openForceDelete()
+			}
+
+		case "U":
+			// Abort stale multipart uploads from bucket detail view
+			if m.currentView == BucketDetailView {
+				return m.confirmAbortStaleUploads()
+			}
+
+		case "m":
+			// Load the next batch of a truncated object listing / add a group member
+			if m.currentView == ObjectBrowserView {
+				m.loadMoreObjects()
+			} else if m.currentView == GroupDetailView {
+				m.initGroupForm(groupFormMember)
+			}
+
+		case "b":
+			// Grant a bucket to a group
+			if m.currentView == GroupDetailView {
+				m.initGroupForm(groupFormGrant)
+			}
+
+		case "S":
+			// Switch to another configured site
+			if m.currentView == MainMenuView {
+				return m.openSitePicker()
+			}
+
+		case "w":
+			// Show every bucket a user can access
+			if m.currentView == UserDetailView {
+				return m.openUserAccess()
+			}
+
+		case "s":
+			// Download the selected object / re-apply group policies
+			if m.currentView == ObjectBrowserView || m.currentView == ObjectDetailView {
+				return m.openObjectDownloadForm()
+			} else if m.currentView == GroupDetailView {
+				return m.syncSelectedGroup()
+			}
+
 		case "c":
 			// Handle copy in UsersListView and UserDetailView
 			if m.currentView == UsersListView && len(m.users) > 0 {
@@ -222,6 +417,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+		case "x":
+			// Export a client config (aws, rclone, s3cmd, mc, env) for a user
+			if (m.currentView == UsersListView && len(m.users) > 0) || m.currentView == UserDetailView {
+				return m.openClientConfigPicker()
+			}
+
 		case "d":
 			// Handle Delete (User or Bucket)
 			if m.currentView == UsersListView && len(m.users) > 0 {
@@ -242,6 +443,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.returnView = BucketsListView
 					m.currentView = ConfirmView
 				}
+			} else if m.currentView == LifecycleView && len(m.lifecycleRules) > 0 {
+				idx := m.page*m.pageSize + m.cursor
+				if idx < len(m.lifecycleRules) {
+					m.pendingAction = "delete_lifecycle_rule"
+					m.pendingTarget = m.lifecycleRules[idx].ID
+					m.returnView = LifecycleView
+					m.currentView = ConfirmView
+				}
+			} else if m.currentView == ObjectBrowserView || m.currentView == ObjectDetailView {
+				return m.confirmDeleteObject()
+			} else if m.currentView == ServiceAccountsView {
+				return m.confirmServiceAccountAction("revoke_service_account")
+			} else if m.currentView == GroupsListView || m.currentView == GroupDetailView {
+				return m.confirmDeleteGroupItem()
+			} else if m.currentView == CorsView && len(m.corsRules) > 0 {
+				idx := m.page*m.pageSize + m.cursor
+				if idx < len(m.corsRules) {
+					m.pendingAction = "delete_cors_rule"
+					m.pendingTarget = strconv.Itoa(idx + 1)
+					m.returnView = CorsView
+					m.currentView = ConfirmView
+				}
 			}
 
 		case "e":
@@ -254,6 +477,47 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.currentView = UpdateUserView
 					m.returnView = UsersListView
 				}
+			} else if m.currentView == LifecycleView {
+				return m.editSelectedLifecycleRule()
+			}
+
+		case "a":
+			// Handle Add Lifecycle Rule / Add CORS Rule
+			if m.currentView == LifecycleView {
+				m.initLifecycleForm(services.LifecycleRule{})
+				m.currentView = LifecycleRuleFormView
+			} else if m.currentView == CorsView {
+				m.initCorsForm()
+				m.currentView = CorsFormView
+			} else if m.currentView == ServiceAccountsView {
+				m.initServiceAccountForm()
+				m.currentView = ServiceAccountFormView
+			} else if m.currentView == GroupsListView {
+				m.initGroupForm(groupFormCreate)
+			}
+
+		case "A":
+			// Manage service accounts of a user
+			if m.currentView == UserDetailView {
+				return m.openServiceAccounts()
+			}
+
+		case "R":
+			// Rotate a service account secret
+			if m.currentView == ServiceAccountsView {
+				return m.confirmServiceAccountAction("rotate_service_account")
+			}
+
+		case "C":
+			// Handle CORS Rules (Bucket Detail)
+			if m.currentView == BucketDetailView {
+				return m.openCorsView()
+			}
+
+		case "L":
+			// Handle Lifecycle Rules (Bucket Detail)
+			if m.currentView == BucketDetailView {
+				return m.openLifecycleView()
 			}
 
 		case "p":
@@ -282,6 +546,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+		case "t":
+			// Handle Edit Tags (Buckets)
+			if m.currentView == BucketsListView && len(m.buckets) > 0 {
+				idx := m.page*m.pageSize + m.cursor
+				if idx < len(m.buckets) {
+					m.selectedBucketIndex = idx
+					m.initTagForm(m.buckets[idx])
+					m.returnView = BucketsListView
+					m.currentView = BucketTagsView
+				}
+			} else if m.currentView == BucketDetailView {
+				if m.selectedBucketIndex >= 0 && m.selectedBucketIndex < len(m.buckets) {
+					m.initTagForm(m.buckets[m.selectedBucketIndex])
+					m.returnView = BucketDetailView
+					m.currentView = BucketTagsView
+				}
+			}
+
 		case "y", "Y":
 			if m.currentView == ConfirmView {
 				return m.executeConfirmedAction()
@@ -305,7 +587,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) executeConfirmedAction() (tea.Model, tea.Cmd) {
 	switch m.pendingAction {
 	case "delete_user":
-		if err := m.versitygwService.DeleteUser(m.pendingTarget); err != nil {
+		audit := m.audit("user.delete", m.pendingTarget, nil)
+		err := services.DeleteUser(context.Background(), m.cfg, m.pendingTarget)
+		audit.Finish(err)
+		if err != nil {
 			m.errorMessage = fmt.Sprintf("Failed to delete user: %v", err)
 		} else {
 			m.successMessage = fmt.Sprintf("User '%s' deleted", m.pendingTarget)
@@ -342,26 +627,103 @@ func (m Model) executeConfirmedAction() (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case "delete_lifecycle_rule":
+		audit := m.audit("lifecycle.delete_rule", m.lifecycleBucket, map[string]any{"rule-id": m.pendingTarget})
+		err := services.RemoveLifecycleRule(context.Background(), m.cfg, m.lifecycleBucket, m.pendingTarget)
+		audit.Finish(err)
+		if err != nil {
+			m.errorMessage = fmt.Sprintf("Failed to delete lifecycle rule: %v", err)
+		} else {
+			m.reloadLifecycleRules()
+			m.successMessage = fmt.Sprintf("Lifecycle rule '%s' deleted", m.pendingTarget)
+		}
+
+	case "delete_cors_rule":
+		// pendingTarget holds the 1-based rule number shown in the CORS view
+		number, _ := strconv.Atoi(m.pendingTarget)
+		audit := m.audit("cors.delete_rule", m.corsBucket, map[string]any{"rule": number})
+		err := services.RemoveCorsRule(context.Background(), m.cfg, m.corsBucket, number-1)
+		audit.Finish(err)
+		if err != nil {
+			m.errorMessage = fmt.Sprintf("Failed to delete CORS rule: %v", err)
+		} else {
+			m.reloadCorsRules()
+			m.successMessage = fmt.Sprintf("CORS rule #%s deleted", m.pendingTarget)
+		}
+
+	case "delete_object":
+		audit := m.audit("object.delete", m.objectBucket, map[string]any{"key": m.pendingTarget})
+		err := m.versitygwService.DeleteObject(context.Background(), m.objectBucket, m.pendingTarget)
+		audit.Finish(err)
+		if err != nil {
+			m.errorMessage = fmt.Sprintf("Failed to delete object: %v", err)
+		} else {
+			page, cursor := m.page, m.cursor
+			m.loadObjects(m.objectPrefix)
+			if page*m.pageSize+cursor < len(m.objectEntries) {
+				m.page, m.cursor = page, cursor
+			}
+			m.successMessage = fmt.Sprintf("Object '%s' deleted", m.pendingTarget)
+		}
+
+	case "revoke_service_account":
+		audit := m.audit("service_account.revoke", m.pendingTarget, nil)
+		err := services.RevokeServiceAccount(context.Background(), m.cfg, m.pendingTarget)
+		audit.Finish(err)
+		if err != nil {
+			m.errorMessage = fmt.Sprintf("Failed to revoke service account: %v", err)
+		} else {
+			m.successMessage = fmt.Sprintf("Service account '%s' revoked", m.pendingTarget)
+		}
+		m.reloadServiceAccounts()
+
+	case "rotate_service_account":
+		m.rotateServiceAccount(m.pendingTarget)
+
+	case "delete_group", "remove_group_member", "revoke_group_bucket":
+		m.executeGroupAction()
+
+	case "abort_multipart":
+		audit := m.audit("multipart.abort", m.pendingTarget, map[string]any{"older-than-hours": int(staleUploadAge.Hours())})
+		result, err := services.CleanupMultipartUploads(context.Background(), m.cfg, m.pendingTarget, staleUploadAge, false)
+		if err == nil && result.Failed > 0 {
+			audit.Finish(fmt.Errorf("%d of %d uploads failed to abort", result.Failed, len(result.Uploads)))
+		}
+		audit.Finish(err)
+		if err != nil {
+			m.errorMessage = fmt.Sprintf("Failed to abort multipart uploads: %v", err)
+		} else if result.Failed > 0 {
+			m.errorMessage = fmt.Sprintf("Aborted %d multipart upload(s), %d failed", result.Aborted, result.Failed)
+		} else {
+			m.successMessage = fmt.Sprintf("Aborted %d multipart upload(s) in '%s'", result.Aborted, m.pendingTarget)
+		}
+		m.loadBucketUploads()
+
 	case "delete_bucket":
 		successMessage := ""
-		err := m.bucketService.DeleteBucket(m.pendingTarget)
+		audit := m.audit("bucket.delete", m.pendingTarget, nil)
+		err := m.bucketService.DeleteBucket(context.Background(), m.pendingTarget)
 		if err != nil {
 			if strings.Contains(err.Error(), "dataset is busy") || strings.Contains(err.Error(), "not empty") {
-				m.errorMessage = fmt.Sprintf("Cannot delete bucket '%s': Bucket is not empty or busy.", m.pendingTarget)
+				audit.Finish(err)
+				m.errorMessage = fmt.Sprintf("Cannot delete bucket '%s': Bucket is not empty or busy. Press F to empty and delete it.", m.pendingTarget)
 				break
 			}
-			if apiErr := m.versitygwService.DeleteBucket(m.pendingTarget); apiErr != nil {
+			if apiErr := m.versitygwService.DeleteBucket(context.Background(), m.pendingTarget); apiErr != nil {
+				audit.Finish(fmt.Errorf("ZFS(%v) API(%v)", err, apiErr))
 				m.errorMessage = fmt.Sprintf("Failed to delete bucket: ZFS(%v) API(%v)", err, apiErr)
 				break
 			}
 			successMessage = fmt.Sprintf("Bucket '%s' deleted (via API).", m.pendingTarget)
 		} else {
-			if apiErr := m.versitygwService.DeleteBucket(m.pendingTarget); apiErr != nil {
+			if apiErr := m.versitygwService.DeleteBucket(context.Background(), m.pendingTarget); apiErr != nil {
+				audit.Finish(fmt.Errorf("ZFS deleted but API delete failed: %w", apiErr))
 				m.errorMessage = fmt.Sprintf("ZFS deleted but API delete failed: %v", apiErr)
 				break
 			}
 			successMessage = fmt.Sprintf("Bucket '%s' deleted.", m.pendingTarget)
 		}
+		audit.Finish(nil)
 
 		// Reload buckets
 		m.currentView = MainMenuView
@@ -392,19 +754,21 @@ func (m Model) executeConfirmedAction() (tea.Model, tea.Cmd) {
 			break
 		}
 
-		// Check if policy already exists
-		_, err := m.versitygwService.GetBucketPolicy(bucket.Name)
-		if err == nil {
-			m.errorMessage = fmt.Sprintf("Bucket '%s' is already PUBLIC (or has policy). Use 'P' to make private.", bucket.Name)
-		} else if !strings.Contains(err.Error(), "404") && !strings.Contains(err.Error(), "NoSuchBucketPolicy") {
+		// Check if the bucket is already public (service account grants alone keep it private)
+		public, err := services.IsBucketPublic(context.Background(), m.cfg, bucket.Name)
+		if err != nil {
 			m.errorMessage = fmt.Sprintf("Failed to check policy status: %v", err)
+		} else if public {
+			m.errorMessage = fmt.Sprintf("Bucket '%s' is already PUBLIC. Use 'P' to make private.", bucket.Name)
 		} else {
 			owner := bucket.Owner
 			if owner == "" || owner == "unknown" || owner == "root" {
 				owner = bucket.Name
 			}
-			policy := services.GeneratePublicPolicy(bucket.Name, owner)
-			if err := m.versitygwService.SetBucketPolicy(bucket.Name, policy); err != nil {
+			audit := m.audit("bucket.make_public", bucket.Name, map[string]any{"owner": owner})
+			err := services.MakeBucketPublic(context.Background(), m.cfg, bucket.Name, owner)
+			audit.Finish(err)
+			if err != nil {
 				m.initMakePublicForm()
 				m.bucketFormInputs[0].SetValue(bucket.Name)
 				m.bucketFormInputs[1].SetValue(owner)
@@ -426,7 +790,7 @@ func (m Model) executeConfirmedAction() (tea.Model, tea.Cmd) {
 		}
 
 	case "make_private":
-		_, err := m.versitygwService.GetBucketPolicy(m.pendingTarget)
+		_, err := m.versitygwService.GetBucketPolicy(context.Background(), m.pendingTarget)
 		if err != nil {
 			if strings.Contains(err.Error(), "404") || strings.Contains(err.Error(), "NoSuchBucketPolicy") {
 				m.successMessage = fmt.Sprintf("Bucket '%s' is already PRIVATE (no policy).", m.pendingTarget)
@@ -434,10 +798,13 @@ func (m Model) executeConfirmedAction() (tea.Model, tea.Cmd) {
 				m.errorMessage = fmt.Sprintf("Failed to check policy: %v", err)
 			}
 		} else {
-			if err := m.versitygwService.DeleteBucketPolicy(m.pendingTarget); err != nil {
+			audit := m.audit("bucket.make_private", m.pendingTarget, nil)
+			err := services.MakeBucketPrivate(context.Background(), m.cfg, m.pendingTarget)
+			audit.Finish(err)
+			if err != nil {
 				m.errorMessage = fmt.Sprintf("Failed to make private: %v", err)
 			} else {
-				m.successMessage = fmt.Sprintf("Bucket '%s' is now PRIVATE (policy removed).", m.pendingTarget)
+				m.successMessage = fmt.Sprintf("Bucket '%s' is now PRIVATE (public policy removed).", m.pendingTarget)
 			}
 		}
 	}
@@ -465,76 +832,14 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			}
 
 		case 1: // List Buckets
-			// 1. Get ZFS buckets (source of truth for quotas/usage)
-			buckets, err := m.bucketService.ListBuckets()
+			// Merged ZFS (quotas/usage) + API (owners, policies, tags) listing,
+			// shared with --list-buckets and the API.
+			buckets, err := services.ListMergedBuckets(context.Background(), m.cfg)
 			if err != nil {
-				m.errorMessage = fmt.Sprintf("Error loading buckets from ZFS: %v", err)
-				// Even if ZFS fails, we want to try showing API buckets
+				m.errorMessage = fmt.Sprintf("Error loading buckets: %v", err)
 				buckets = []models.Bucket{}
 			}
 
-			// 2. Try to get API buckets (source of truth for owners and missing ZFS buckets)
-			apiBuckets, apiErr := m.versitygwService.ListBuckets()
-			if apiErr == nil {
-				// Create map for fast lookup of ZFS buckets
-				zfsMap := make(map[string]*models.Bucket)
-				for i := range buckets {
-					zfsMap[buckets[i].Name] = &buckets[i]
-				}
-
-				// Create map for API info
-				apiMap := make(map[string]services.BucketInfo)
-				for _, b := range apiBuckets {
-					apiMap[b.Name] = b
-				}
-
-				// Update existing ZFS buckets with owner info
-				for i := range buckets {
-					if info, ok := apiMap[buckets[i].Name]; ok {
-						if info.Owner != "" {
-							buckets[i].Owner = info.Owner
-						}
-					}
-
-					// Fetch TRUE owner from ACL separately if needed (preserving existing logic)
-					trueOwner, err := m.versitygwService.GetBucketOwner(buckets[i].Name)
-					if err == nil && trueOwner != "" {
-						buckets[i].Owner = trueOwner
-					}
-				}
-
-				// Add buckets that exist in API but NOT in ZFS
-				for _, apiBucket := range apiBuckets {
-					if _, exists := zfsMap[apiBucket.Name]; !exists {
-						// Create placeholder bucket
-						newBucket := models.Bucket{
-							Name:       apiBucket.Name,
-							Mountpoint: "-",
-							Quota:      "-",
-							Used:       "-",
-							Available:  "-",
-							Owner:      apiBucket.Owner,
-						}
-
-						// Try to fetch true owner for these as well
-						trueOwner, err := m.versitygwService.GetBucketOwner(apiBucket.Name)
-						if err == nil && trueOwner != "" {
-							newBucket.Owner = trueOwner
-						}
-
-						buckets = append(buckets, newBucket)
-					}
-				}
-			} else if err != nil {
-				// If both failed, show error
-				m.errorMessage = fmt.Sprintf("Error loading buckets: ZFS(%v) API(%v)", err, apiErr)
-			}
-
-			// Sort merged list
-			sort.Slice(buckets, func(i, j int) bool {
-				return buckets[i].Name < buckets[j].Name
-			})
-
 			m.buckets = buckets
 			m.currentView = BucketsListView
 			m.cursor = 0
@@ -544,7 +849,10 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			m.currentView = OperationsView
 			m.cursor = 0
 
-		case 3: // Quit
+		case 3: // Groups
+			return m.openGroups()
+
+		case 4: // Quit
 			return m, tea.Quit
 		}
 	case OperationsView:
@@ -582,6 +890,7 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 		if len(m.users) > 0 && idx >= 0 && idx < len(m.users) {
 			m.selectedUserIndex = idx
 			m.currentView = UserDetailView
+			m.loadUserServiceAccounts()
 		}
 
 	case BucketsListView:
@@ -590,6 +899,7 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 		if len(m.buckets) > 0 && idx >= 0 && idx < len(m.buckets) {
 			m.selectedBucketIndex = idx
 			m.currentView = BucketDetailView
+			m.loadBucketUploads()
 		}
 
 	case CreateUserView, UpdateUserView:
@@ -600,6 +910,24 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 
 	case MakeBucketPublicView:
 		return m.handleMakePublic()
+
+	case BucketTagsView:
+		return m.handleSetTags()
+
+	case LifecycleView:
+		return m.editSelectedLifecycleRule()
+
+	case ObjectBrowserView:
+		return m.openSelectedObjectEntry()
+
+	case ClientConfigView:
+		return m.copySelectedClientConfig()
+
+	case GroupsListView:
+		return m.openSelectedGroup()
+
+	case SitesView:
+		return m.switchToSelectedSite()
 	}
 
 	return m, nil
@@ -634,6 +962,42 @@ func (m Model) View() string {
 		return m.renderMakePublicForm()
 	case ConfirmView:
 		return m.renderConfirmView()
+	case BucketTagsView:
+		return m.renderTagForm()
+	case LifecycleView:
+		return m.renderLifecycleView()
+	case LifecycleRuleFormView:
+		return m.renderLifecycleForm()
+	case CorsView:
+		return m.renderCorsView()
+	case CorsFormView:
+		return m.renderCorsForm()
+	case ObjectBrowserView:
+		return m.renderObjectBrowser()
+	case ObjectDetailView:
+		return m.renderObjectDetail()
+	case ObjectDownloadView:
+		return m.renderObjectDownloadForm()
+	case BucketReportView:
+		return m.renderBucketReport()
+	case ForceDeleteView:
+		return m.renderForceDelete()
+	case ClientConfigView:
+		return m.renderClientConfigPicker()
+	case ServiceAccountsView:
+		return m.renderServiceAccounts()
+	case ServiceAccountFormView:
+		return m.renderServiceAccountForm()
+	case GroupsListView:
+		return m.renderGroupsList()
+	case GroupDetailView:
+		return m.renderGroupDetail()
+	case GroupFormView:
+		return m.renderGroupForm()
+	case UserAccessView:
+		return m.renderUserAccess()
+	case SitesView:
+		return m.renderSitePicker()
 	default:
 		return "Unknown view"
 	}
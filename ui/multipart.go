@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// staleUploadAge is the age after which the bucket detail view treats an
+// incomplete multipart upload as abandoned
+const (
+	staleUploadAge      = 24 * time.Hour
+	staleUploadAgeLabel = "24h"
+)
+
+// loadBucketUploads fetches the incomplete multipart uploads of the selected bucket
+func (m *Model) loadBucketUploads() {
+	m.bucketUploads = nil
+	m.bucketUploadsErr = ""
+	if m.selectedBucketIndex < 0 || m.selectedBucketIndex >= len(m.buckets) {
+		return
+	}
+
+	uploads, err := m.versitygwService.ListMultipartUploads(context.Background(), m.buckets[m.selectedBucketIndex].Name)
+	if err != nil {
+		m.bucketUploadsErr = err.Error()
+		return
+	}
+	m.bucketUploads = uploads
+}
+
+// staleBucketUploads returns the selected bucket's uploads older than staleUploadAge
+func (m Model) staleBucketUploads() []services.MultipartUpload {
+	return services.StaleUploads(m.bucketUploads, time.Now().Add(-staleUploadAge))
+}
+
+// bucketUploadsSummary renders the multipart upload count for the bucket detail view
+func (m Model) bucketUploadsSummary() string {
+	if m.bucketUploadsErr != "" {
+		return "unknown (" + truncate(m.bucketUploadsErr, 60) + ")"
+	}
+	if len(m.bucketUploads) == 0 {
+		return "0"
+	}
+	return fmt.Sprintf("%d (%d older than %s)", len(m.bucketUploads), len(m.staleBucketUploads()), staleUploadAgeLabel)
+}
+
+// confirmAbortStaleUploads asks for confirmation before aborting the bucket's stale uploads
+func (m Model) confirmAbortStaleUploads() (tea.Model, tea.Cmd) {
+	if m.selectedBucketIndex < 0 || m.selectedBucketIndex >= len(m.buckets) {
+		return m, nil
+	}
+	if len(m.staleBucketUploads()) == 0 {
+		m.successMessage = fmt.Sprintf("No multipart uploads older than %s", staleUploadAgeLabel)
+		return m, nil
+	}
+
+	m.pendingAction = "abort_multipart"
+	m.pendingTarget = m.buckets[m.selectedBucketIndex].Name
+	m.returnView = BucketDetailView
+	m.currentView = ConfirmView
+	return m, nil
+}
@@ -0,0 +1,488 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/config"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// objectListBatch is the number of keys requested per ListObjectsV2 call
+const objectListBatch = 1000
+
+// objectEntry is a row in the object browser: either a prefix ("folder") or an object
+type objectEntry struct {
+	isPrefix bool
+	prefix   string
+	object   services.ObjectInfo
+}
+
+// openObjectBrowser starts browsing the selected bucket at its root
+func (m Model) openObjectBrowser() (tea.Model, tea.Cmd) {
+	if m.selectedBucketIndex < 0 || m.selectedBucketIndex >= len(m.buckets) {
+		m.errorMessage = "Invalid bucket selection"
+		return m, nil
+	}
+
+	m.objectBucket = m.buckets[m.selectedBucketIndex].Name
+	m.currentView = ObjectBrowserView
+	m.loadObjects("")
+	return m, nil
+}
+
+// loadObjects lists the first batch of entries directly under prefix
+func (m *Model) loadObjects(prefix string) {
+	listing, err := m.versitygwService.ListObjects(context.Background(), m.objectBucket, prefix, "/", "", objectListBatch)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to list objects: %v", err)
+		return
+	}
+
+	m.objectPrefix = prefix
+	m.objectEntries = nil
+	m.appendObjectListing(listing)
+	m.page = 0
+	m.cursor = 0
+}
+
+// loadMoreObjects fetches the next batch of a truncated listing
+func (m *Model) loadMoreObjects() {
+	if m.objectNextToken == "" {
+		m.successMessage = "All objects loaded"
+		return
+	}
+
+	listing, err := m.versitygwService.ListObjects(context.Background(), m.objectBucket, m.objectPrefix, "/", m.objectNextToken, objectListBatch)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to list objects: %v", err)
+		return
+	}
+	m.appendObjectListing(listing)
+}
+
+// appendObjectListing adds a listing page to the entries, prefixes first
+func (m *Model) appendObjectListing(listing services.ObjectListing) {
+	for _, prefix := range listing.CommonPrefixes {
+		m.objectEntries = append(m.objectEntries, objectEntry{isPrefix: true, prefix: prefix})
+	}
+	for _, obj := range listing.Objects {
+		// Some gateways return the "folder" marker object itself under its own prefix
+		if obj.Key == m.objectPrefix {
+			continue
+		}
+		m.objectEntries = append(m.objectEntries, objectEntry{object: obj})
+	}
+
+	m.objectNextToken = ""
+	if listing.IsTruncated {
+		m.objectNextToken = listing.NextContinuationToken
+	}
+}
+
+// selectedObjectEntry returns the entry under the cursor
+func (m Model) selectedObjectEntry() (objectEntry, bool) {
+	idx := m.page*m.pageSize + m.cursor
+	if idx < 0 || idx >= len(m.objectEntries) {
+		return objectEntry{}, false
+	}
+	return m.objectEntries[idx], true
+}
+
+// openSelectedObjectEntry descends into a prefix or shows the details of an object
+func (m Model) openSelectedObjectEntry() (tea.Model, tea.Cmd) {
+	entry, ok := m.selectedObjectEntry()
+	if !ok {
+		return m, nil
+	}
+	if entry.isPrefix {
+		m.loadObjects(entry.prefix)
+		return m, nil
+	}
+
+	info, err := m.versitygwService.HeadObject(context.Background(), m.objectBucket, entry.object.Key)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to inspect object: %v", err)
+		return m, nil
+	}
+	if info.StorageClass == "" {
+		info.StorageClass = entry.object.StorageClass
+	}
+	m.selectedObject = info
+	m.currentView = ObjectDetailView
+	return m, nil
+}
+
+// objectUnderFocus returns the object the download/delete keys act on
+func (m Model) objectUnderFocus() (services.ObjectInfo, bool) {
+	if m.currentView == ObjectDetailView {
+		return m.selectedObject, true
+	}
+	entry, ok := m.selectedObjectEntry()
+	if !ok || entry.isPrefix {
+		return services.ObjectInfo{}, false
+	}
+	return entry.object, true
+}
+
+// confirmDeleteObject asks for confirmation before deleting the focused object
+func (m Model) confirmDeleteObject() (tea.Model, tea.Cmd) {
+	obj, ok := m.objectUnderFocus()
+	if !ok {
+		return m, nil
+	}
+	m.pendingAction = "delete_object"
+	m.pendingTarget = obj.Key
+	m.returnView = ObjectBrowserView
+	m.currentView = ConfirmView
+	return m, nil
+}
+
+// openObjectDownloadForm asks for the local path to save the focused object to
+func (m Model) openObjectDownloadForm() (tea.Model, tea.Cmd) {
+	obj, ok := m.objectUnderFocus()
+	if !ok {
+		return m, nil
+	}
+	m.returnView = m.currentView
+	m.selectedObject = obj
+	m.initObjectDownloadForm(obj.Key)
+	m.currentView = ObjectDownloadView
+	return m, nil
+}
+
+// copyPresignedURL copies a presigned GET URL for the focused object to the clipboard
+func (m Model) copyPresignedURL(signAs string) (tea.Model, tea.Cmd) {
+	obj, ok := m.objectUnderFocus()
+	if !ok {
+		return m, nil
+	}
+
+	presigned, err := services.PresignObjectURL(context.Background(), m.cfg, services.PresignRequest{
+		Bucket: m.objectBucket,
+		Key:    obj.Key,
+		Method: http.MethodGet,
+		Expiry: services.DefaultPresignExpiry,
+		SignAs: signAs,
+	})
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Presign failed: %v", err)
+		return m, nil
+	}
+	if err := clipboard.WriteAll(presigned.URL); err != nil {
+		m.errorMessage = fmt.Sprintf("Copy failed: failed to copy to clipboard: %v", err)
+		return m, nil
+	}
+
+	m.successMessage = fmt.Sprintf("Download link for '%s' copied (signed by %s, expires %s)",
+		obj.Key, presigned.SignedBy, presigned.ExpiresAt.Local().Format("15:04"))
+	return m, nil
+}
+
+// renderObjectBrowser renders the entries under the current prefix
+func (m Model) renderObjectBrowser() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Objects: "+m.objectBucket+"/"+m.objectPrefix) + "\n\n")
+
+	start := m.page * m.pageSize
+	end := start + m.pageSize
+	if end > len(m.objectEntries) {
+		end = len(m.objectEntries)
+	}
+
+	header := fmt.Sprintf("  %-50s %12s %-20s %-34s", "Name", "Size", "Last Modified", "ETag")
+	s.WriteString(dimStyle.Render(header) + "\n")
+	s.WriteString(dimStyle.Render(strings.Repeat("-", 121)) + "\n")
+
+	if len(m.objectEntries) == 0 {
+		s.WriteString(dimStyle.Render("  No objects under this prefix.") + "\n")
+	}
+
+	for i := start; i < end; i++ {
+		entry := m.objectEntries[i]
+		cursor := " "
+		if m.cursor == (i - start) {
+			cursor = ">"
+		}
+
+		var line string
+		if entry.isPrefix {
+			line = fmt.Sprintf("%s %-50s %12s %-20s %-34s", cursor, truncate(services.ObjectBaseName(entry.prefix), 50), "-", "-", "")
+		} else {
+			line = fmt.Sprintf("%s %-50s %12s %-20s %-34s",
+				cursor,
+				truncate(services.ObjectBaseName(entry.object.Key), 50),
+				services.FormatBytes(entry.object.Size),
+				entry.object.LastModified.Local().Format("2006-01-02 15:04:05"),
+				truncate(entry.object.ETag, 34),
+			)
+		}
+
+		if m.cursor == (i - start) {
+			s.WriteString(selectedTableRowStyle.Render(line) + "\n")
+		} else {
+			s.WriteString(line + "\n")
+		}
+	}
+
+	totalPages := (len(m.objectEntries) + m.pageSize - 1) / m.pageSize
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	pageInfo := fmt.Sprintf("Page %d/%d • %d entries", m.page+1, totalPages, len(m.objectEntries))
+	if m.objectNextToken != "" {
+		pageInfo += " (more available, press m)"
+	}
+	s.WriteString("\n" + helpStyle.Render(pageInfo))
+
+	help := helpStyle.Render("↑/↓: Navigate • ←/→: Page • enter: Open • backspace: Up • m: Load More • s: Download • g/G: Copy Link (admin/owner) • d: Delete • esc: Back")
+	s.WriteString("\n" + help)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render(m.errorMessage))
+	} else if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
+
+// renderObjectDetail renders the metadata of the selected object
+func (m Model) renderObjectDetail() string {
+	var s strings.Builder
+
+	obj := m.selectedObject
+	s.WriteString(titleStyle.Render("Object Details") + "\n\n")
+
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = "-"
+	}
+	storageClass := obj.StorageClass
+	if storageClass == "" {
+		storageClass = "STANDARD"
+	}
+
+	fields := []struct{ label, value string }{
+		{"Bucket", m.objectBucket},
+		{"Key", obj.Key},
+		{"Size", fmt.Sprintf("%s (%d bytes)", services.FormatBytes(obj.Size), obj.Size)},
+		{"Last Modified", obj.LastModified.Local().Format("2006-01-02 15:04:05")},
+		{"ETag", obj.ETag},
+		{"Content Type", contentType},
+		{"Storage Class", storageClass},
+	}
+	for _, f := range fields {
+		s.WriteString(fmt.Sprintf("%s %s\n", tableHeaderStyle.Render(fmt.Sprintf("%-14s", f.label+":")), tableCellStyle.Render(f.value)))
+	}
+
+	help := helpStyle.Render("s: Download • g: Copy Link • G: Copy Link (signed as owner) • d: Delete • esc: Back to objects")
+	s.WriteString("\n" + help)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render(m.errorMessage))
+	} else if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
+
+// initObjectDownloadForm initializes the download form with a path in the working directory
+func (m *Model) initObjectDownloadForm(key string) {
+	inputs := make([]textinput.Model, 1)
+
+	// Local path
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "Local file path"
+	inputs[0].SetValue(filepath.Join(".", services.ObjectBaseName(key)))
+	inputs[0].Focus()
+	inputs[0].CharLimit = 4096
+	inputs[0].Width = 60
+
+	m.objectFormInputs = inputs
+	m.focusIndex = 0
+}
+
+// renderObjectDownloadForm renders the download form
+func (m Model) renderObjectDownloadForm() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Download Object: "+m.selectedObject.Key) + "\n\n")
+
+	labels := []string{"Save To:"}
+
+	for i, input := range m.objectFormInputs {
+		label := inputLabelStyle.Render(labels[i])
+		s.WriteString(label + "\n")
+
+		if i == m.focusIndex {
+			s.WriteString(focusedInputStyle.Render(input.View()) + "\n\n")
+		} else {
+			s.WriteString(inputStyle.Render(input.View()) + "\n\n")
+		}
+	}
+
+	if m.downloadRunning {
+		s.WriteString(helpStyle.Render(fmt.Sprintf("Downloading %s… (esc: Cancel)", services.FormatBytes(m.selectedObject.Size))))
+		return s.String()
+	}
+
+	downloadBtn := "[ Download ]"
+	cancelBtn := "[ Cancel ]"
+
+	if m.focusIndex == len(m.objectFormInputs) {
+		s.WriteString(focusedButtonStyle.Render(downloadBtn) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	} else if m.focusIndex == len(m.objectFormInputs)+1 {
+		s.WriteString(buttonStyle.Render(downloadBtn) + "  ")
+		s.WriteString(focusedButtonStyle.Render(cancelBtn) + "\n")
+	} else {
+		s.WriteString(buttonStyle.Render(downloadBtn) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	}
+
+	help := helpStyle.Render("tab: Next field • shift+tab: Previous • enter: Submit/Select • esc: Cancel")
+	s.WriteString("\n" + help)
+
+	info := dimStyle.Render(fmt.Sprintf("Size: %s. Existing files are not overwritten.", services.FormatBytes(m.selectedObject.Size)))
+	s.WriteString("\n" + info)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render("Error: "+m.errorMessage))
+	}
+	if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
+
+// updateObjectDownloadForm handles key events for the download form
+func (m Model) updateObjectDownloadForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.downloadRunning {
+		switch msg.String() {
+		case "ctrl+c":
+			m.downloadCancel()
+			return m, tea.Quit
+		case "esc":
+			// The partial file is removed once the download has stopped.
+			m.downloadCancel()
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		m.currentView = m.returnView
+		return m, nil
+
+	case "tab", "down":
+		m.focusIndex++
+		if m.focusIndex > len(m.objectFormInputs)+1 {
+			m.focusIndex = 0
+		}
+		m.updateObjectFormFocus()
+		return m, nil
+
+	case "shift+tab", "up":
+		m.focusIndex--
+		if m.focusIndex < 0 {
+			m.focusIndex = len(m.objectFormInputs) + 1
+		}
+		m.updateObjectFormFocus()
+		return m, nil
+
+	case "enter":
+		if m.focusIndex == len(m.objectFormInputs)+1 {
+			m.currentView = m.returnView
+			return m, nil
+		}
+		return m.handleObjectDownload()
+	}
+
+	if m.focusIndex < len(m.objectFormInputs) {
+		m.objectFormInputs[m.focusIndex], cmd = m.objectFormInputs[m.focusIndex].Update(msg)
+	}
+
+	return m, cmd
+}
+
+// updateObjectFormFocus updates focus state for download form inputs
+func (m *Model) updateObjectFormFocus() {
+	for i := range m.objectFormInputs {
+		if i == m.focusIndex {
+			m.objectFormInputs[i].Focus()
+		} else {
+			m.objectFormInputs[i].Blur()
+		}
+	}
+}
+
+// objectDownloadDoneMsg is sent when a download finishes
+type objectDownloadDoneMsg struct {
+	key       string
+	localPath string
+	n         int64
+	err       error
+}
+
+// handleObjectDownload starts saving the selected object to the path in the download form
+func (m Model) handleObjectDownload() (tea.Model, tea.Cmd) {
+	localPath := strings.TrimSpace(m.objectFormInputs[0].Value())
+	if localPath == "" {
+		m.errorMessage = "Local path is required"
+		return m, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.errorMessage = ""
+	m.downloadRunning = true
+	m.downloadCancel = cancel
+	return m, startObjectDownload(ctx, m.cfg, m.objectBucket, m.selectedObject.Key, localPath)
+}
+
+// startObjectDownload runs a download in the background until it finishes or ctx is cancelled
+func startObjectDownload(ctx context.Context, cfg *config.Config, bucket, key, localPath string) tea.Cmd {
+	return func() tea.Msg {
+		n, err := services.DownloadObjectToFile(ctx, cfg, bucket, key, localPath)
+		return objectDownloadDoneMsg{key: key, localPath: localPath, n: n, err: err}
+	}
+}
+
+// handleObjectDownloadDone reports the outcome of a download
+func (m Model) handleObjectDownloadDone(msg objectDownloadDoneMsg) (tea.Model, tea.Cmd) {
+	if m.downloadCancel != nil {
+		m.downloadCancel()
+	}
+	m.downloadRunning = false
+	m.downloadCancel = nil
+
+	if errors.Is(msg.err, context.Canceled) {
+		m.errorMessage = "Download cancelled"
+		return m, nil
+	}
+	if msg.err != nil {
+		m.errorMessage = fmt.Sprintf("Download failed: %v", msg.err)
+		return m, nil
+	}
+
+	m.errorMessage = ""
+	m.currentView = m.returnView
+	m.successMessage = fmt.Sprintf("Downloaded '%s' to %s (%s)", msg.key, msg.localPath, services.FormatBytes(msg.n))
+
+	return m, nil
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// openBucketReport walks the selected bucket and shows its usage report
+func (m Model) openBucketReport() (tea.Model, tea.Cmd) {
+	if m.selectedBucketIndex < 0 || m.selectedBucketIndex >= len(m.buckets) {
+		m.errorMessage = "Invalid bucket selection"
+		return m, nil
+	}
+
+	bucket := m.buckets[m.selectedBucketIndex].Name
+	report, err := services.GenerateBucketReport(context.Background(), m.cfg, bucket, services.ReportOptions{})
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to generate report: %v", err)
+		return m, nil
+	}
+
+	m.bucketReport = report
+	m.currentView = BucketReportView
+	return m, nil
+}
+
+// renderBucketReport renders the usage report of a bucket
+func (m Model) renderBucketReport() string {
+	var s strings.Builder
+
+	report := m.bucketReport
+	s.WriteString(titleStyle.Render("Usage Report: "+report.Bucket) + "\n\n")
+
+	s.WriteString(tableHeaderStyle.Render("Objects") + "\n")
+	s.WriteString(tableCellStyle.Render(fmt.Sprintf("%d", report.Objects)) + "\n\n")
+	s.WriteString(tableHeaderStyle.Render("Total Size") + "\n")
+	s.WriteString(tableCellStyle.Render(fmt.Sprintf("%s (%d bytes)", services.FormatBytes(report.Bytes), report.Bytes)) + "\n\n")
+	if report.OldestObject != nil {
+		s.WriteString(tableHeaderStyle.Render("Oldest / Newest Object") + "\n")
+		s.WriteString(tableCellStyle.Render(fmt.Sprintf("%s / %s",
+			report.OldestObject.Local().Format("2006-01-02 15:04"),
+			report.NewestObject.Local().Format("2006-01-02 15:04"))) + "\n\n")
+	}
+
+	s.WriteString(tableHeaderStyle.Render(fmt.Sprintf("Largest Prefixes (%d of %d)", len(report.LargestPrefixes), report.TotalPrefixes)) + "\n")
+	header := fmt.Sprintf("  %-40s %10s %12s %7s", "Prefix", "Objects", "Size", "Share")
+	s.WriteString(dimStyle.Render(header) + "\n")
+	for _, p := range report.LargestPrefixes {
+		s.WriteString(fmt.Sprintf("  %-40s %10d %12s %6.1f%%\n", truncate(p.Prefix, 40), p.Objects, services.FormatBytes(p.Bytes), report.Share(p.Bytes)))
+	}
+	if len(report.LargestPrefixes) == 0 {
+		s.WriteString(dimStyle.Render("  Bucket is empty.") + "\n")
+	}
+
+	s.WriteString("\n" + tableHeaderStyle.Render("Age (by last modified)") + "\n")
+	header = fmt.Sprintf("  %-15s %10s %12s %7s  %s", "Age", "Objects", "Size", "Share", "")
+	s.WriteString(dimStyle.Render(header) + "\n")
+	for _, h := range report.AgeHistogram {
+		share := report.Share(h.Bytes)
+		bar := strings.Repeat("█", int(share/5))
+		s.WriteString(fmt.Sprintf("  %-15s %10d %12s %6.1f%%  %s\n", h.Label, h.Objects, services.FormatBytes(h.Bytes), share, bar))
+	}
+
+	help := helpStyle.Render("esc: Back to bucket details")
+	s.WriteString("\n" + help)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render(m.errorMessage))
+	}
+
+	return s.String()
+}
@@ -0,0 +1,327 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// loadUserServiceAccounts loads the service account relationships of the
+// selected user for the user detail view.
+func (m *Model) loadUserServiceAccounts() {
+	m.serviceAccounts = nil
+	m.serviceAccountsErr = ""
+	m.userParent = ""
+	if m.selectedUserIndex < 0 || m.selectedUserIndex >= len(m.users) {
+		return
+	}
+	user := m.users[m.selectedUserIndex]
+	m.serviceAccountParent = user.Access
+
+	if account, err := services.GetServiceAccount(m.cfg, user.Access); err == nil {
+		m.userParent = account.Parent
+		return
+	}
+	accounts, err := services.ListServiceAccounts(m.cfg, user.Access)
+	if err != nil {
+		m.serviceAccountsErr = err.Error()
+		return
+	}
+	m.serviceAccounts = accounts
+}
+
+// serviceAccountsSummary describes the selected user's parent or children.
+func (m Model) serviceAccountsSummary() string {
+	switch {
+	case m.userParent != "":
+		return "Service account of " + m.userParent
+	case m.serviceAccountsErr != "":
+		return "Unavailable: " + m.serviceAccountsErr
+	case len(m.serviceAccounts) == 0:
+		return "None (press A to add)"
+	}
+	names := make([]string, len(m.serviceAccounts))
+	for i, account := range m.serviceAccounts {
+		names[i] = account.Access
+	}
+	return fmt.Sprintf("%d: %s", len(names), truncate(strings.Join(names, ", "), 60))
+}
+
+// openServiceAccounts shows the service accounts of the selected user.
+func (m Model) openServiceAccounts() (tea.Model, tea.Cmd) {
+	if m.userParent != "" {
+		m.errorMessage = fmt.Sprintf("'%s' is a service account of '%s'; service accounts cannot be nested", m.serviceAccountParent, m.userParent)
+		return m, nil
+	}
+	if m.serviceAccountsErr != "" {
+		m.errorMessage = fmt.Sprintf("Failed to load service accounts: %s", m.serviceAccountsErr)
+		return m, nil
+	}
+
+	m.currentView = ServiceAccountsView
+	m.cursor = 0
+	m.page = 0
+	return m, nil
+}
+
+// reloadServiceAccounts refreshes the service account list and the users list
+// (service accounts are users too) after a change.
+func (m *Model) reloadServiceAccounts() {
+	if users, err := m.userService.ListUsers(); err == nil {
+		m.users = users
+		for i, user := range users {
+			if user.Access == m.serviceAccountParent {
+				m.selectedUserIndex = i
+			}
+		}
+	}
+
+	accounts, err := services.ListServiceAccounts(m.cfg, m.serviceAccountParent)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to load service accounts: %v", err)
+		return
+	}
+	m.serviceAccounts = accounts
+	if m.page*m.pageSize+m.cursor >= len(accounts) {
+		m.page, m.cursor = 0, 0
+	}
+}
+
+// confirmServiceAccountAction asks to revoke or rotate the service account under the cursor.
+func (m Model) confirmServiceAccountAction(action string) (tea.Model, tea.Cmd) {
+	idx := m.page*m.pageSize + m.cursor
+	if idx < 0 || idx >= len(m.serviceAccounts) {
+		return m, nil
+	}
+	m.pendingAction = action
+	m.pendingTarget = m.serviceAccounts[idx].Access
+	m.returnView = ServiceAccountsView
+	m.currentView = ConfirmView
+	return m, nil
+}
+
+// rotateServiceAccount generates a new secret and copies the credentials to the clipboard.
+func (m *Model) rotateServiceAccount(access string) {
+	audit := m.audit("service_account.rotate", access, nil)
+	rotated, err := services.RotateServiceAccount(context.Background(), m.cfg, access)
+	audit.Finish(err)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to rotate service account: %v", err)
+		return
+	}
+	m.successMessage = credentialsMessage("Secret of '"+access+"' rotated", rotated.Access, rotated.Secret)
+}
+
+// credentialsMessage copies new credentials to the clipboard, falling back to
+// showing the secret when the clipboard is unavailable.
+func credentialsMessage(prefix, access, secret string) string {
+	credentials := fmt.Sprintf("Access Key: %s\nSecret Key: %s", access, secret)
+	if err := clipboard.WriteAll(credentials); err != nil {
+		return fmt.Sprintf("✓ %s. %s", prefix, strings.ReplaceAll(credentials, "\n", "  "))
+	}
+	return fmt.Sprintf("✓ %s. Credentials copied to clipboard!", prefix)
+}
+
+func (m Model) renderServiceAccounts() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Service Accounts: "+m.serviceAccountParent) + "\n\n")
+
+	start := m.page * m.pageSize
+	end := start + m.pageSize
+	if end > len(m.serviceAccounts) {
+		end = len(m.serviceAccounts)
+	}
+
+	header := fmt.Sprintf("  %-35s %-17s %-40s", "Access Key", "Created", "Description")
+	s.WriteString(dimStyle.Render(header) + "\n")
+	s.WriteString(dimStyle.Render(strings.Repeat("-", 94)) + "\n")
+
+	if len(m.serviceAccounts) == 0 {
+		s.WriteString(dimStyle.Render("  No service accounts. Press 'a' to add one.") + "\n")
+	}
+
+	for i := start; i < end; i++ {
+		account := m.serviceAccounts[i]
+		cursor := " "
+		if m.cursor == (i - start) {
+			cursor = ">"
+		}
+
+		line := fmt.Sprintf("%s %-35s %-17s %-40s",
+			cursor,
+			truncate(account.Access, 35),
+			account.CreatedAt.Local().Format("2006-01-02 15:04"),
+			truncate(account.Description, 40),
+		)
+
+		if m.cursor == (i - start) {
+			s.WriteString(selectedTableRowStyle.Render(line) + "\n")
+		} else {
+			s.WriteString(line + "\n")
+		}
+	}
+
+	s.WriteString("\n" + dimStyle.Render("Service accounts can read and write every bucket owned by "+m.serviceAccountParent+"."))
+
+	help := helpStyle.Render("↑/↓: Navigate • a: Add • R: Rotate Secret • d: Revoke • esc: Back")
+	s.WriteString("\n" + help)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render(m.errorMessage))
+	} else if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
+
+// initServiceAccountForm initializes the new service account form
+func (m *Model) initServiceAccountForm() {
+	inputs := make([]textinput.Model, 2)
+
+	// Access Key
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = m.serviceAccountParent + "-xxxxxxxx (generated if empty)"
+	inputs[0].Focus()
+	inputs[0].CharLimit = 128
+	inputs[0].Width = 50
+
+	// Description
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = "CI pipeline, nightly backup, ..."
+	inputs[1].CharLimit = 256
+	inputs[1].Width = 50
+
+	m.serviceAccountInputs = inputs
+	m.focusIndex = 0
+}
+
+// renderServiceAccountForm renders the new service account form
+func (m Model) renderServiceAccountForm() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("New Service Account for "+m.serviceAccountParent) + "\n\n")
+
+	labels := []string{"Access Key:", "Description:"}
+
+	for i, input := range m.serviceAccountInputs {
+		label := inputLabelStyle.Render(labels[i])
+		s.WriteString(label + "\n")
+
+		if i == m.focusIndex {
+			s.WriteString(focusedInputStyle.Render(input.View()) + "\n\n")
+		} else {
+			s.WriteString(inputStyle.Render(input.View()) + "\n\n")
+		}
+	}
+
+	createBtn := "[ Create ]"
+	cancelBtn := "[ Cancel ]"
+
+	if m.focusIndex == len(m.serviceAccountInputs) {
+		s.WriteString(focusedButtonStyle.Render(createBtn) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	} else if m.focusIndex == len(m.serviceAccountInputs)+1 {
+		s.WriteString(buttonStyle.Render(createBtn) + "  ")
+		s.WriteString(focusedButtonStyle.Render(cancelBtn) + "\n")
+	} else {
+		s.WriteString(buttonStyle.Render(createBtn) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	}
+
+	help := helpStyle.Render("tab: Next field • shift+tab: Previous • enter: Submit/Select • esc: Cancel")
+	s.WriteString("\n" + help)
+
+	info := dimStyle.Render("The secret is generated and copied to the clipboard.")
+	s.WriteString("\n" + info)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render("Error: "+m.errorMessage))
+	}
+
+	return s.String()
+}
+
+// updateServiceAccountForm handles key events for the new service account form
+func (m Model) updateServiceAccountForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		m.currentView = ServiceAccountsView
+		return m, nil
+
+	case "tab", "down":
+		m.focusIndex++
+		if m.focusIndex > len(m.serviceAccountInputs)+1 {
+			m.focusIndex = 0
+		}
+		m.updateServiceAccountFormFocus()
+		return m, nil
+
+	case "shift+tab", "up":
+		m.focusIndex--
+		if m.focusIndex < 0 {
+			m.focusIndex = len(m.serviceAccountInputs) + 1
+		}
+		m.updateServiceAccountFormFocus()
+		return m, nil
+
+	case "enter":
+		if m.focusIndex == len(m.serviceAccountInputs)+1 {
+			m.currentView = ServiceAccountsView
+			return m, nil
+		}
+		return m.handleCreateServiceAccount()
+	}
+
+	if m.focusIndex < len(m.serviceAccountInputs) {
+		m.serviceAccountInputs[m.focusIndex], cmd = m.serviceAccountInputs[m.focusIndex].Update(msg)
+	}
+
+	return m, cmd
+}
+
+// updateServiceAccountFormFocus updates focus state for service account form inputs
+func (m *Model) updateServiceAccountFormFocus() {
+	for i := range m.serviceAccountInputs {
+		if i == m.focusIndex {
+			m.serviceAccountInputs[i].Focus()
+		} else {
+			m.serviceAccountInputs[i].Blur()
+		}
+	}
+}
+
+// handleCreateServiceAccount creates the service account from the form
+func (m Model) handleCreateServiceAccount() (tea.Model, tea.Cmd) {
+	req := services.ServiceAccountRequest{
+		Parent:      m.serviceAccountParent,
+		Access:      strings.TrimSpace(m.serviceAccountInputs[0].Value()),
+		Description: strings.TrimSpace(m.serviceAccountInputs[1].Value()),
+	}
+	audit := m.audit("service_account.create", req.Parent, map[string]any{"access": req.Access, "description": req.Description})
+	created, err := services.CreateServiceAccount(context.Background(), m.cfg, req)
+	audit.Finish(err)
+	if err != nil {
+		m.errorMessage = err.Error()
+		return m, nil
+	}
+
+	m.currentView = ServiceAccountsView
+	m.reloadServiceAccounts()
+	m.successMessage = credentialsMessage(
+		fmt.Sprintf("Service account '%s' created with access to %d bucket(s)", created.Access, len(created.Buckets)),
+		created.Access, created.Secret)
+
+	return m, nil
+}
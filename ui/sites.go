@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// openSitePicker lists the configured sites with the current one selected.
+func (m Model) openSitePicker() (tea.Model, tea.Cmd) {
+	m.currentView = SitesView
+	m.page = 0
+	m.cursor = 0
+	for i, name := range m.root.SiteNames() {
+		if name == m.cfg.Site {
+			m.cursor = i
+		}
+	}
+	return m, nil
+}
+
+// switchToSelectedSite points the services at the site under the cursor and
+// drops everything loaded from the previous site.
+func (m Model) switchToSelectedSite() (tea.Model, tea.Cmd) {
+	names := m.root.SiteNames()
+	if m.cursor < 0 || m.cursor >= len(names) {
+		return m, nil
+	}
+	cfg, err := m.root.ForSite(names[m.cursor])
+	if err != nil {
+		m.errorMessage = err.Error()
+		return m, nil
+	}
+
+	m.cfg = cfg
+	m.userService = services.NewUserService(cfg)
+	m.bucketService = services.NewBucketService(cfg)
+	m.versitygwService = services.NewVersityGWService(cfg)
+	m.users = nil
+	m.buckets = nil
+	m.groups = nil
+	m.selectedUserIndex = 0
+	m.selectedBucketIndex = 0
+
+	m.currentView = MainMenuView
+	m.cursor = 0
+	m.successMessage = fmt.Sprintf("✓ Switched to site %s (%s)", cfg.Site, cfg.EndpointURL)
+	return m, nil
+}
+
+func (m Model) renderSitePicker() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Switch Site") + "\n\n")
+
+	for i, name := range m.root.SiteNames() {
+		endpoint := ""
+		if cfg, err := m.root.ForSite(name); err == nil {
+			endpoint = cfg.EndpointURL
+		}
+		marker := " "
+		if name == m.cfg.Site {
+			marker = "*"
+		}
+		line := fmt.Sprintf("  %s %-16s %s", marker, name, endpoint)
+		if m.cursor == i {
+			s.WriteString(selectedTableRowStyle.Render("> "+strings.TrimPrefix(line, "  ")) + "\n")
+		} else {
+			s.WriteString(line + "\n")
+		}
+	}
+
+	help := helpStyle.Render("↑/↓: Navigate • Enter: Switch • esc: Back")
+	s.WriteString("\n" + help)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render("Error: "+m.errorMessage))
+	}
+
+	return s.String()
+}
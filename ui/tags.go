@@ -0,0 +1,175 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/monobilisim/vgw-manager/models"
+	"github.com/monobilisim/vgw-manager/services"
+)
+
+// initTagForm initializes the bucket tags form with the bucket's current tags
+func (m *Model) initTagForm(bucket models.Bucket) {
+	inputs := make([]textinput.Model, 2)
+
+	// Bucket Name (read-only)
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "Bucket Name"
+	inputs[0].SetValue(bucket.Name)
+	inputs[0].CharLimit = 63
+	inputs[0].Width = 40
+
+	// Tags
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = "key=value,key2=value2 (empty removes all tags)"
+	inputs[1].SetValue(services.FormatTags(bucket.Tags))
+	inputs[1].Focus()
+	inputs[1].CharLimit = 512
+	inputs[1].Width = 60
+
+	m.tagFormInputs = inputs
+	m.focusIndex = 1 // Start focus on Tags
+}
+
+// renderTagForm renders the bucket tags form
+func (m Model) renderTagForm() string {
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Edit Bucket Tags") + "\n\n")
+
+	labels := []string{"Bucket Name:", "Tags:"}
+
+	for i, input := range m.tagFormInputs {
+		label := inputLabelStyle.Render(labels[i])
+		s.WriteString(label + "\n")
+
+		if i == m.focusIndex {
+			s.WriteString(focusedInputStyle.Render(input.View()) + "\n\n")
+		} else {
+			s.WriteString(inputStyle.Render(input.View()) + "\n\n")
+		}
+	}
+
+	saveBtn := "[ Save Tags ]"
+	cancelBtn := "[ Cancel ]"
+
+	if m.focusIndex == len(m.tagFormInputs) {
+		s.WriteString(focusedButtonStyle.Render(saveBtn) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	} else if m.focusIndex == len(m.tagFormInputs)+1 {
+		s.WriteString(buttonStyle.Render(saveBtn) + "  ")
+		s.WriteString(focusedButtonStyle.Render(cancelBtn) + "\n")
+	} else {
+		s.WriteString(buttonStyle.Render(saveBtn) + "  ")
+		s.WriteString(buttonStyle.Render(cancelBtn) + "\n")
+	}
+
+	help := helpStyle.Render("tab: Next field • shift+tab: Previous • enter: Submit/Select • esc: Cancel")
+	s.WriteString("\n" + help)
+
+	info := dimStyle.Render("Tags replace the bucket's existing tag set (e.g. cost-center=42,customer=acme).")
+	s.WriteString("\n" + info)
+
+	if m.errorMessage != "" {
+		s.WriteString("\n" + errorStyle.Render("Error: "+m.errorMessage))
+	}
+	if m.successMessage != "" {
+		s.WriteString("\n" + successStyle.Render(m.successMessage))
+	}
+
+	return s.String()
+}
+
+// updateTagForm handles key events for the bucket tags form
+func (m Model) updateTagForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		m.currentView = m.returnView
+		return m, nil
+
+	case "tab", "down":
+		m.focusIndex++
+		if m.focusIndex > len(m.tagFormInputs)+1 {
+			m.focusIndex = 1
+		}
+		m.updateTagFormFocus()
+		return m, nil
+
+	case "shift+tab", "up":
+		m.focusIndex--
+		if m.focusIndex < 1 {
+			m.focusIndex = len(m.tagFormInputs) + 1
+		}
+		m.updateTagFormFocus()
+		return m, nil
+
+	case "enter":
+		if m.focusIndex == len(m.tagFormInputs)+1 {
+			m.currentView = m.returnView
+			return m, nil
+		}
+		return m.handleSetTags()
+	}
+
+	// The bucket name is fixed; only the tags input accepts text.
+	if m.focusIndex == 1 {
+		m.tagFormInputs[1], cmd = m.tagFormInputs[1].Update(msg)
+	}
+
+	return m, cmd
+}
+
+// updateTagFormFocus updates focus state for tag form inputs
+func (m *Model) updateTagFormFocus() {
+	for i := range m.tagFormInputs {
+		if i == m.focusIndex {
+			m.tagFormInputs[i].Focus()
+		} else {
+			m.tagFormInputs[i].Blur()
+		}
+	}
+}
+
+// handleSetTags replaces the tags of the bucket in the tags form
+func (m Model) handleSetTags() (tea.Model, tea.Cmd) {
+	bucketName := m.tagFormInputs[0].Value()
+
+	tags, err := services.ParseTags(m.tagFormInputs[1].Value())
+	if err != nil {
+		m.errorMessage = err.Error()
+		return m, nil
+	}
+
+	audit := m.audit("bucket.set_tags", bucketName, map[string]any{"tags": services.FormatTags(tags)})
+	err = services.SetBucketTags(context.Background(), m.cfg, bucketName, tags)
+	audit.Finish(err)
+	if err != nil {
+		m.errorMessage = fmt.Sprintf("Failed to set tags: %v", err)
+		return m, nil
+	}
+
+	// Update the local bucket list to reflect the change immediately
+	for i := range m.buckets {
+		if m.buckets[i].Name == bucketName {
+			m.buckets[i].Tags = tags
+			break
+		}
+	}
+
+	if len(tags) == 0 {
+		m.successMessage = fmt.Sprintf("Tags removed from bucket '%s'", bucketName)
+	} else {
+		m.successMessage = fmt.Sprintf("Tags of bucket '%s' set to %s", bucketName, services.FormatTags(tags))
+	}
+	m.currentView = m.returnView
+
+	return m, nil
+}
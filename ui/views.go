@@ -3,6 +3,8 @@ package ui
 import (
 	"fmt"
 	"strings"
+
+	"github.com/monobilisim/vgw-manager/services"
 )
 
 // renderMainMenu renders the main menu
@@ -14,13 +16,15 @@ func (m Model) renderMainMenu() string {
 	subtitle := subtitleStyle.Render("VersityGW Management Tool")
 
 	s.WriteString(title + "\n")
-	s.WriteString(subtitle + "\n\n")
+	s.WriteString(subtitle + "\n")
+	s.WriteString(dimStyle.Render(fmt.Sprintf("Site: %s (%s)", m.cfg.Site, m.cfg.EndpointURL)) + "\n\n")
 
 	// Menu items
 	menuItems := []string{
 		"List Users",
 		"List Buckets",
 		"Operations",
+		"Groups",
 		"Quit",
 	}
 
@@ -35,7 +39,7 @@ func (m Model) renderMainMenu() string {
 	}
 
 	// Help text
-	help := helpStyle.Render("↑/↓: Navigate • Enter: Select • q: Quit")
+	help := helpStyle.Render("↑/↓: Navigate • Enter: Select • S: Switch Site • q: Quit")
 	s.WriteString("\n" + help)
 
 	// Error/Success messages
@@ -143,7 +147,7 @@ func (m Model) renderUsersList() string {
 	s.WriteString("\n" + helpStyle.Render(pageInfo))
 
 	// Help text
-	help := helpStyle.Render("↑/↓: Navigate • ←/→: Page • c: Copy • e: Edit • d: Delete • Enter: View • q: Back")
+	help := helpStyle.Render("↑/↓: Navigate • ←/→: Page • c: Copy • x: Export Config • e: Edit • d: Delete • Enter: View • q: Back")
 	s.WriteString("\n" + help)
 
 	// Error/Success messages
@@ -171,10 +175,10 @@ func (m Model) renderBucketsList() string {
 	}
 
 	// Header (Matched to previous preferred layout)
-	// Name (30) | Mountpoint (40) | Quota (10) | Used (10) | Avail (10) | Owner (15) | Public (8)
-	header := fmt.Sprintf("  %-30s %-40s %-10s %-10s %-10s %-15s %-8s", "Name", "Mountpoint", "Quota", "Used", "Available", "Owner", "Public")
+	// Name (30) | Mountpoint (40) | Quota (10) | Used (10) | Avail (10) | Alert (6) | Owner (15) | Public (8) | Tags (30)
+	header := fmt.Sprintf("  %-30s %-40s %-10s %-10s %-10s %-6s %-15s %-8s %-30s", "Name", "Mountpoint", "Quota", "Used", "Available", "Alert", "Owner", "Public", "Tags")
 	s.WriteString(dimStyle.Render(header) + "\n")
-	s.WriteString(dimStyle.Render(strings.Repeat("-", 134)) + "\n")
+	s.WriteString(dimStyle.Render(strings.Repeat("-", 172)) + "\n")
 
 	for i := start; i < end; i++ {
 		bucket := m.buckets[i]
@@ -200,18 +204,22 @@ func (m Model) renderBucketsList() string {
 		visibility := "Private"
 		if bucket.Public {
 			visibility = "Public"
+		} else if bucket.Error != "" {
+			visibility = "?"
 		}
 
 		// Row content
-		line := fmt.Sprintf("%s %-30s %-40s %-10s %-10s %-10s %-15s %-8s",
+		line := fmt.Sprintf("%s %-30s %-40s %-10s %-10s %-10s %-6s %-15s %-8s %-30s",
 			cursor,
 			trunc(bucket.Name, 30),
 			trunc(bucket.Mountpoint, 40),
 			bucket.Quota,
 			bucket.Used,      // Assuming bucket.Used is already formatted or a string
 			bucket.Available, // Available is already formatted string from service? Let's check service. Assuming yes or string.
+			services.FormatQuotaAlert(bucket),
 			owner,
 			visibility,
+			trunc(services.FormatTags(bucket.Tags), 30),
 		)
 
 		if m.cursor == (i - start) {
@@ -228,8 +236,24 @@ func (m Model) renderBucketsList() string {
 	pageInfo := fmt.Sprintf("Page %d of %d (%d items)", m.page+1, totalPages, len(m.buckets))
 	s.WriteString("\n" + helpStyle.Render(pageInfo))
 
+	incomplete, alerting := 0, 0
+	for _, bucket := range m.buckets {
+		if bucket.Error != "" {
+			incomplete++
+		}
+		if bucket.QuotaAlert > 0 {
+			alerting++
+		}
+	}
+	if incomplete > 0 {
+		s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("%d bucket(s) could not be fully read (?); see details", incomplete)))
+	}
+	if alerting > 0 {
+		s.WriteString("\n" + errorStyle.Render(fmt.Sprintf("%d bucket(s) over a quota alert threshold (Alert)", alerting)))
+	}
+
 	// Help text
-	help := helpStyle.Render("↑/k: Up • ↓/j: Down • ←/h: Prev Page • →/l: Next Page • p: Public • P: Private • d: Delete • enter: Details • esc: Back")
+	help := helpStyle.Render("↑/k: Up • ↓/j: Down • ←/h: Prev Page • →/l: Next Page • p: Public • P: Private • t: Tags • d: Delete • F: Force Delete • enter: Details • esc: Back")
 	s.WriteString("\n" + help)
 
 	// Error/Success messages
@@ -277,8 +301,11 @@ func (m Model) renderUserDetail() string {
 		s.WriteString(tableCellStyle.Render(fmt.Sprintf("%d", user.ProjectID)) + "\n\n")
 	}
 
+	s.WriteString(tableHeaderStyle.Render("Service Accounts") + "\n")
+	s.WriteString(tableCellStyle.Render(m.serviceAccountsSummary()) + "\n\n")
+
 	// Help text
-	help := helpStyle.Render("c: Copy Credentials • esc/q: Back to list")
+	help := helpStyle.Render("c: Copy Credentials • x: Export Client Config • A: Service Accounts • w: Access • esc/q: Back to list")
 	s.WriteString("\n" + help)
 
 	// Error/Success messages
@@ -323,6 +350,11 @@ func (m Model) renderBucketDetail() string {
 	s.WriteString(tableHeaderStyle.Render("Visibility") + "\n")
 	s.WriteString(tableCellStyle.Render(visibility) + "\n\n")
 
+	if bucket.Error != "" {
+		s.WriteString(tableHeaderStyle.Render("Lookup Errors") + "\n")
+		s.WriteString(errorStyle.Render(bucket.Error) + "\n\n")
+	}
+
 	s.WriteString(tableHeaderStyle.Render("Quota") + "\n")
 	s.WriteString(tableCellStyle.Render(bucket.Quota) + "\n\n")
 
@@ -332,8 +364,23 @@ func (m Model) renderBucketDetail() string {
 	s.WriteString(tableHeaderStyle.Render("Available Space") + "\n")
 	s.WriteString(tableCellStyle.Render(bucket.Available) + "\n\n")
 
+	if bucket.QuotaAlert > 0 {
+		s.WriteString(tableHeaderStyle.Render("Quota Alert") + "\n")
+		s.WriteString(errorStyle.Render(fmt.Sprintf("Usage reached %d%% of the quota", bucket.QuotaAlert)) + "\n\n")
+	}
+
+	tags := "-"
+	if len(bucket.Tags) > 0 {
+		tags = strings.ReplaceAll(services.FormatTags(bucket.Tags), ",", "\n")
+	}
+	s.WriteString(tableHeaderStyle.Render("Tags") + "\n")
+	s.WriteString(tableCellStyle.Render(tags) + "\n\n")
+
+	s.WriteString(tableHeaderStyle.Render("Incomplete Multipart Uploads") + "\n")
+	s.WriteString(tableCellStyle.Render(m.bucketUploadsSummary()) + "\n\n")
+
 	// Help text
-	help := helpStyle.Render("esc/q: Back to list")
+	help := helpStyle.Render("o: Browse Objects • r: Usage Report • U: Abort Stale Uploads • F: Force Delete • t: Edit Tags • L: Lifecycle Rules • C: CORS Rules • esc/q: Back to list")
 	s.WriteString("\n" + help)
 
 	// Error/Success messages
@@ -377,6 +424,25 @@ func (m Model) renderConfirmView() string {
 		actionDesc = fmt.Sprintf("Make bucket '%s' PUBLIC?", m.pendingTarget)
 	case "make_private":
 		actionDesc = fmt.Sprintf("Make bucket '%s' PRIVATE?", m.pendingTarget)
+	case "delete_lifecycle_rule":
+		actionDesc = fmt.Sprintf("Delete lifecycle rule '%s' from bucket '%s'?", m.pendingTarget, m.lifecycleBucket)
+	case "delete_cors_rule":
+		actionDesc = fmt.Sprintf("Delete CORS rule #%s from bucket '%s'?", m.pendingTarget, m.corsBucket)
+	case "abort_multipart":
+		actionDesc = fmt.Sprintf("Abort %d incomplete multipart upload(s) older than %s in bucket '%s'?",
+			len(m.staleBucketUploads()), staleUploadAgeLabel, m.pendingTarget)
+	case "revoke_service_account":
+		actionDesc = fmt.Sprintf("Revoke service account '%s'? Its access key stops working immediately.", m.pendingTarget)
+	case "rotate_service_account":
+		actionDesc = fmt.Sprintf("Rotate the secret of service account '%s'? The current secret stops working.", m.pendingTarget)
+	case "delete_group":
+		actionDesc = fmt.Sprintf("Delete group '%s'? Its members lose the access granted through it.", m.pendingTarget)
+	case "remove_group_member":
+		actionDesc = fmt.Sprintf("Remove '%s' from group '%s'?", m.pendingTarget, m.selectedGroup.Name)
+	case "revoke_group_bucket":
+		actionDesc = fmt.Sprintf("Revoke group '%s' access to bucket '%s'?", m.selectedGroup.Name, m.pendingTarget)
+	case "delete_object":
+		actionDesc = fmt.Sprintf("Delete object '%s' from bucket '%s'? This cannot be undone.", m.pendingTarget, m.objectBucket)
 	default:
 		actionDesc = "Perform this action?"
 	}